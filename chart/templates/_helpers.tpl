@@ -53,6 +53,16 @@ app.kubernetes.io/managed-by: {{ .Release.Service }}
 app.kubernetes.io/component: webhook
 {{- end }}
 
+{{- define "harvester-vm-dhcp-agent.labels" -}}
+helm.sh/chart: {{ include "harvester-vm-dhcp-controller.chart" . }}
+{{ include "harvester-vm-dhcp-agent.selectorLabels" . }}
+{{- if .Chart.AppVersion }}
+app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}
+{{- end }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+app.kubernetes.io/component: agent
+{{- end }}
+
 {{/*
 Selector labels
 */}}
@@ -66,6 +76,11 @@ app.kubernetes.io/name: {{ include "harvester-vm-dhcp-controller.name" . }}-webh
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end }}
 
+{{- define "harvester-vm-dhcp-agent.selectorLabels" -}}
+app.kubernetes.io/name: {{ include "harvester-vm-dhcp-controller.name" . }}-agent
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end }}
+
 {{/*
 Create the name of the service account to use
 */}}
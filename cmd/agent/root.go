@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/rancher/wrangler/v3/pkg/kv"
 	"github.com/sirupsen/logrus"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/harvester/vm-dhcp-controller/pkg/agent"
 	"github.com/harvester/vm-dhcp-controller/pkg/config"
+	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
+	"github.com/harvester/vm-dhcp-controller/pkg/metrics"
 	"github.com/harvester/vm-dhcp-controller/pkg/util"
 )
 
@@ -20,11 +23,15 @@ var (
 
 	name               string
 	dryRun             bool
+	dhcpv6             bool
 	nic                string
+	tftpRoots          []string
 	enableCacheDumpAPI bool
 	kubeConfigPath     string
 	kubeContext        string
-	ippoolRef          string
+	ippoolRefs         []string
+	rateLimit          dhcp.RateLimit
+	leaseSnapshotDir   string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -43,16 +50,21 @@ var rootCmd = &cobra.Command{
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		ipPoolNamespace, ipPoolName := kv.RSplit(ippoolRef, "/")
+		ipPools, err := parseIPPools(ippoolRefs, tftpRoots)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		options := &config.AgentOptions{
-			DryRun:         dryRun,
-			Nic:            nic,
-			KubeConfigPath: kubeConfigPath,
-			KubeContext:    kubeContext,
-			IPPoolRef: types.NamespacedName{
-				Namespace: ipPoolNamespace,
-				Name:      ipPoolName,
-			},
+			DryRun:           dryRun,
+			DHCPv6:           dhcpv6,
+			KubeConfigPath:   kubeConfigPath,
+			KubeContext:      kubeContext,
+			IPPools:          ipPools,
+			RateLimit:        rateLimit,
+			LeaseSnapshotDir: leaseSnapshotDir,
+			MetricsAllocator: metrics.NewMetricsAllocator(),
 		}
 
 		if err := run(options); err != nil {
@@ -73,9 +85,71 @@ func init() {
 	rootCmd.Flags().StringVar(&kubeConfigPath, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to the kubeconfig file")
 	rootCmd.Flags().StringVar(&kubeContext, "kubecontext", os.Getenv("KUBECONTEXT"), "Context name")
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Run vm-dhcp-agent without starting the DHCP server")
+	rootCmd.Flags().BoolVar(&dhcpv6, "dhcpv6", false, "Serve DHCPv6 alongside DHCPv4")
 	rootCmd.Flags().BoolVar(&enableCacheDumpAPI, "enable-cache-dump-api", false, "Enable cache dump APIs")
-	rootCmd.Flags().StringVar(&ippoolRef, "ippool-ref", os.Getenv("IPPOOL_REF"), "The IPPool object the agent should sync with")
-	rootCmd.Flags().StringVar(&nic, "nic", agent.DefaultNetworkInterface, "The network interface the embedded DHCP server listens on")
+	rootCmd.Flags().StringArrayVar(&ippoolRefs, "ippool-ref", envStrings("IPPOOL_REF"), "The IPPool object the agent should sync with, as <namespace>/<name>[=<nic>]. Repeat to serve multiple IPPools")
+	rootCmd.Flags().StringVar(&nic, "nic", agent.DefaultNetworkInterface, "The network interface the embedded DHCP server listens on if not given by --ippool-ref")
+	rootCmd.Flags().Float64Var(&rateLimit.ClientRate, "client-rate-limit", dhcp.DefaultRateLimit.ClientRate, "DHCP packets per second served to each client, 0 for no limit")
+	rootCmd.Flags().IntVar(&rateLimit.ClientBurst, "client-rate-burst", dhcp.DefaultRateLimit.ClientBurst, "DHCP packets each client may send at once above --client-rate-limit")
+	rootCmd.Flags().Float64Var(&rateLimit.GlobalRate, "global-rate-limit", dhcp.DefaultRateLimit.GlobalRate, "DHCP packets per second served by the agent, 0 for no limit")
+	rootCmd.Flags().IntVar(&rateLimit.GlobalBurst, "global-rate-burst", dhcp.DefaultRateLimit.GlobalBurst, "DHCP packets the agent may receive at once above --global-rate-limit")
+	rootCmd.Flags().StringVar(&leaseSnapshotDir, "lease-snapshot-dir", "", "Keep a snapshot of the leases in this directory and serve them on startup until the IPPools are synced, none if empty")
+	rootCmd.Flags().StringArrayVar(&tftpRoots, "tftp-root", nil, "Serve the files in this directory over TFTP on the same network interface, as [<nic>=]<path>")
+}
+
+func envStrings(key string) []string {
+	if value := os.Getenv(key); value != "" {
+		return []string{value}
+	}
+	return nil
+}
+
+// parseIPPools maps the IPPools to serve to the network interfaces of the
+// agent. An IPPool or TFTP root without a network interface is served on the
+// one given by --nic. Each TFTP root must be served on the network interface
+// of an IPPool.
+func parseIPPools(refs, tftpRoots []string) ([]config.AgentIPPool, error) {
+	roots := make(map[string]string, len(tftpRoots))
+	rootNics := make([]string, 0, len(tftpRoots))
+	for _, tftpRoot := range tftpRoots {
+		rootNic, path := nic, tftpRoot
+		if k, v, ok := strings.Cut(tftpRoot, "="); ok {
+			rootNic, path = k, v
+		}
+		roots[rootNic] = path
+		rootNics = append(rootNics, rootNic)
+	}
+
+	ipPools := make([]config.AgentIPPool, 0, len(refs))
+	nics := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		poolNic := nic
+		if k, v, ok := strings.Cut(ref, "="); ok {
+			ref, poolNic = k, v
+		}
+		if other, ok := nics[poolNic]; ok {
+			return nil, fmt.Errorf("ippools %s and %s are both served on nic %s", other, ref, poolNic)
+		}
+		nics[poolNic] = ref
+
+		ipPoolNamespace, ipPoolName := kv.RSplit(ref, "/")
+		ipPools = append(ipPools, config.AgentIPPool{
+			Nic: poolNic,
+			IPPoolRef: types.NamespacedName{
+				Namespace: ipPoolNamespace,
+				Name:      ipPoolName,
+			},
+			TFTPRoot: roots[poolNic],
+		})
+	}
+
+	for _, rootNic := range rootNics {
+		if _, ok := nics[rootNic]; !ok {
+			return nil, fmt.Errorf("tftp root %s is served on nic %s, which serves no ippool", roots[rootNic], rootNic)
+		}
+	}
+
+	return ipPools, nil
 }
 
 // execute adds all child commands to the root command and sets flags appropriately.
@@ -21,8 +21,10 @@ func run(options *config.AgentOptions) error {
 	agent := agent.NewAgent(options)
 
 	httpServerOptions := config.HTTPServerOptions{
-		DebugMode:     enableCacheDumpAPI,
-		DHCPAllocator: agent.DHCPAllocator,
+		DebugMode:        enableCacheDumpAPI,
+		DHCPAllocator:    agent.DHCPAllocator,
+		MetricsAllocator: options.MetricsAllocator,
+		Ready:            agent.Ready,
 	}
 	s := server.NewHTTPServer(&httpServerOptions)
 	s.RegisterAgentHandlers()
@@ -23,6 +23,9 @@ var (
 	agentNamespace          string
 	agentImage              string
 	agentServiceAccountName string
+	agentPlacement          string
+	agentLeaseSnapshot      string
+	agentLeaseSnapshotClaim string
 	noDHCP                  bool
 )
 
@@ -53,12 +56,33 @@ var rootCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		if agentPlacement != config.AgentPlacementIPPool && agentPlacement != config.AgentPlacementClusterNetwork {
+			fmt.Fprintf(os.Stderr, "Error: invalid agent placement %q\n", agentPlacement)
+			os.Exit(1)
+		}
+
+		switch agentLeaseSnapshot {
+		case config.AgentLeaseSnapshotNone, config.AgentLeaseSnapshotEmptyDir, config.AgentLeaseSnapshotHostPath:
+		case config.AgentLeaseSnapshotPVC:
+			if agentLeaseSnapshotClaim == "" {
+				fmt.Fprintf(os.Stderr, "Error: agent lease snapshot %q needs a claim name\n", agentLeaseSnapshot)
+				os.Exit(1)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Error: invalid agent lease snapshot %q\n", agentLeaseSnapshot)
+			os.Exit(1)
+		}
+
 		options := &config.ControllerOptions{
 			NoAgent:                 noAgent,
 			AgentNamespace:          agentNamespace,
 			AgentImage:              image,
 			AgentServiceAccountName: agentServiceAccountName,
+			AgentPlacement:          agentPlacement,
 			NoDHCP:                  noDHCP,
+
+			AgentLeaseSnapshot:          agentLeaseSnapshot,
+			AgentLeaseSnapshotClaimName: agentLeaseSnapshotClaim,
 		}
 
 		if err := run(options); err != nil {
@@ -83,6 +107,9 @@ func init() {
 	rootCmd.Flags().StringVar(&agentNamespace, "namespace", os.Getenv("AGENT_NAMESPACE"), "The namespace for the spawned agents")
 	rootCmd.Flags().StringVar(&agentImage, "image", os.Getenv("AGENT_IMAGE"), "The container image for the spawned agents")
 	rootCmd.Flags().StringVar(&agentServiceAccountName, "service-account-name", os.Getenv("AGENT_SERVICE_ACCOUNT_NAME"), "The service account for the spawned agents")
+	rootCmd.Flags().StringVar(&agentPlacement, "agent-placement", config.AgentPlacementIPPool, "How IPPools are assigned to the spawned agents: \"ippool\" for one agent per IPPool, \"clusternetwork\" for one agent per cluster network")
+	rootCmd.Flags().StringVar(&agentLeaseSnapshot, "agent-lease-snapshot", config.AgentLeaseSnapshotEmptyDir, "Where the spawned agents keep their lease snapshots: \"emptydir\" for the lifetime of the agent pod, \"hostpath\" on the node, \"pvc\" on the persistent volume claimed by --agent-lease-snapshot-claim, or \"none\"")
+	rootCmd.Flags().StringVar(&agentLeaseSnapshotClaim, "agent-lease-snapshot-claim", "", "The persistent volume claim in the agent namespace the spawned agents keep their lease snapshots on, which must be ReadWriteMany")
 }
 
 // execute adds all child commands to the root command and sets flags appropriately.
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -10,72 +11,158 @@ import (
 	"github.com/harvester/vm-dhcp-controller/pkg/agent/ippool"
 	"github.com/harvester/vm-dhcp-controller/pkg/config"
 	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
+	"github.com/harvester/vm-dhcp-controller/pkg/tftp"
 )
 
 const DefaultNetworkInterface = "eth1"
 
 type Agent struct {
-	dryRun  bool
+	dryRun bool
+	dhcpv6 bool
+	pools  []*agentIPPool
+
+	DHCPAllocator *dhcp.DHCPAllocator
+}
+
+// agentIPPool holds everything needed to serve a single IPPool on one of the
+// network interfaces of the agent.
+type agentIPPool struct {
 	nic     string
 	poolRef types.NamespacedName
 
 	ippoolEventHandler *ippool.EventHandler
-	DHCPAllocator      *dhcp.DHCPAllocator
+	tftpServer         *tftp.Server
 	poolCache          map[string]string
 }
 
 func NewAgent(options *config.AgentOptions) *Agent {
 	dhcpAllocator := dhcp.NewDHCPAllocator()
-	poolCache := make(map[string]string, 10)
+	dhcpAllocator.SetRateLimit(options.RateLimit)
+
+	pools := make([]*agentIPPool, 0, len(options.IPPools))
+	for _, p := range options.IPPools {
+		poolCache := make(map[string]string, 10)
+
+		var tftpServer *tftp.Server
+		if p.TFTPRoot != "" {
+			tftpServer = tftp.NewServer(p.TFTPRoot, p.IPPoolRef.String(), options.MetricsAllocator)
+		}
+
+		pools = append(pools, &agentIPPool{
+			nic:        p.Nic,
+			poolRef:    p.IPPoolRef,
+			tftpServer: tftpServer,
+			ippoolEventHandler: ippool.NewEventHandler(
+				options.KubeConfigPath,
+				options.KubeContext,
+				nil,
+				p.IPPoolRef,
+				p.Nic,
+				dhcpAllocator,
+				poolCache,
+				options.LeaseSnapshotDir,
+				options.MetricsAllocator,
+			),
+			poolCache: poolCache,
+		})
+	}
+
+	if metricsAllocator := options.MetricsAllocator; metricsAllocator != nil {
+		poolNames := make(map[string]string, len(options.IPPools))
+		for _, p := range options.IPPools {
+			poolNames[p.Nic] = p.IPPoolRef.String()
+		}
+		dhcpAllocator.OnPacketDropped(func(nic string, reason dhcp.DropReason) {
+			metricsAllocator.IncDHCPDroppedPackets(poolNames[nic], string(reason))
+		})
+		dhcpAllocator.SetMessageHooks(dhcp.MessageHooks{
+			Received: func(nic, messageType string) {
+				metricsAllocator.IncDHCPMessages(poolNames[nic], messageType)
+			},
+			Replied: func(nic, messageType string, latency time.Duration) {
+				metricsAllocator.ObserveDHCPReply(poolNames[nic], messageType, latency)
+			},
+			UnknownClient: func(nic string) {
+				metricsAllocator.IncDHCPUnknownClientMessages(poolNames[nic])
+			},
+		})
+	}
 
 	return &Agent{
-		dryRun:  options.DryRun,
-		nic:     options.Nic,
-		poolRef: options.IPPoolRef,
+		dryRun: options.DryRun,
+		dhcpv6: options.DHCPv6,
+		pools:  pools,
 
 		DHCPAllocator: dhcpAllocator,
-		ippoolEventHandler: ippool.NewEventHandler(
-			options.KubeConfigPath,
-			options.KubeContext,
-			nil,
-			options.IPPoolRef,
-			dhcpAllocator,
-			poolCache,
-		),
-		poolCache: poolCache,
 	}
 }
 
-func (a *Agent) Run(ctx context.Context) error {
-	logrus.Infof("monitor ippool %s", a.poolRef.String())
+// Ready reports whether the leases of all the IPPools have been loaded, i.e.
+// whether the agent actually serves them.
+func (a *Agent) Ready() bool {
+	for _, pool := range a.pools {
+		if !pool.ippoolEventHandler.Synced() {
+			return false
+		}
+	}
+	return true
+}
 
+func (a *Agent) Run(ctx context.Context) error {
 	eg, egctx := errgroup.WithContext(ctx)
 
-	eg.Go(func() error {
-		if a.dryRun {
-			return a.DHCPAllocator.DryRun(egctx, a.nic)
+	var errChs []<-chan error
+
+	for _, pool := range a.pools {
+		logrus.Infof("monitor ippool %s on nic %s", pool.poolRef.String(), pool.nic)
+
+		eg.Go(func() error {
+			if a.dryRun {
+				return a.DHCPAllocator.DryRun(egctx, pool.nic)
+			}
+			if err := a.DHCPAllocator.Run(egctx, pool.nic); err != nil {
+				return err
+			}
+			if a.dhcpv6 {
+				return a.DHCPAllocator.Run6(egctx, pool.nic)
+			}
+			return nil
+		})
+
+		if pool.tftpServer != nil && !a.dryRun {
+			eg.Go(func() error {
+				return pool.tftpServer.Run(egctx, pool.nic)
+			})
 		}
-		return a.DHCPAllocator.Run(egctx, a.nic)
-	})
 
-	eg.Go(func() error {
-		if err := a.ippoolEventHandler.Init(); err != nil {
-			return err
+		eg.Go(func() error {
+			if err := pool.ippoolEventHandler.Init(); err != nil {
+				return err
+			}
+			if !a.dryRun {
+				go pool.ippoolEventHandler.DetectRogueServers(egctx)
+			}
+			pool.ippoolEventHandler.EventListener(egctx)
+			return nil
+		})
+
+		errChs = append(errChs, dhcp.Cleanup(egctx, a.DHCPAllocator, pool.nic))
+		if pool.tftpServer != nil {
+			errChs = append(errChs, tftp.Cleanup(egctx, pool.tftpServer))
 		}
-		a.ippoolEventHandler.EventListener(egctx)
-		return nil
-	})
-
-	errCh := dhcp.Cleanup(egctx, a.DHCPAllocator, a.nic)
+	}
 
 	if err := eg.Wait(); err != nil {
 		return err
 	}
 
 	// Return cleanup error message if any
-	if err := <-errCh; err != nil {
-		return err
+	var cleanupErr error
+	for _, errCh := range errChs {
+		if err := <-errCh; err != nil && cleanupErr == nil {
+			cleanupErr = err
+		}
 	}
 
-	return nil
+	return cleanupErr
 }
@@ -1,6 +1,8 @@
 package ippool
 
 import (
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,6 +14,7 @@ import (
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
+	"github.com/harvester/vm-dhcp-controller/pkg/metrics"
 )
 
 type Controller struct {
@@ -21,8 +24,24 @@ type Controller struct {
 	informer cache.Controller
 
 	poolRef       types.NamespacedName
+	nic           string
 	dhcpAllocator *dhcp.DHCPAllocator
 	poolCache     map[string]string
+	pool6Cache    map[string]networkv1.IPv6Binding
+
+	// The IPPool configuration and overrides the leases were last built of
+	ipv4Config *networkv1.IPv4Config
+	ipv6Config *networkv1.IPv6Config
+	overrides  map[string]networkv1.LeaseOverride
+
+	// The MAC addresses whose lease could not be added or updated
+	skipped map[string]struct{}
+
+	synced   *atomic.Bool
+	retired  *atomic.Bool
+	snapshot *snapshotter
+
+	metricsAllocator *metrics.MetricsAllocator
 }
 
 func NewController(
@@ -30,8 +49,13 @@ func NewController(
 	indexer cache.Indexer,
 	informer cache.Controller,
 	poolRef types.NamespacedName,
+	nic string,
 	dhcpAllocator *dhcp.DHCPAllocator,
 	poolCache map[string]string,
+	synced *atomic.Bool,
+	retired *atomic.Bool,
+	snapshot *snapshotter,
+	metricsAllocator *metrics.MetricsAllocator,
 ) *Controller {
 	return &Controller{
 		stopCh:        make(chan struct{}),
@@ -39,8 +63,15 @@ func NewController(
 		indexer:       indexer,
 		queue:         queue,
 		poolRef:       poolRef,
+		nic:           nic,
 		dhcpAllocator: dhcpAllocator,
 		poolCache:     poolCache,
+		pool6Cache:    make(map[string]networkv1.IPv6Binding),
+		synced:        synced,
+		retired:       retired,
+		snapshot:      snapshot,
+
+		metricsAllocator: metricsAllocator,
 	}
 }
 
@@ -76,20 +107,65 @@ func (c *Controller) sync(event Event) (err error) {
 	}
 
 	switch event.action {
-	case UPDATE:
+	case ADD, UPDATE:
 		ipPool, ok := obj.(*networkv1.IPPool)
 		if !ok {
-			logrus.Error("(controller.sync) failed to assert obj during UPDATE")
+			logrus.Errorf("(controller.sync) failed to assert obj during %s", strings.ToUpper(event.action))
+			return
+		}
+		logrus.Infof("(controller.sync) %s %s/%s", strings.ToUpper(event.action), ipPool.Namespace, ipPool.Name)
+		if ipPool.Spec.Paused != nil && *ipPool.Spec.Paused {
+			if err = c.retire(); err != nil {
+				logrus.Errorf("(controller.sync) failed to clear DHCP lease store: %s", err.Error())
+				return
+			}
+			updateLeaseMetrics(c.metricsAllocator, c.poolRef, c.nic, c.dhcpAllocator)
+			c.snapshot.remove()
+			return
 		}
-		logrus.Infof("(controller.sync) UPDATE %s/%s", ipPool.Namespace, ipPool.Name)
-		if err := c.Update(ipPool); err != nil {
+		c.retired.Store(false)
+		// Update filters the allocated addresses in place
+		snapshotPool := ipPool.DeepCopy()
+		if err = c.Update(ipPool); err != nil {
 			logrus.Errorf("(controller.sync) failed to update DHCP lease store: %s", err.Error())
+			return
+		}
+		updateLeaseMetrics(c.metricsAllocator, c.poolRef, c.nic, c.dhcpAllocator)
+		if c.synced.Load() {
+			c.snapshot.setIPPool(snapshotPool)
 		}
+		if c.synced.Load() {
+			if c.metricsAllocator != nil {
+				c.metricsAllocator.UpdateIPPoolLastSync(c.poolRef.String(), time.Now())
+			}
+		}
+	case DELETE:
+		logrus.Infof("(controller.sync) DELETE %s", event.key)
+		if err = c.retire(); err != nil {
+			logrus.Errorf("(controller.sync) failed to clear DHCP lease store: %s", err.Error())
+			return
+		}
+		updateLeaseMetrics(c.metricsAllocator, c.poolRef, c.nic, c.dhcpAllocator)
+		c.snapshot.remove()
 	}
 
 	return
 }
 
+// restore builds the leases out of the snapshot of a former run. The leases
+// are not considered synced until the IPPool is listed from the API server,
+// which then reconciles any drift.
+func (c *Controller) restore(snap *snapshot) error {
+	logrus.Infof("(controller.restore) restore leases of %s from snapshot", c.poolRef.String())
+	if err := c.Update(snap.IPPool); err != nil {
+		c.synced.Store(false)
+		return err
+	}
+	c.synced.Store(false)
+	c.dhcpAllocator.RestoreLeaseRecords(c.nic, snap.Leases)
+	return nil
+}
+
 func (c *Controller) handleErr(err error, key interface{}) {
 	if err == nil {
 		c.queue.Forget(key.(Event))
@@ -123,6 +199,12 @@ func (c *Controller) Run(workers int) {
 		return
 	}
 
+	// The IPPool may have been deleted while the agent was down
+	if _, exists, err := c.indexer.GetByKey(c.poolRef.String()); err == nil && !exists {
+		logrus.Infof("(controller.Run) IPPool %s does not exist anymore", c.poolRef.String())
+		c.snapshot.remove()
+	}
+
 	for i := 0; i < workers; i++ {
 		go wait.Until(c.runWorker, time.Second, c.stopCh)
 	}
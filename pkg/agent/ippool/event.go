@@ -2,19 +2,27 @@ package ippool
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
 	clientset "github.com/harvester/vm-dhcp-controller/pkg/generated/clientset/versioned"
+	"github.com/harvester/vm-dhcp-controller/pkg/generated/clientset/versioned/scheme"
+	"github.com/harvester/vm-dhcp-controller/pkg/metrics"
 	"github.com/harvester/vm-dhcp-controller/pkg/util"
 )
 
@@ -22,6 +30,8 @@ const (
 	ADD    = "add"
 	UPDATE = "update"
 	DELETE = "delete"
+
+	eventSource = "vm-dhcp-agent"
 )
 
 type EventHandler struct {
@@ -29,10 +39,24 @@ type EventHandler struct {
 	kubeContext    string
 	kubeRestConfig *rest.Config
 	k8sClientset   *clientset.Clientset
+	recorder       record.EventRecorder
 
 	poolRef       types.NamespacedName
+	nic           string
 	dhcpAllocator *dhcp.DHCPAllocator
 	poolCache     map[string]string
+	snapshot      *snapshotter
+
+	metricsAllocator *metrics.MetricsAllocator
+
+	// Whether the lease store is in line with the allocated addresses of
+	// the IPPool
+	synced atomic.Bool
+	// Whether the IPPool is deleted or paused, and no longer served
+	retired atomic.Bool
+	// DHCPServing condition last reported by the health probe, and when
+	lastHealthStatus corev1.ConditionStatus
+	lastHealthUpdate time.Time
 }
 
 type Event struct {
@@ -47,16 +71,23 @@ func NewEventHandler(
 	kubeContext string,
 	kubeRestConfig *rest.Config,
 	poolRef types.NamespacedName,
+	nic string,
 	dhcpAllocator *dhcp.DHCPAllocator,
 	poolCache map[string]string,
+	snapshotDir string,
+	metricsAllocator *metrics.MetricsAllocator,
 ) *EventHandler {
 	return &EventHandler{
 		kubeConfig:     kubeConfig,
 		kubeContext:    kubeContext,
 		kubeRestConfig: kubeRestConfig,
 		poolRef:        poolRef,
+		nic:            nic,
 		dhcpAllocator:  dhcpAllocator,
 		poolCache:      poolCache,
+		snapshot:       newSnapshotter(snapshotDir, poolRef, nic, dhcpAllocator),
+
+		metricsAllocator: metricsAllocator,
 	}
 }
 
@@ -71,6 +102,14 @@ func (e *EventHandler) Init() (err error) {
 		return
 	}
 
+	kubeClient, err := kubernetes.NewForConfig(e.kubeRestConfig)
+	if err != nil {
+		return
+	}
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	e.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: eventSource})
+
 	return
 }
 
@@ -91,7 +130,7 @@ func (e *EventHandler) getKubeConfig() (config *rest.Config, err error) {
 }
 
 func (e *EventHandler) EventListener(ctx context.Context) {
-	logrus.Info("(eventhandler.EventListener) starting IPPool event listener")
+	logrus.Infof("(eventhandler.EventListener) starting IPPool event listener for %s on nic %s", e.poolRef.String(), e.nic)
 
 	// TODO: could be more specific on what namespaces we want to watch and what fields we need
 	watcher := cache.NewListWatchFromClient(e.k8sClientset.NetworkV1alpha1().RESTClient(), "ippools", e.poolRef.Namespace, fields.Everything())
@@ -103,6 +142,19 @@ func (e *EventHandler) EventListener(ctx context.Context) {
 		ObjectType:    &networkv1.IPPool{},
 		ResyncPeriod:  0,
 		Handler: cache.ResourceEventHandlerFuncs{
+			// Load the leases of the initial list, e.g. after the agent
+			// restarted
+			AddFunc: func(obj interface{}) {
+				key, err := cache.MetaNamespaceKeyFunc(obj)
+				if err == nil {
+					queue.Add(Event{
+						key:             key,
+						action:          ADD,
+						poolName:        obj.(*networkv1.IPPool).Name,
+						poolNetworkName: obj.(*networkv1.IPPool).Spec.NetworkName,
+					})
+				}
+			},
 			UpdateFunc: func(old interface{}, new interface{}) {
 				key, err := cache.MetaNamespaceKeyFunc(new)
 				if err == nil {
@@ -114,16 +166,51 @@ func (e *EventHandler) EventListener(ctx context.Context) {
 					})
 				}
 			},
+			// Drop the lease snapshot of the IPPool
+			DeleteFunc: func(obj interface{}) {
+				key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+				if err != nil {
+					return
+				}
+				_, name, err := cache.SplitMetaNamespaceKey(key)
+				if err == nil {
+					queue.Add(Event{
+						key:      key,
+						action:   DELETE,
+						poolName: name,
+					})
+				}
+			},
 		},
 		Indexers: cache.Indexers{},
 	})
 
-	controller := NewController(queue, indexer.(cache.Indexer), informer, e.poolRef, e.dhcpAllocator, e.poolCache)
+	controller := NewController(queue, indexer.(cache.Indexer), informer, e.poolRef, e.nic, e.dhcpAllocator, e.poolCache, &e.synced, &e.retired, e.snapshot, e.metricsAllocator)
+
+	// Serve the leases of the former run until the IPPool is listed, in case
+	// the API server is not reachable
+	if snap, err := e.snapshot.load(); err != nil {
+		logrus.Warnf("(eventhandler.EventListener) skip lease snapshot of ippool %s: %v", e.poolRef.String(), err)
+	} else if snap != nil {
+		if err := controller.restore(snap); err != nil {
+			logrus.Errorf("(eventhandler.EventListener) failed to restore lease snapshot of ippool %s: %v", e.poolRef.String(), err)
+		}
+	}
 
 	go controller.Run(1)
 
+	go e.reportLeaseStates(ctx)
+
+	go e.probeHealth(ctx)
+
 	<-ctx.Done()
 	controller.Stop()
 
 	logrus.Info("(eventhandler.Run) IPPool event listener terminated")
 }
+
+// Synced reports whether the leases of the IPPool have been loaded and match
+// its allocated addresses.
+func (e *EventHandler) Synced() bool {
+	return e.synced.Load()
+}
@@ -0,0 +1,103 @@
+package ippool
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
+)
+
+const (
+	healthProbeInterval = time.Minute
+	// Minimum time between two updates of the health probe status of an
+	// IPPool while the DHCPServing condition does not change
+	healthStatusMinInterval = 10 * time.Minute
+)
+
+// probeHealth runs the synthetic DHCP health probe every healthProbeInterval
+// once the leases have been loaded, and reports the result as the DHCPServing
+// condition and the health probe status of the IPPool. The latency of every
+// successful probe also goes to the metrics. A retired IPPool is not probed.
+func (e *EventHandler) probeHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !e.Synced() || e.retired.Load() {
+			continue
+		}
+
+		latency, probeErr := e.dhcpAllocator.ProbeHealth(ctx, e.nic)
+		if probeErr != nil {
+			logrus.Warnf("(eventhandler.probeHealth) health probe of ippool %s on nic %s failed: %v", e.poolRef.String(), e.nic, probeErr)
+		} else {
+			logrus.Debugf("(eventhandler.probeHealth) health probe of ippool %s on nic %s took %s", e.poolRef.String(), e.nic, latency)
+			if e.metricsAllocator != nil {
+				e.metricsAllocator.ObserveDHCPHealthProbe(e.poolRef.String(), latency, time.Now())
+			}
+		}
+
+		if err := e.updateHealthStatus(ctx, latency, probeErr); err != nil {
+			logrus.Errorf("(eventhandler.probeHealth) failed to update health status of ippool %s: %v", e.poolRef.String(), err)
+		}
+	}
+}
+
+// updateHealthStatus sets the DHCPServing condition and the health probe
+// status of the IPPool according to the result of the probe. As every update
+// makes the agent and the controller sync the IPPool again, it is only
+// updated when the condition flips or healthStatusMinInterval after the last
+// update.
+func (e *EventHandler) updateHealthStatus(ctx context.Context, latency time.Duration, probeErr error) error {
+	status := corev1.ConditionTrue
+	if probeErr != nil {
+		status = corev1.ConditionFalse
+	}
+	now := time.Now()
+	if e.lastHealthStatus == status && now.Sub(e.lastHealthUpdate) < healthStatusMinInterval {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		ipPool, err := e.k8sClientset.NetworkV1alpha1().IPPools(e.poolRef.Namespace).Get(ctx, e.poolRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		ipPoolCpy := ipPool.DeepCopy()
+		if probeErr != nil {
+			setDHCPServingCondition(ipPoolCpy, status, "ProbeFailed", probeErr.Error())
+		} else {
+			lastSuccess := metav1.NewTime(now)
+			ipPoolCpy.Status.HealthProbe = &networkv1.HealthProbeStatus{
+				LastSuccessTime: &lastSuccess,
+				Latency:         &metav1.Duration{Duration: latency},
+			}
+			setDHCPServingCondition(ipPoolCpy, status, "", "")
+		}
+
+		if _, err := e.k8sClientset.NetworkV1alpha1().IPPools(e.poolRef.Namespace).UpdateStatus(ctx, ipPoolCpy, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		e.lastHealthStatus = status
+		e.lastHealthUpdate = now
+		return nil
+	})
+}
+
+func setDHCPServingCondition(ipPool *networkv1.IPPool, status corev1.ConditionStatus, reason, message string) {
+	networkv1.DHCPServing.SetStatus(ipPool, string(status))
+	networkv1.DHCPServing.Reason(ipPool, reason)
+	networkv1.DHCPServing.Message(ipPool, message)
+}
@@ -1,47 +1,92 @@
 package ippool
 
 import (
+	"reflect"
+
 	"github.com/sirupsen/logrus"
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
+	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
 	"github.com/harvester/vm-dhcp-controller/pkg/util"
 )
 
 func (c *Controller) Update(ipPool *networkv1.IPPool) error {
 	if !networkv1.CacheReady.IsTrue(ipPool) {
 		logrus.Warningf("ippool %s/%s is not ready", ipPool.Namespace, ipPool.Name)
+		c.synced.Store(false)
 		return nil
 	}
 	if ipPool.Status.IPv4 == nil {
 		logrus.Warningf("ippool %s/%s status has no records", ipPool.Namespace, ipPool.Name)
+		c.synced.Store(false)
 		return nil
 	}
 	allocated := ipPool.Status.IPv4.Allocated
+	reserved := reservedIPs(allocated)
 	filterExcludedAndReserved(allocated)
-	return c.updatePoolCacheAndLeaseStore(allocated, ipPool.Spec.IPv4Config)
+	if err := c.updatePoolCacheAndLeaseStore(allocated, ipPool.Spec.IPv4Config, ipPool.Status.IPv4.Overrides); err != nil {
+		c.synced.Store(false)
+		return err
+	}
+	c.synced.Store(c.leaseStoreSynced(allocated))
+	if err := c.dhcpAllocator.SetHealthProbeLease(c.nic, ipPool.Spec.IPv4Config.ServerIP, ipPool.Spec.IPv4Config.CIDR); err != nil {
+		return err
+	}
+	if err := c.updateDynamicRange(ipPool.Spec.IPv4Config, reserved); err != nil {
+		return err
+	}
+	c.dhcpAllocator.SetClientClasses(c.nic, clientClasses(ipPool.Spec.IPv4Config.Classes))
+	if ipPool.Spec.IPv6Config == nil {
+		return c.clearPool6CacheAndLeaseStore()
+	}
+	var allocated6 map[string]networkv1.IPv6Binding
+	if ipPool.Status.IPv6 != nil {
+		allocated6 = ipPool.Status.IPv6.Allocated
+	}
+	return c.updatePool6CacheAndLeaseStore(allocated6, *ipPool.Spec.IPv6Config)
 }
 
-func (c *Controller) updatePoolCacheAndLeaseStore(latest map[string]string, ipv4Config networkv1.IPv4Config) error {
-	for ip, mac := range c.poolCache {
-		if newMAC, exists := latest[ip]; exists {
-			if mac != newMAC {
-				logrus.Infof("set %s with new value %s", ip, newMAC)
-				// TODO: update lease
-				c.poolCache[ip] = newMAC
-			}
-		} else {
+// updatePoolCacheAndLeaseStore brings the leases in line with the allocated
+// addresses and the IPPool configuration. The leases are keyed by MAC address,
+// so an address handed over to another MAC address moves to a new lease, and
+// a changed configuration or override is applied to the existing leases in
+// place. A lease which cannot be added or updated is skipped, and retried the
+// next time, so it does not hold up the others.
+func (c *Controller) updatePoolCacheAndLeaseStore(
+	latest map[string]string,
+	ipv4Config networkv1.IPv4Config,
+	overrides map[string]networkv1.LeaseOverride,
+) error {
+	cached := byMAC(c.poolCache)
+	wanted := byMAC(latest)
+
+	for mac, ip := range cached {
+		if _, exists := wanted[mac]; !exists {
 			logrus.Infof("remove %s", ip)
-			if err := c.dhcpAllocator.DeleteLease(c.poolCache[ip]); err != nil {
+			if err := c.dhcpAllocator.DeleteLease(c.nic, mac); err != nil {
 				return err
 			}
 			delete(c.poolCache, ip)
 		}
 	}
 
-	for newIP, newMAC := range latest {
-		if _, exists := c.poolCache[newIP]; !exists {
+	configChanged := c.ipv4Config == nil || !reflect.DeepEqual(*c.ipv4Config, ipv4Config)
+
+	// Release the client identifiers which changed hands first, as they can
+	// only be held by one lease at a time
+	for mac := range wanted {
+		if _, exists := cached[mac]; exists && c.overrides[mac].ClientID != overrides[mac].ClientID {
+			c.dhcpAllocator.ReleaseClientID(c.nic, mac)
+		}
+	}
+
+	skipped := make(map[string]struct{})
+	for newMAC, newIP := range wanted {
+		ip, exists := cached[newMAC]
+		if !exists {
 			logrus.Infof("add %s with value %s", newIP, newMAC)
 			if err := c.dhcpAllocator.AddLease(
+				c.nic,
 				newMAC,
 				ipv4Config.ServerIP,
 				newIP,
@@ -52,19 +97,323 @@ func (c *Controller) updatePoolCacheAndLeaseStore(latest map[string]string, ipv4
 				ipv4Config.DomainSearch,
 				ipv4Config.NTP,
 				ipv4Config.LeaseTime,
+				leaseOptions(ipv4Config, overrides[newMAC])...,
 			); err != nil {
-				return err
+				logrus.Errorf("(ippool.updatePoolCacheAndLeaseStore) skip lease of %s: %v", newMAC, err)
+				skipped[newMAC] = struct{}{}
+				continue
 			}
 			c.poolCache[newIP] = newMAC
+			continue
 		}
+
+		_, retry := c.skipped[newMAC]
+		if ip == newIP && !configChanged && !retry && reflect.DeepEqual(c.overrides[newMAC], overrides[newMAC]) {
+			continue
+		}
+
+		logrus.Infof("update %s with value %s", newIP, newMAC)
+		if err := c.dhcpAllocator.UpdateLease(
+			c.nic,
+			newMAC,
+			ipv4Config.ServerIP,
+			newIP,
+			ipv4Config.CIDR,
+			ipv4Config.Router,
+			ipv4Config.DNS,
+			ipv4Config.DomainName,
+			ipv4Config.DomainSearch,
+			ipv4Config.NTP,
+			ipv4Config.LeaseTime,
+			leaseOptions(ipv4Config, overrides[newMAC])...,
+		); err != nil {
+			logrus.Errorf("(ippool.updatePoolCacheAndLeaseStore) skip lease of %s: %v", newMAC, err)
+			skipped[newMAC] = struct{}{}
+			continue
+		}
+		if c.poolCache[ip] == newMAC {
+			delete(c.poolCache, ip)
+		}
+		c.poolCache[newIP] = newMAC
+	}
+
+	c.ipv4Config = ipv4Config.DeepCopy()
+	c.overrides = make(map[string]networkv1.LeaseOverride, len(overrides))
+	for mac, override := range overrides {
+		c.overrides[mac] = *override.DeepCopy()
 	}
+	c.skipped = skipped
 
 	return nil
 }
 
+// retire drops the leases and the settings of the IPPool once it is deleted
+// or paused. A shared agent keeps running for the other IPPools of the
+// cluster network, so it must stop serving this one by itself.
+func (c *Controller) retire() error {
+	for ip, mac := range c.poolCache {
+		logrus.Infof("remove %s", ip)
+		if err := c.dhcpAllocator.DeleteLease(c.nic, mac); err != nil {
+			return err
+		}
+		delete(c.poolCache, ip)
+	}
+	c.ipv4Config = nil
+	c.overrides = nil
+	c.skipped = nil
+
+	c.dhcpAllocator.SetDynamicRange(c.nic, nil)
+	c.dhcpAllocator.SetClientClasses(c.nic, nil)
+	if err := c.dhcpAllocator.SetHealthProbeLease(c.nic, "", ""); err != nil {
+		return err
+	}
+	if err := c.clearPool6CacheAndLeaseStore(); err != nil {
+		return err
+	}
+
+	// There is nothing left to serve
+	c.retired.Store(true)
+	c.synced.Store(true)
+
+	return nil
+}
+
+// leaseStoreSynced reports whether the lease store holds a lease for every
+// allocated address, and nothing else. The skipped leases are left out.
+func (c *Controller) leaseStoreSynced(allocated map[string]string) bool {
+	for ip, mac := range c.poolCache {
+		if _, skipped := c.skipped[mac]; !skipped && allocated[ip] != mac {
+			return false
+		}
+	}
+	for ip, mac := range allocated {
+		if _, skipped := c.skipped[mac]; skipped {
+			continue
+		}
+		lease := c.dhcpAllocator.GetLease(c.nic, mac)
+		if lease.Dynamic || lease.ClientIP.String() != ip {
+			return false
+		}
+	}
+	return true
+}
+
+// updateDynamicRange hands out the addresses of the dynamic range, if any,
+// which are not reserved to the clients without a lease of their own.
+func (c *Controller) updateDynamicRange(ipv4Config networkv1.IPv4Config, reserved []string) error {
+	dynamicRange := ipv4Config.DynamicRange
+	if dynamicRange == nil {
+		c.dhcpAllocator.SetDynamicRange(c.nic, nil)
+		return nil
+	}
+
+	r, err := dhcp.NewDynamicRange(
+		dynamicRange.Start,
+		dynamicRange.End,
+		util.DynamicLeaseTime(dynamicRange),
+		ipv4Config.ServerIP,
+		ipv4Config.CIDR,
+		ipv4Config.Router,
+		ipv4Config.DNS,
+		ipv4Config.DomainName,
+		ipv4Config.DomainSearch,
+		ipv4Config.NTP,
+		leaseOptions(ipv4Config, networkv1.LeaseOverride{})...,
+	)
+	if err != nil {
+		return err
+	}
+	r.Reserve(reserved...)
+	c.dhcpAllocator.SetDynamicRange(c.nic, r)
+
+	return nil
+}
+
+// updatePool6CacheAndLeaseStore brings the IPv6 leases in line with the
+// allocated addresses and the IPPool configuration. The leases are keyed by
+// address, so a changed binding or configuration replaces the lease.
+func (c *Controller) updatePool6CacheAndLeaseStore(latest map[string]networkv1.IPv6Binding, ipv6Config networkv1.IPv6Config) error {
+	configChanged := c.ipv6Config == nil || !reflect.DeepEqual(*c.ipv6Config, ipv6Config)
+
+	for ip, binding := range c.pool6Cache {
+		if newBinding, exists := latest[ip]; exists && !configChanged && reflect.DeepEqual(binding, newBinding) {
+			continue
+		}
+		logrus.Infof("remove %s", ip)
+		if err := c.dhcpAllocator.DeleteLease6(c.nic, ip); err != nil {
+			return err
+		}
+		delete(c.pool6Cache, ip)
+	}
+
+	for newIP, newBinding := range latest {
+		if _, exists := c.pool6Cache[newIP]; !exists {
+			logrus.Infof("add %s with value %+v", newIP, newBinding)
+			if err := c.dhcpAllocator.AddLease6(
+				c.nic,
+				newBinding.DUID,
+				newBinding.IAID,
+				newBinding.MACAddress,
+				newIP,
+				ipv6Config.CIDR,
+				ipv6Config.DNS,
+				ipv6Config.DomainSearch,
+				ipv6Config.PreferredLifetime,
+				ipv6Config.ValidLifetime,
+			); err != nil {
+				return err
+			}
+			c.pool6Cache[newIP] = newBinding
+		}
+	}
+
+	c.ipv6Config = ipv6Config.DeepCopy()
+
+	return nil
+}
+
+// clearPool6CacheAndLeaseStore removes the IPv6 leases once the IPPool no
+// longer has an IPv6 configuration.
+func (c *Controller) clearPool6CacheAndLeaseStore() error {
+	for ip := range c.pool6Cache {
+		logrus.Infof("remove %s", ip)
+		if err := c.dhcpAllocator.DeleteLease6(c.nic, ip); err != nil {
+			return err
+		}
+		delete(c.pool6Cache, ip)
+	}
+
+	c.ipv6Config = nil
+
+	return nil
+}
+
+func leaseOptions(ipv4Config networkv1.IPv4Config, override networkv1.LeaseOverride) []dhcp.LeaseOption {
+	var opts []dhcp.LeaseOption
+	if override.Hostname != "" {
+		opts = append(opts, dhcp.WithHostname(override.Hostname))
+	}
+	if override.ClientID != "" {
+		opts = append(opts, dhcp.WithClientID(override.ClientID))
+	}
+	if ipv4Config.DDNS != nil {
+		opts = append(opts, dhcp.WithDDNS())
+	}
+	if util.IsInfiniteLease(ipv4Config) {
+		opts = append(opts, dhcp.WithInfiniteLease())
+	} else {
+		_, renewalTime, rebindingTime := util.LeaseTimes(ipv4Config)
+		opts = append(opts, dhcp.WithRenewalTime(renewalTime), dhcp.WithRebindingTime(rebindingTime))
+	}
+	if ipv4Config.RapidCommit != nil && *ipv4Config.RapidCommit {
+		opts = append(opts, dhcp.WithRapidCommit())
+	}
+	if ipv4Config.ConflictDetection != nil && *ipv4Config.ConflictDetection {
+		opts = append(opts, dhcp.WithConflictDetection())
+	}
+	for _, route := range ipv4Config.Routes {
+		opts = append(opts, dhcp.WithRoute(route.Destination, route.Gateway))
+	}
+	for _, option := range util.MergeCustomOptions(ipv4Config.CustomOptions, override.CustomOptions) {
+		value, err := util.EncodeCustomOption(option)
+		if err != nil {
+			logrus.Errorf("(ippool.leaseOptions) skip custom option: %v", err)
+			continue
+		}
+		opts = append(opts, dhcp.WithCustomOption(uint8(option.Code), value))
+	}
+	if boot := ipv4Config.Boot; boot != nil {
+		if boot.NextServer != "" {
+			opts = append(opts, dhcp.WithNextServer(boot.NextServer))
+		} else if boot.TFTP != nil {
+			// The agent serves the boot files itself
+			opts = append(opts, dhcp.WithNextServer(ipv4Config.ServerIP))
+		}
+		if boot.Filename != "" {
+			opts = append(opts, dhcp.WithBootFilename(boot.Filename))
+		}
+		for _, arch := range boot.Architectures {
+			opts = append(opts, dhcp.WithArchBootFilename(uint16(arch.Arch), arch.Filename))
+		}
+		if boot.IPXEScriptURL != "" {
+			opts = append(opts, dhcp.WithIPXEScript(boot.IPXEScriptURL))
+		}
+	}
+	if relay := ipv4Config.Relay; relay != nil {
+		for _, circuitID := range relay.CircuitIDs {
+			opts = append(opts, dhcp.WithRelayCircuitID(circuitID))
+		}
+		for _, remoteID := range relay.RemoteIDs {
+			opts = append(opts, dhcp.WithRelayRemoteID(remoteID))
+		}
+	}
+	return opts
+}
+
+// clientClasses returns the client classes of the IPPool in order. Malformed
+// classes are skipped.
+func clientClasses(classes []networkv1.ClientClass) []dhcp.ClientClass {
+	var clientClasses []dhcp.ClientClass
+	for _, class := range classes {
+		var opts []dhcp.ClassOption
+		for _, vendorClass := range class.VendorClasses {
+			opts = append(opts, dhcp.MatchVendorClass(vendorClass))
+		}
+		for _, userClass := range class.UserClasses {
+			opts = append(opts, dhcp.MatchUserClass(userClass))
+		}
+		for _, macPrefix := range class.MACPrefixes {
+			opts = append(opts, dhcp.MatchMACPrefix(macPrefix))
+		}
+		if class.NextServer != "" {
+			opts = append(opts, dhcp.WithClassNextServer(class.NextServer))
+		}
+		if class.Filename != "" {
+			opts = append(opts, dhcp.WithClassBootFilename(class.Filename))
+		}
+		for _, option := range class.CustomOptions {
+			value, err := util.EncodeCustomOption(option)
+			if err != nil {
+				logrus.Errorf("(ippool.clientClasses) skip custom option of class %s: %v", class.Name, err)
+				continue
+			}
+			opts = append(opts, dhcp.WithClassCustomOption(uint8(option.Code), value))
+		}
+
+		clientClass, err := dhcp.NewClientClass(class.Name, opts...)
+		if err != nil {
+			logrus.Errorf("(ippool.clientClasses) skip class: %v", err)
+			continue
+		}
+		clientClasses = append(clientClasses, clientClass)
+	}
+	return clientClasses
+}
+
+// byMAC turns a map of addresses to MAC addresses around.
+func byMAC(allocated map[string]string) map[string]string {
+	macs := make(map[string]string, len(allocated))
+	for ip, mac := range allocated {
+		macs[mac] = ip
+	}
+	return macs
+}
+
+// reservedIPs returns the allocated addresses which are not available to the
+// dynamic range, i.e. all but the ones the dynamic range already leased out.
+func reservedIPs(allocated map[string]string) []string {
+	reserved := make([]string, 0, len(allocated))
+	for ip, mac := range allocated {
+		if mac != util.DynamicMark {
+			reserved = append(reserved, ip)
+		}
+	}
+	return reserved
+}
+
 func filterExcludedAndReserved(allocated map[string]string) {
 	for ip, mac := range allocated {
-		if mac == util.ExcludedMark || mac == util.ReservedMark {
+		if mac == util.ExcludedMark || mac == util.ReservedMark || mac == util.DynamicMark || mac == util.QuarantinedMark {
 			delete(allocated, ip)
 		}
 	}
@@ -0,0 +1,158 @@
+package ippool
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
+	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
+	"github.com/harvester/vm-dhcp-controller/pkg/metrics"
+)
+
+const (
+	leaseStatusRetryPeriod = 10 * time.Second
+	// How often the renewals of bound leases are written back in one go
+	leaseRenewalReportPeriod = 30 * time.Second
+)
+
+// reportLeaseStates writes the lease states observed by the DHCP server, e.g.
+// released or declined addresses, and the leases handed out from the dynamic
+// range back to the IPPool status so that the controller can act on them. The
+// lease snapshot, if any, is saved on the way. Plain renewals are only written
+// every leaseRenewalReportPeriod, as they happen all the time.
+func (e *EventHandler) reportLeaseStates(ctx context.Context) {
+	retryCh := make(chan struct{}, 1)
+	ticker := time.NewTicker(leaseRenewalReportPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.dhcpAllocator.LeaseStateChanged(e.nic):
+			e.snapshot.save()
+			updateLeaseMetrics(e.metricsAllocator, e.poolRef, e.nic, e.dhcpAllocator)
+		case <-ticker.C:
+			select {
+			case <-e.dhcpAllocator.LeaseRenewed(e.nic):
+			default:
+				continue
+			}
+			e.snapshot.save()
+		case <-retryCh:
+		}
+
+		if err := e.updateLeaseStatus(ctx); err != nil {
+			logrus.Errorf("(eventhandler.reportLeaseStates) failed to update lease status of ippool %s: %v", e.poolRef.String(), err)
+			time.AfterFunc(leaseStatusRetryPeriod, func() {
+				select {
+				case retryCh <- struct{}{}:
+				default:
+				}
+			})
+		}
+	}
+}
+
+func (e *EventHandler) updateLeaseStatus(ctx context.Context) error {
+	states := e.dhcpAllocator.ListLeaseStates(e.nic)
+	dynamics := e.dhcpAllocator.ListDynamicLeases(e.nic)
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		ipPool, err := e.k8sClientset.NetworkV1alpha1().IPPools(e.poolRef.Namespace).Get(ctx, e.poolRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if ipPool.Status.IPv4 == nil {
+			return nil
+		}
+
+		leases := make(map[string]networkv1.LeaseStatus, len(states))
+
+		// Keep the records of the leases still being served
+		for hwAddr, leaseStatus := range ipPool.Status.IPv4.Leases {
+			lease := e.dhcpAllocator.GetLease(e.nic, hwAddr)
+			if lease.ClientIP == nil || lease.ClientIP.String() != leaseStatus.IPAddress {
+				continue
+			}
+			leases[hwAddr] = leaseStatus
+		}
+
+		for hwAddr, lease := range states {
+			leaseStatus := newLeaseStatus(lease)
+			if oldLeaseStatus, ok := leases[hwAddr]; ok &&
+				oldLeaseStatus.IPAddress == leaseStatus.IPAddress &&
+				oldLeaseStatus.State == leaseStatus.State {
+				leaseStatus.LastUpdate = oldLeaseStatus.LastUpdate
+			}
+			leases[hwAddr] = leaseStatus
+		}
+
+		// For DeepEqual
+		if len(leases) == 0 {
+			leases = nil
+		}
+
+		var dynamicLeases map[string]networkv1.DynamicLease
+		if len(dynamics) > 0 {
+			dynamicLeases = make(map[string]networkv1.DynamicLease, len(dynamics))
+			for ip, lease := range dynamics {
+				dynamicLeases[ip] = networkv1.DynamicLease{
+					MACAddress: lease.HWAddr,
+					ExpiryTime: metav1.NewTime(lease.ExpiryTime.Truncate(time.Second)),
+				}
+			}
+		}
+
+		if reflect.DeepEqual(leases, ipPool.Status.IPv4.Leases) &&
+			reflect.DeepEqual(dynamicLeases, ipPool.Status.IPv4.DynamicLeases) {
+			return nil
+		}
+
+		ipPoolCpy := ipPool.DeepCopy()
+		ipPoolCpy.Status.IPv4.Leases = leases
+		ipPoolCpy.Status.IPv4.DynamicLeases = dynamicLeases
+
+		logrus.Infof("(eventhandler.updateLeaseStatus) update lease status of ippool %s", e.poolRef.String())
+		_, err = e.k8sClientset.NetworkV1alpha1().IPPools(e.poolRef.Namespace).UpdateStatus(ctx, ipPoolCpy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// updateLeaseMetrics exports the size of the lease store, if metrics are
+// enabled.
+func updateLeaseMetrics(metricsAllocator *metrics.MetricsAllocator, poolRef types.NamespacedName, nic string, dhcpAllocator *dhcp.DHCPAllocator) {
+	if metricsAllocator == nil {
+		return
+	}
+	metricsAllocator.UpdateDHCPLeases(poolRef.String(), dhcpAllocator.LeaseCount(nic))
+}
+
+func newLeaseStatus(lease dhcp.DHCPLease) networkv1.LeaseStatus {
+	return networkv1.LeaseStatus{
+		IPAddress:      lease.ClientIP.String(),
+		State:          networkv1.LeaseState(lease.State),
+		LastUpdate:     metav1.NewTime(lease.StateTime),
+		ClientHostname: lease.ClientHostname,
+		BoundTime:      newTime(lease.BoundTime),
+		RenewTime:      newTime(lease.RenewTime),
+		ExpiryTime:     newTime(lease.ExpiryTime),
+	}
+}
+
+// newTime truncates t to the precision kept by the API server so that
+// unchanged lease records compare equal to the stored ones.
+func newTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	mt := metav1.NewTime(t.Truncate(time.Second))
+	return &mt
+}
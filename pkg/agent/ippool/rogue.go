@@ -0,0 +1,144 @@
+package ippool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
+	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
+)
+
+const (
+	// rogueServerTimeout is how long a rogue DHCP server is reported after
+	// its last reply seen
+	rogueServerTimeout = 10 * time.Minute
+	// rogueServerCheckInterval is how often the rogue DHCP servers are
+	// expired, and failed status updates retried
+	rogueServerCheckInterval = time.Minute
+
+	rogueServerEventReason = "RogueDHCPServer"
+)
+
+type rogueServerKey struct {
+	serverIP string
+	hwAddr   string
+}
+
+func (k rogueServerKey) String() string {
+	return fmt.Sprintf("%s (%s)", k.serverIP, k.hwAddr)
+}
+
+// DetectRogueServers watches the network of the IPPool for DHCP servers
+// other than the agent until ctx is done. Each rogue server is counted in the
+// metrics, reported with an Event when first seen, and listed in the
+// RogueDHCPServer condition of the IPPool until it has been silent for
+// rogueServerTimeout.
+func (e *EventHandler) DetectRogueServers(ctx context.Context) {
+	replies := make(chan dhcp.RogueServer, 16)
+	go func() {
+		if err := e.dhcpAllocator.WatchRogueServers(ctx, e.nic, func(server dhcp.RogueServer) {
+			select {
+			case replies <- server:
+			default:
+			}
+		}); err != nil {
+			logrus.Errorf("(eventhandler.DetectRogueServers) cannot watch for rogue dhcp servers on nic %s: %v", e.nic, err)
+		}
+	}()
+
+	ticker := time.NewTicker(rogueServerCheckInterval)
+	defer ticker.Stop()
+
+	lastSeen := make(map[rogueServerKey]time.Time)
+	var detected []rogueServerKey
+	// Clear the condition left behind by a former run
+	dirty := true
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case server := <-replies:
+			key := rogueServerKey{serverIP: server.ServerIP, hwAddr: server.HWAddr}
+			if e.metricsAllocator != nil {
+				e.metricsAllocator.IncDHCPRogueServerReplies(e.poolRef.String(), server.ServerIP, server.HWAddr)
+			}
+			if _, ok := lastSeen[key]; !ok {
+				logrus.Warnf("(eventhandler.DetectRogueServers) rogue dhcp server %s sent DHCP%s on nic %s of ippool %s", key, server.MessageType, e.nic, e.poolRef.String())
+				detected = append(detected, key)
+				dirty = true
+			}
+			lastSeen[key] = time.Now()
+		case <-ticker.C:
+			for key, t := range lastSeen {
+				if time.Since(t) >= rogueServerTimeout {
+					logrus.Infof("(eventhandler.DetectRogueServers) rogue dhcp server %s on nic %s of ippool %s went silent", key, e.nic, e.poolRef.String())
+					delete(lastSeen, key)
+					dirty = true
+				}
+			}
+		}
+
+		if !dirty {
+			continue
+		}
+		if err := e.updateRogueServerStatus(ctx, lastSeen, detected); err != nil {
+			logrus.Errorf("(eventhandler.DetectRogueServers) failed to update rogue dhcp server status of ippool %s: %v", e.poolRef.String(), err)
+			continue
+		}
+		detected = nil
+		dirty = false
+	}
+}
+
+// updateRogueServerStatus records an Event for each of the newly detected
+// rogue servers and sets the RogueDHCPServer condition to the ones seen.
+func (e *EventHandler) updateRogueServerStatus(ctx context.Context, lastSeen map[rogueServerKey]time.Time, detected []rogueServerKey) error {
+	servers := make([]string, 0, len(lastSeen))
+	for key := range lastSeen {
+		servers = append(servers, key.String())
+	}
+	sort.Strings(servers)
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		ipPool, err := e.k8sClientset.NetworkV1alpha1().IPPools(e.poolRef.Namespace).Get(ctx, e.poolRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range detected {
+			e.recorder.Eventf(ipPool, corev1.EventTypeWarning, rogueServerEventReason,
+				"DHCP server %s other than the agent answers on network %s", key, ipPool.Spec.NetworkName)
+		}
+		detected = nil
+
+		ipPoolCpy := ipPool.DeepCopy()
+		if len(servers) > 0 {
+			setRogueDHCPServerCondition(ipPoolCpy, corev1.ConditionTrue, "RogueServerDetected",
+				"rogue dhcp servers: "+strings.Join(servers, ", "))
+		} else {
+			setRogueDHCPServerCondition(ipPoolCpy, corev1.ConditionFalse, "", "")
+		}
+		if networkv1.RogueDHCPServer.GetStatus(ipPool) == networkv1.RogueDHCPServer.GetStatus(ipPoolCpy) &&
+			networkv1.RogueDHCPServer.GetMessage(ipPool) == networkv1.RogueDHCPServer.GetMessage(ipPoolCpy) {
+			return nil
+		}
+
+		_, err = e.k8sClientset.NetworkV1alpha1().IPPools(e.poolRef.Namespace).UpdateStatus(ctx, ipPoolCpy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func setRogueDHCPServerCondition(ipPool *networkv1.IPPool, status corev1.ConditionStatus, reason, message string) {
+	networkv1.RogueDHCPServer.SetStatus(ipPool, string(status))
+	networkv1.RogueDHCPServer.Reason(ipPool, reason)
+	networkv1.RogueDHCPServer.Message(ipPool, message)
+}
@@ -0,0 +1,145 @@
+package ippool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+
+	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
+	"github.com/harvester/vm-dhcp-controller/pkg/dhcp"
+)
+
+// snapshotVersion is bumped whenever the snapshot format changes in a way
+// older agents cannot read. Snapshots of other versions are ignored.
+const snapshotVersion = 1
+
+// snapshot is what the agent needs to serve the leases of an IPPool without
+// the API server, i.e. the IPPool the leases were last built of and what the
+// clients did with them.
+type snapshot struct {
+	Version int                         `json:"version"`
+	IPPool  *networkv1.IPPool           `json:"ipPool"`
+	Leases  map[string]dhcp.LeaseRecord `json:"leases,omitempty"`
+}
+
+// snapshotter writes the snapshot of an IPPool to a file whenever its leases
+// change. A nil snapshotter does nothing.
+type snapshotter struct {
+	path          string
+	nic           string
+	dhcpAllocator *dhcp.DHCPAllocator
+
+	mutex  sync.Mutex
+	ipPool *networkv1.IPPool
+}
+
+func newSnapshotter(dir string, poolRef types.NamespacedName, nic string, dhcpAllocator *dhcp.DHCPAllocator) *snapshotter {
+	if dir == "" {
+		return nil
+	}
+	return &snapshotter{
+		path:          filepath.Join(dir, poolRef.Namespace+"_"+poolRef.Name+".json"),
+		nic:           nic,
+		dhcpAllocator: dhcpAllocator,
+	}
+}
+
+// load reads the snapshot written by a former run, if any.
+func (s *snapshotter) load() (*snapshot, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, fmt.Errorf("cannot parse lease snapshot %s: %w", s.path, err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("lease snapshot %s has version %d, wanted %d", s.path, snap.Version, snapshotVersion)
+	}
+	if snap.IPPool == nil {
+		return nil, fmt.Errorf("lease snapshot %s has no ippool", s.path)
+	}
+
+	return &snap, nil
+}
+
+// setIPPool records the IPPool the leases were built of and saves the
+// snapshot.
+func (s *snapshotter) setIPPool(ipPool *networkv1.IPPool) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	s.ipPool = ipPool.DeepCopy()
+	s.mutex.Unlock()
+
+	s.save()
+}
+
+// remove deletes the snapshot once the IPPool is gone or paused, and stops
+// saving it until the IPPool is set again.
+func (s *snapshotter) remove() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ipPool = nil
+	for _, path := range []string{s.path, s.path + ".tmp"} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logrus.Errorf("(snapshotter.remove) cannot remove lease snapshot %s: %v", path, err)
+		}
+	}
+}
+
+// save writes the snapshot to a temporary file first, so that a crash never
+// leaves a partial snapshot behind. Failures are only logged, the snapshot is
+// written again on the next change.
+func (s *snapshotter) save() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ipPool == nil {
+		return
+	}
+
+	b, err := json.Marshal(snapshot{
+		Version: snapshotVersion,
+		IPPool:  s.ipPool,
+		Leases:  s.dhcpAllocator.ListLeaseRecords(s.nic),
+	})
+	if err != nil {
+		logrus.Errorf("(snapshotter.save) cannot encode lease snapshot %s: %v", s.path, err)
+		return
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		logrus.Errorf("(snapshotter.save) cannot write lease snapshot %s: %v", s.path, err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		logrus.Errorf("(snapshotter.save) cannot write lease snapshot %s: %v", s.path, err)
+	}
+}
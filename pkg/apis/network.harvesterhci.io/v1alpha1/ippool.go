@@ -7,11 +7,31 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
+const (
+	LeaseBound    LeaseState = "Bound"
+	LeaseReleased LeaseState = "Released"
+	LeaseDeclined LeaseState = "Declined"
+	LeaseInformed LeaseState = "Informed"
+	LeaseConflict LeaseState = "Conflict"
+)
+
+const (
+	CustomOptionTypeIP     CustomOptionType = "ip"
+	CustomOptionTypeIPList CustomOptionType = "ip-list"
+	CustomOptionTypeString CustomOptionType = "string"
+	CustomOptionTypeUint8  CustomOptionType = "uint8"
+	CustomOptionTypeUint16 CustomOptionType = "uint16"
+	CustomOptionTypeUint32 CustomOptionType = "uint32"
+	CustomOptionTypeHex    CustomOptionType = "hex"
+)
+
 var (
-	Registered condition.Cond = "Registered"
-	CacheReady condition.Cond = "CacheReady"
-	AgentReady condition.Cond = "AgentReady"
-	Stopped    condition.Cond = "Stopped"
+	Registered      condition.Cond = "Registered"
+	CacheReady      condition.Cond = "CacheReady"
+	AgentReady      condition.Cond = "AgentReady"
+	Stopped         condition.Cond = "Stopped"
+	DHCPServing     condition.Cond = "DHCPServing"
+	RogueDHCPServer condition.Cond = "RogueDHCPServer"
 )
 
 // +genclient
@@ -37,6 +57,10 @@ type IPPool struct {
 type IPPoolSpec struct {
 	IPv4Config IPv4Config `json:"ipv4Config,omitempty"`
 
+	// +optional
+	// +kubebuilder:validation:Optional
+	IPv6Config *IPv6Config `json:"ipv6Config,omitempty"`
+
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="NetworkName is immutable"
 	// +kubebuilder:validation:MaxLength=64
@@ -89,6 +113,167 @@ type IPv4Config struct {
 	// +optional
 	// +kubebuilder:validation:Optional
 	LeaseTime *int `json:"leaseTime,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	RenewalTime *int `json:"renewalTime,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	RebindingTime *int `json:"rebindingTime,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	InfiniteLease *bool `json:"infiniteLease,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	RapidCommit *bool `json:"rapidCommit,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	ConflictDetection *bool `json:"conflictDetection,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=16
+	Routes []Route `json:"routes,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	CustomOptions []CustomOption `json:"customOptions,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	Boot *BootConfig `json:"boot,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	Relay *RelayConfig `json:"relay,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	DynamicRange *DynamicRange `json:"dynamicRange,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=32
+	Classes []ClientClass `json:"classes,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	DDNS *DDNSConfig `json:"ddns,omitempty"`
+}
+
+type Route struct {
+	// +kubebuilder:validation:Required
+	Destination string `json:"destination"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format=ipv4
+	Gateway string `json:"gateway"`
+}
+
+type CustomOptionType string
+
+type CustomOption struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=254
+	Code int `json:"code"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=ip;ip-list;string;uint8;uint16;uint32;hex
+	Type CustomOptionType `json:"type"`
+
+	// +kubebuilder:validation:Required
+	Value string `json:"value"`
+}
+
+type BootConfig struct {
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format=ipv4
+	NextServer string `json:"nextServer,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=128
+	Filename string `json:"filename,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	Architectures []BootArchitecture `json:"architectures,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	IPXEScriptURL string `json:"ipxeScriptURL,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	TFTP *TFTPConfig `json:"tftp,omitempty"`
+}
+
+type TFTPConfig struct {
+	// +optional
+	// +kubebuilder:validation:Optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+}
+
+type RelayConfig struct {
+	// +optional
+	// +kubebuilder:validation:Optional
+	CircuitIDs []string `json:"circuitIDs,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	RemoteIDs []string `json:"remoteIDs,omitempty"`
+}
+
+type BootArchitecture struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	Arch int `json:"arch"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=128
+	Filename string `json:"filename"`
+}
+
+type IPv6Config struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="CIDR is immutable"
+	CIDR string `json:"cidr"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format=ipv6
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="ServerIP is immutable"
+	ServerIP string `json:"serverIP"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=3
+	// +kubebuilder:validation:items:Format=ipv6
+	DNS []string `json:"dns,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	DomainSearch []string `json:"domainSearch,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	PreferredLifetime *int `json:"preferredLifetime,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	ValidLifetime *int `json:"validLifetime,omitempty"`
 }
 
 // +kubebuilder:validation:XValidation:rule="!has(oldSelf.exclude) || has(self.exclude)", message="End is required once set"
@@ -110,6 +295,89 @@ type Pool struct {
 	Exclude []string `json:"exclude,omitempty"`
 }
 
+type DynamicRange struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format=ipv4
+	Start string `json:"start"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format=ipv4
+	End string `json:"end"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=60
+	LeaseTime *int `json:"leaseTime,omitempty"`
+}
+
+type ClientClass struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=64
+	Name string `json:"name"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	VendorClasses []string `json:"vendorClasses,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	UserClasses []string `json:"userClasses,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	MACPrefixes []string `json:"macPrefixes,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format=ipv4
+	NextServer string `json:"nextServer,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=128
+	Filename string `json:"filename,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	CustomOptions []CustomOption `json:"customOptions,omitempty"`
+}
+
+type DDNSConfig struct {
+	// +kubebuilder:validation:Required
+	Server string `json:"server"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=253
+	Zone string `json:"zone"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	ReverseZone string `json:"reverseZone,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	TTL *int `json:"ttl,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	TSIG *TSIGConfig `json:"tsig,omitempty"`
+}
+
+type TSIGConfig struct {
+	// +kubebuilder:validation:Required
+	KeyName string `json:"keyName"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=hmac-sha1;hmac-sha256;hmac-sha512
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// +kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
+}
+
 type IPPoolStatus struct {
 	LastUpdate metav1.Time `json:"lastUpdate,omitempty"`
 
@@ -117,6 +385,10 @@ type IPPoolStatus struct {
 	// +kubebuilder:validation:Optional
 	IPv4 *IPv4Status `json:"ipv4,omitempty"`
 
+	// +optional
+	// +kubebuilder:validation:Optional
+	IPv6 *IPv6Status `json:"ipv6,omitempty"`
+
 	// +optional
 	// +kubebuilder:validation:Optional
 	AgentPodRef *PodReference `json:"agentPodRef,omitempty"`
@@ -124,12 +396,83 @@ type IPPoolStatus struct {
 	// +optional
 	// +kubebuilder:validation:Optional
 	Conditions []genericcondition.GenericCondition `json:"conditions,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	HealthProbe *HealthProbeStatus `json:"healthProbe,omitempty"`
+}
+
+type HealthProbeStatus struct {
+	// +optional
+	// +kubebuilder:validation:Optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	Latency *metav1.Duration `json:"latency,omitempty"`
 }
 
 type IPv4Status struct {
 	Allocated map[string]string `json:"allocated,omitempty"`
-	Used      int               `json:"used"`
-	Available int               `json:"available"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	Leases map[string]LeaseStatus `json:"leases,omitempty"`
+
+	Overrides map[string]LeaseOverride `json:"overrides,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	DynamicLeases map[string]DynamicLease `json:"dynamicLeases,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	Quarantined map[string]QuarantinedAddress `json:"quarantined,omitempty"`
+
+	Used      int `json:"used"`
+	Available int `json:"available"`
+}
+
+type LeaseState string
+
+type LeaseOverride struct {
+	Hostname      string         `json:"hostname,omitempty"`
+	ClientID      string         `json:"clientID,omitempty"`
+	CustomOptions []CustomOption `json:"customOptions,omitempty"`
+}
+
+type DynamicLease struct {
+	MACAddress string      `json:"macAddress"`
+	ExpiryTime metav1.Time `json:"expiryTime"`
+}
+
+type QuarantinedAddress struct {
+	MACAddress string      `json:"macAddress"`
+	ExpiryTime metav1.Time `json:"expiryTime"`
+}
+
+type LeaseStatus struct {
+	IPAddress      string       `json:"ipAddress"`
+	State          LeaseState   `json:"state"`
+	LastUpdate     metav1.Time  `json:"lastUpdate,omitempty"`
+	ClientHostname string       `json:"clientHostname,omitempty"`
+	BoundTime      *metav1.Time `json:"boundTime,omitempty"`
+	RenewTime      *metav1.Time `json:"renewTime,omitempty"`
+	ExpiryTime     *metav1.Time `json:"expiryTime,omitempty"`
+}
+
+type IPv6Status struct {
+	Allocated map[string]IPv6Binding `json:"allocated,omitempty"`
+}
+
+type IPv6Binding struct {
+	// +optional
+	DUID string `json:"duid,omitempty"`
+
+	// +optional
+	IAID *uint32 `json:"iaid,omitempty"`
+
+	MACAddress string `json:"macAddress"`
 }
 
 type PodReference struct {
@@ -13,9 +13,10 @@ const (
 )
 
 var (
-	Allocated condition.Cond = "Allocated"
-	Disabled  condition.Cond = "Disabled"
-	InSynced  condition.Cond = "InSynced"
+	Allocated     condition.Cond = "Allocated"
+	Disabled      condition.Cond = "Disabled"
+	InSynced      condition.Cond = "InSynced"
+	DNSRegistered condition.Cond = "DNSRegistered"
 )
 
 type NetworkConfigState string
@@ -44,6 +45,11 @@ type VirtualMachineNetworkConfigSpec struct {
 	// +kubebuilder:validation:MaxLength=64
 	VMName string `json:"vmName"`
 
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	Hostname string `json:"hostname,omitempty"`
+
 	// +optional
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:MaxItems=4
@@ -67,6 +73,29 @@ type NetworkConfig struct {
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Format=ipv4
 	IPAddress *string `json:"ipAddress,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format=ipv6
+	IPv6Address *string `json:"ipv6Address,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^([0-9a-fA-F]{2}:)*[0-9a-fA-F]{2}$`
+	DUID *string `json:"duid,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	IAID *uint32 `json:"iaid,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^([0-9a-fA-F]{2}:)*[0-9a-fA-F]{2}$`
+	ClientID *string `json:"clientID,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Optional
+	CustomOptions []CustomOption `json:"customOptions,omitempty"`
 }
 
 type VirtualMachineNetworkConfigStatus struct {
@@ -78,8 +107,20 @@ type VirtualMachineNetworkConfigStatus struct {
 }
 
 type NetworkConfigStatus struct {
-	AllocatedIPAddress string             `json:"allocatedIPAddress,omitempty"`
-	MACAddress         string             `json:"macAddress,omitempty"`
-	NetworkName        string             `json:"networkName,omitempty"`
-	State              NetworkConfigState `json:"state,omitempty"`
+	AllocatedIPAddress   string             `json:"allocatedIPAddress,omitempty"`
+	AllocatedIPv6Address string             `json:"allocatedIPv6Address,omitempty"`
+	MACAddress           string             `json:"macAddress,omitempty"`
+	NetworkName          string             `json:"networkName,omitempty"`
+	State                NetworkConfigState `json:"state,omitempty"`
+	LeaseState           LeaseState         `json:"leaseState,omitempty"`
+	ClientHostname       string             `json:"clientHostname,omitempty"`
+	BoundTime            *metav1.Time       `json:"boundTime,omitempty"`
+	RenewTime            *metav1.Time       `json:"renewTime,omitempty"`
+	ExpiryTime           *metav1.Time       `json:"expiryTime,omitempty"`
+	DNSRecord            *DNSRecord         `json:"dnsRecord,omitempty"`
+}
+
+type DNSRecord struct {
+	Name      string `json:"name"`
+	IPAddress string `json:"ipAddress"`
 }
@@ -23,9 +23,209 @@ package v1alpha1
 
 import (
 	genericcondition "github.com/rancher/wrangler/v3/pkg/genericcondition"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootArchitecture) DeepCopyInto(out *BootArchitecture) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootArchitecture.
+func (in *BootArchitecture) DeepCopy() *BootArchitecture {
+	if in == nil {
+		return nil
+	}
+	out := new(BootArchitecture)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootConfig) DeepCopyInto(out *BootConfig) {
+	*out = *in
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]BootArchitecture, len(*in))
+		copy(*out, *in)
+	}
+	if in.TFTP != nil {
+		in, out := &in.TFTP, &out.TFTP
+		*out = new(TFTPConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootConfig.
+func (in *BootConfig) DeepCopy() *BootConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BootConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientClass) DeepCopyInto(out *ClientClass) {
+	*out = *in
+	if in.VendorClasses != nil {
+		in, out := &in.VendorClasses, &out.VendorClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UserClasses != nil {
+		in, out := &in.UserClasses, &out.UserClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MACPrefixes != nil {
+		in, out := &in.MACPrefixes, &out.MACPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomOptions != nil {
+		in, out := &in.CustomOptions, &out.CustomOptions
+		*out = make([]CustomOption, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientClass.
+func (in *ClientClass) DeepCopy() *ClientClass {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomOption) DeepCopyInto(out *CustomOption) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomOption.
+func (in *CustomOption) DeepCopy() *CustomOption {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomOption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DDNSConfig) DeepCopyInto(out *DDNSConfig) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(int)
+		**out = **in
+	}
+	if in.TSIG != nil {
+		in, out := &in.TSIG, &out.TSIG
+		*out = new(TSIGConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DDNSConfig.
+func (in *DDNSConfig) DeepCopy() *DDNSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DDNSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSRecord) DeepCopyInto(out *DNSRecord) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSRecord.
+func (in *DNSRecord) DeepCopy() *DNSRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamicLease) DeepCopyInto(out *DynamicLease) {
+	*out = *in
+	in.ExpiryTime.DeepCopyInto(&out.ExpiryTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamicLease.
+func (in *DynamicLease) DeepCopy() *DynamicLease {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamicLease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamicRange) DeepCopyInto(out *DynamicRange) {
+	*out = *in
+	if in.LeaseTime != nil {
+		in, out := &in.LeaseTime, &out.LeaseTime
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamicRange.
+func (in *DynamicRange) DeepCopy() *DynamicRange {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamicRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthProbeStatus) DeepCopyInto(out *HealthProbeStatus) {
+	*out = *in
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Latency != nil {
+		in, out := &in.Latency, &out.Latency
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthProbeStatus.
+func (in *HealthProbeStatus) DeepCopy() *HealthProbeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthProbeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPPool) DeepCopyInto(out *IPPool) {
 	*out = *in
@@ -91,6 +291,11 @@ func (in *IPPoolList) DeepCopyObject() runtime.Object {
 func (in *IPPoolSpec) DeepCopyInto(out *IPPoolSpec) {
 	*out = *in
 	in.IPv4Config.DeepCopyInto(&out.IPv4Config)
+	if in.IPv6Config != nil {
+		in, out := &in.IPv6Config, &out.IPv6Config
+		*out = new(IPv6Config)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Paused != nil {
 		in, out := &in.Paused, &out.Paused
 		*out = new(bool)
@@ -118,6 +323,11 @@ func (in *IPPoolStatus) DeepCopyInto(out *IPPoolStatus) {
 		*out = new(IPv4Status)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.IPv6 != nil {
+		in, out := &in.IPv6, &out.IPv6
+		*out = new(IPv6Status)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.AgentPodRef != nil {
 		in, out := &in.AgentPodRef, &out.AgentPodRef
 		*out = new(PodReference)
@@ -128,6 +338,11 @@ func (in *IPPoolStatus) DeepCopyInto(out *IPPoolStatus) {
 		*out = make([]genericcondition.GenericCondition, len(*in))
 		copy(*out, *in)
 	}
+	if in.HealthProbe != nil {
+		in, out := &in.HealthProbe, &out.HealthProbe
+		*out = new(HealthProbeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -170,6 +385,68 @@ func (in *IPv4Config) DeepCopyInto(out *IPv4Config) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.RenewalTime != nil {
+		in, out := &in.RenewalTime, &out.RenewalTime
+		*out = new(int)
+		**out = **in
+	}
+	if in.RebindingTime != nil {
+		in, out := &in.RebindingTime, &out.RebindingTime
+		*out = new(int)
+		**out = **in
+	}
+	if in.InfiniteLease != nil {
+		in, out := &in.InfiniteLease, &out.InfiniteLease
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RapidCommit != nil {
+		in, out := &in.RapidCommit, &out.RapidCommit
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ConflictDetection != nil {
+		in, out := &in.ConflictDetection, &out.ConflictDetection
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]Route, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomOptions != nil {
+		in, out := &in.CustomOptions, &out.CustomOptions
+		*out = make([]CustomOption, len(*in))
+		copy(*out, *in)
+	}
+	if in.Boot != nil {
+		in, out := &in.Boot, &out.Boot
+		*out = new(BootConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Relay != nil {
+		in, out := &in.Relay, &out.Relay
+		*out = new(RelayConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DynamicRange != nil {
+		in, out := &in.DynamicRange, &out.DynamicRange
+		*out = new(DynamicRange)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Classes != nil {
+		in, out := &in.Classes, &out.Classes
+		*out = make([]ClientClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DDNS != nil {
+		in, out := &in.DDNS, &out.DDNS
+		*out = new(DDNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -193,6 +470,34 @@ func (in *IPv4Status) DeepCopyInto(out *IPv4Status) {
 			(*out)[key] = val
 		}
 	}
+	if in.Leases != nil {
+		in, out := &in.Leases, &out.Leases
+		*out = make(map[string]LeaseStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make(map[string]LeaseOverride, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.DynamicLeases != nil {
+		in, out := &in.DynamicLeases, &out.DynamicLeases
+		*out = make(map[string]DynamicLease, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Quarantined != nil {
+		in, out := &in.Quarantined, &out.Quarantined
+		*out = make(map[string]QuarantinedAddress, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -206,6 +511,136 @@ func (in *IPv4Status) DeepCopy() *IPv4Status {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPv6Binding) DeepCopyInto(out *IPv6Binding) {
+	*out = *in
+	if in.IAID != nil {
+		in, out := &in.IAID, &out.IAID
+		*out = new(uint32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPv6Binding.
+func (in *IPv6Binding) DeepCopy() *IPv6Binding {
+	if in == nil {
+		return nil
+	}
+	out := new(IPv6Binding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPv6Config) DeepCopyInto(out *IPv6Config) {
+	*out = *in
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DomainSearch != nil {
+		in, out := &in.DomainSearch, &out.DomainSearch
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreferredLifetime != nil {
+		in, out := &in.PreferredLifetime, &out.PreferredLifetime
+		*out = new(int)
+		**out = **in
+	}
+	if in.ValidLifetime != nil {
+		in, out := &in.ValidLifetime, &out.ValidLifetime
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPv6Config.
+func (in *IPv6Config) DeepCopy() *IPv6Config {
+	if in == nil {
+		return nil
+	}
+	out := new(IPv6Config)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPv6Status) DeepCopyInto(out *IPv6Status) {
+	*out = *in
+	if in.Allocated != nil {
+		in, out := &in.Allocated, &out.Allocated
+		*out = make(map[string]IPv6Binding, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPv6Status.
+func (in *IPv6Status) DeepCopy() *IPv6Status {
+	if in == nil {
+		return nil
+	}
+	out := new(IPv6Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseOverride) DeepCopyInto(out *LeaseOverride) {
+	*out = *in
+	if in.CustomOptions != nil {
+		in, out := &in.CustomOptions, &out.CustomOptions
+		*out = make([]CustomOption, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseOverride.
+func (in *LeaseOverride) DeepCopy() *LeaseOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseStatus) DeepCopyInto(out *LeaseStatus) {
+	*out = *in
+	in.LastUpdate.DeepCopyInto(&out.LastUpdate)
+	if in.BoundTime != nil {
+		in, out := &in.BoundTime, &out.BoundTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RenewTime != nil {
+		in, out := &in.RenewTime, &out.RenewTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiryTime != nil {
+		in, out := &in.ExpiryTime, &out.ExpiryTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseStatus.
+func (in *LeaseStatus) DeepCopy() *LeaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
 	*out = *in
@@ -214,6 +649,31 @@ func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.IPv6Address != nil {
+		in, out := &in.IPv6Address, &out.IPv6Address
+		*out = new(string)
+		**out = **in
+	}
+	if in.DUID != nil {
+		in, out := &in.DUID, &out.DUID
+		*out = new(string)
+		**out = **in
+	}
+	if in.IAID != nil {
+		in, out := &in.IAID, &out.IAID
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.ClientID != nil {
+		in, out := &in.ClientID, &out.ClientID
+		*out = new(string)
+		**out = **in
+	}
+	if in.CustomOptions != nil {
+		in, out := &in.CustomOptions, &out.CustomOptions
+		*out = make([]CustomOption, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -230,6 +690,23 @@ func (in *NetworkConfig) DeepCopy() *NetworkConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkConfigStatus) DeepCopyInto(out *NetworkConfigStatus) {
 	*out = *in
+	if in.BoundTime != nil {
+		in, out := &in.BoundTime, &out.BoundTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RenewTime != nil {
+		in, out := &in.RenewTime, &out.RenewTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiryTime != nil {
+		in, out := &in.ExpiryTime, &out.ExpiryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.DNSRecord != nil {
+		in, out := &in.DNSRecord, &out.DNSRecord
+		*out = new(DNSRecord)
+		**out = **in
+	}
 	return
 }
 
@@ -280,6 +757,97 @@ func (in *Pool) DeepCopy() *Pool {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuarantinedAddress) DeepCopyInto(out *QuarantinedAddress) {
+	*out = *in
+	in.ExpiryTime.DeepCopyInto(&out.ExpiryTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuarantinedAddress.
+func (in *QuarantinedAddress) DeepCopy() *QuarantinedAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(QuarantinedAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelayConfig) DeepCopyInto(out *RelayConfig) {
+	*out = *in
+	if in.CircuitIDs != nil {
+		in, out := &in.CircuitIDs, &out.CircuitIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemoteIDs != nil {
+		in, out := &in.RemoteIDs, &out.RemoteIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelayConfig.
+func (in *RelayConfig) DeepCopy() *RelayConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RelayConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Route) DeepCopyInto(out *Route) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
+func (in *Route) DeepCopy() *Route {
+	if in == nil {
+		return nil
+	}
+	out := new(Route)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TFTPConfig) DeepCopyInto(out *TFTPConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TFTPConfig.
+func (in *TFTPConfig) DeepCopy() *TFTPConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TFTPConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TSIGConfig) DeepCopyInto(out *TSIGConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TSIGConfig.
+func (in *TSIGConfig) DeepCopy() *TSIGConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TSIGConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMachineNetworkConfig) DeepCopyInto(out *VirtualMachineNetworkConfig) {
 	*out = *in
@@ -375,7 +943,9 @@ func (in *VirtualMachineNetworkConfigStatus) DeepCopyInto(out *VirtualMachineNet
 	if in.NetworkConfigs != nil {
 		in, out := &in.NetworkConfigs, &out.NetworkConfigs
 		*out = make([]NetworkConfigStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
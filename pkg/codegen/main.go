@@ -46,6 +46,7 @@ func main() {
 				Types: []interface{}{
 					corev1.Node{},
 					corev1.Pod{},
+					corev1.Secret{},
 				},
 			},
 			cniv1.SchemeGroupVersion.Group: {
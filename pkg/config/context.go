@@ -70,15 +70,56 @@ type ControllerOptions struct {
 	AgentNamespace          string
 	AgentImage              *Image
 	AgentServiceAccountName string
+	AgentPlacement          string
 	NoDHCP                  bool
+
+	// Volume the agents keep their lease snapshots on, along with the
+	// claim of the persistent volume if there is one
+	AgentLeaseSnapshot          string
+	AgentLeaseSnapshotClaimName string
 }
 
+const (
+	// AgentPlacementIPPool deploys a dedicated agent for every IPPool
+	AgentPlacementIPPool = "ippool"
+	// AgentPlacementClusterNetwork deploys one agent per cluster network,
+	// serving all the IPPools of the cluster network
+	AgentPlacementClusterNetwork = "clusternetwork"
+)
+
+const (
+	// AgentLeaseSnapshotNone keeps no lease snapshots
+	AgentLeaseSnapshotNone = "none"
+	// AgentLeaseSnapshotEmptyDir keeps the lease snapshots as long as the
+	// agent pod, i.e. across restarts of the agent container
+	AgentLeaseSnapshotEmptyDir = "emptydir"
+	// AgentLeaseSnapshotHostPath keeps the lease snapshots on the node
+	AgentLeaseSnapshotHostPath = "hostpath"
+	// AgentLeaseSnapshotPVC keeps the lease snapshots on a persistent volume
+	// shared by all the agents
+	AgentLeaseSnapshotPVC = "pvc"
+)
+
 type AgentOptions struct {
 	DryRun         bool
-	Nic            string
+	DHCPv6         bool
 	KubeConfigPath string
 	KubeContext    string
-	IPPoolRef      types.NamespacedName
+	IPPools        []AgentIPPool
+	RateLimit      dhcp.RateLimit
+
+	// Directory the lease snapshots are kept in, none if empty
+	LeaseSnapshotDir string
+
+	MetricsAllocator *metrics.MetricsAllocator
+}
+
+// AgentIPPool is an IPPool served by the agent on one of its network
+// interfaces.
+type AgentIPPool struct {
+	Nic       string
+	IPPoolRef types.NamespacedName
+	TFTPRoot  string
 }
 
 type HTTPServerOptions struct {
@@ -87,6 +128,9 @@ type HTTPServerOptions struct {
 	IPAllocator      *ipam.IPAllocator
 	DHCPAllocator    *dhcp.DHCPAllocator
 	MetricsAllocator *metrics.MetricsAllocator
+
+	// Ready reports whether the server is ready to serve, always if unset
+	Ready func() bool
 }
 
 type Management struct {
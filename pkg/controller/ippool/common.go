@@ -1,9 +1,12 @@
 package ippool
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
@@ -29,32 +32,146 @@ func prepareAgentPod(
 ) (*corev1.Pod, error) {
 	name := util.SafeAgentConcatName(ipPool.Namespace, ipPool.Name)
 
-	nadNamespace, nadName := kv.RSplit(ipPool.Spec.NetworkName, "/")
-	networks := []Network{
-		{
-			Namespace:     nadNamespace,
-			Name:          nadName,
-			InterfaceName: "eth1",
-		},
-	}
-	networksStr, err := json.Marshal(networks)
+	pod, err := newAgentPod(name, []*networkv1.IPPool{ipPool}, noDHCP, agentNamespace, clusterNetwork, agentServiceAccountName, agentImage)
 	if err != nil {
 		return nil, err
 	}
 
-	_, ipNet, err := net.ParseCIDR(ipPool.Spec.IPv4Config.CIDR)
+	pod.Labels[util.IPPoolNamespaceLabelKey] = ipPool.Namespace
+	pod.Labels[util.IPPoolNameLabelKey] = ipPool.Name
+
+	return pod, nil
+}
+
+// prepareSharedAgentPod returns the agent pod serving all the given IPPools
+// of a cluster network, each of them on its own network interface. The name
+// of the pod is derived from the IPPools, their networks and the image, so
+// that a new agent pod can be deployed next to the former one.
+func prepareSharedAgentPod(
+	ipPools []*networkv1.IPPool,
+	noDHCP bool,
+	agentNamespace string,
+	clusterNetwork string,
+	agentServiceAccountName string,
+	agentImage *config.Image,
+) (*corev1.Pod, error) {
+	refs := make([]string, 0, len(ipPools))
+	revision := sha256.New()
+	for _, ipPool := range ipPools {
+		refs = append(refs, ipPool.Namespace+"/"+ipPool.Name)
+		fmt.Fprintf(revision, "%s/%s=%s,", ipPool.Namespace, ipPool.Name, ipPool.Spec.NetworkName)
+	}
+	revision.Write([]byte(agentImage.String()))
+	name := util.SafeAgentConcatName("clusternetwork", clusterNetwork, hex.EncodeToString(revision.Sum(nil))[:5])
+
+	pod, err := newAgentPod(name, ipPools, noDHCP, agentNamespace, clusterNetwork, agentServiceAccountName, agentImage)
 	if err != nil {
 		return nil, err
 	}
-	prefixLength, _ := ipNet.Mask.Size()
 
-	args := []string{
-		"--ippool-ref",
-		fmt.Sprintf("%s/%s", ipPool.Namespace, ipPool.Name),
+	pod.Annotations[ipPoolsAnnotationKey] = strings.Join(refs, ",")
+	pod.Labels[clusterNetworkLabelKey] = clusterNetwork
+
+	return pod, nil
+}
+
+// newAgentPod returns an agent pod serving the given IPPools. The IPPools are
+// attached to the interfaces eth1, eth2, and so on in order. The interface is
+// passed to the agent along with the IPPool only if there are several.
+func newAgentPod(
+	name string,
+	ipPools []*networkv1.IPPool,
+	noDHCP bool,
+	agentNamespace string,
+	clusterNetwork string,
+	agentServiceAccountName string,
+	agentImage *config.Image,
+) (*corev1.Pod, error) {
+	var (
+		networks     []Network
+		args         []string
+		dhcpv6       bool
+		volumes      []corev1.Volume
+		volumeMounts []corev1.VolumeMount
+	)
+	script := setIPAddrScript
+	shared := len(ipPools) > 1
+
+	for i, ipPool := range ipPools {
+		nic := fmt.Sprintf("eth%d", i+1)
+
+		nadNamespace, nadName := kv.RSplit(ipPool.Spec.NetworkName, "/")
+		networks = append(networks, Network{
+			Namespace:     nadNamespace,
+			Name:          nadName,
+			InterfaceName: nic,
+		})
+
+		_, ipNet, err := net.ParseCIDR(ipPool.Spec.IPv4Config.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		prefixLength, _ := ipNet.Mask.Size()
+
+		script += fmt.Sprintf(setIPv4AddrScript, ipPool.Spec.IPv4Config.ServerIP, prefixLength, nic)
+
+		ref := fmt.Sprintf("%s/%s", ipPool.Namespace, ipPool.Name)
+		if shared {
+			ref += "=" + nic
+		}
+		args = append(args, "--ippool-ref", ref)
+
+		if ipPool.Spec.IPv6Config != nil {
+			_, ipNet6, err := net.ParseCIDR(ipPool.Spec.IPv6Config.CIDR)
+			if err != nil {
+				return nil, err
+			}
+			prefixLength6, _ := ipNet6.Mask.Size()
+			script += fmt.Sprintf(setIPv6AddrScript, ipPool.Spec.IPv6Config.ServerIP, prefixLength6, nic)
+			dhcpv6 = true
+		}
+
+		if boot := ipPool.Spec.IPv4Config.Boot; boot != nil && boot.TFTP != nil {
+			volumeName, mountPath, tftpRoot := tftpRootVolumeName, tftpRootPath, tftpRootPath
+			if shared {
+				volumeName = tftpRootVolumeName + "-" + nic
+				mountPath = tftpRootPath + "/" + nic
+				tftpRoot = nic + "=" + mountPath
+			}
+			volume := corev1.Volume{Name: volumeName}
+			if boot.TFTP.ConfigMapName != "" {
+				volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: boot.TFTP.ConfigMapName,
+					},
+				}
+			} else {
+				volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: boot.TFTP.PersistentVolumeClaimName,
+					ReadOnly:  true,
+				}
+			}
+			volumes = append(volumes, volume)
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: mountPath,
+				ReadOnly:  true,
+			})
+			args = append(args, "--tftp-root", tftpRoot)
+		}
+	}
+
+	networksStr, err := json.Marshal(networks)
+	if err != nil {
+		return nil, err
 	}
+
 	if noDHCP {
 		args = append(args, "--dry-run")
 	}
+	if dhcpv6 {
+		args = append(args, "--dhcpv6")
+	}
 
 	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -62,9 +179,7 @@ func prepareAgentPod(
 				multusNetworksAnnotationKey: string(networksStr),
 			},
 			Labels: map[string]string{
-				vmDHCPControllerLabelKey:     "agent",
-				util.IPPoolNamespaceLabelKey: ipPool.Namespace,
-				util.IPPoolNameLabelKey:      ipPool.Name,
+				vmDHCPControllerLabelKey: "agent",
 			},
 			Name:      name,
 			Namespace: agentNamespace,
@@ -98,7 +213,7 @@ func prepareAgentPod(
 					Command: []string{
 						"/bin/sh",
 						"-c",
-						fmt.Sprintf(setIPAddrScript, ipPool.Spec.IPv4Config.ServerIP, prefixLength),
+						script,
 					},
 					SecurityContext: &corev1.SecurityContext{
 						RunAsUser:  &runAsUserID,
@@ -122,12 +237,22 @@ func prepareAgentPod(
 							Value: name,
 						},
 					},
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "metrics",
+							ContainerPort: 8080,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					VolumeMounts: volumeMounts,
 					SecurityContext: &corev1.SecurityContext{
 						RunAsUser:  &runAsUserID,
 						RunAsGroup: &runAsGroupID,
 						Capabilities: &corev1.Capabilities{
 							Add: []corev1.Capability{
 								"NET_ADMIN",
+								// ARP probes for conflict detection
+								"NET_RAW",
 							},
 						},
 					},
@@ -149,10 +274,58 @@ func prepareAgentPod(
 					},
 				},
 			},
+			Volumes: volumes,
 		},
 	}, nil
 }
 
+// leaseSnapshotVolumeSource returns the volume the agents keep their lease
+// snapshots on, or nil if they keep none.
+func leaseSnapshotVolumeSource(options *config.ControllerOptions) *corev1.VolumeSource {
+	switch options.AgentLeaseSnapshot {
+	case config.AgentLeaseSnapshotHostPath:
+		hostPathType := corev1.HostPathDirectoryOrCreate
+		return &corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: leaseSnapshotPath,
+				Type: &hostPathType,
+			},
+		}
+	case config.AgentLeaseSnapshotPVC:
+		return &corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: options.AgentLeaseSnapshotClaimName,
+			},
+		}
+	case config.AgentLeaseSnapshotNone:
+		return nil
+	default:
+		return &corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		}
+	}
+}
+
+// addLeaseSnapshotVolume mounts the lease snapshot volume into the agent pod
+// and has the agent keep its snapshots there. A nil source leaves the pod
+// untouched.
+func addLeaseSnapshotVolume(pod *corev1.Pod, source *corev1.VolumeSource) {
+	if source == nil {
+		return
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name:         leaseSnapshotVolumeName,
+		VolumeSource: *source.DeepCopy(),
+	})
+	agent := &pod.Spec.Containers[0]
+	agent.VolumeMounts = append(agent.VolumeMounts, corev1.VolumeMount{
+		Name:      leaseSnapshotVolumeName,
+		MountPath: leaseSnapshotPath,
+	})
+	agent.Args = append(agent.Args, "--lease-snapshot-dir", leaseSnapshotPath)
+}
+
 func setRegisteredCondition(ipPool *networkv1.IPPool, status corev1.ConditionStatus, reason, message string) {
 	networkv1.Registered.SetStatus(ipPool, string(status))
 	networkv1.Registered.Reason(ipPool, reason)
@@ -243,6 +416,195 @@ func (b *IPPoolBuilder) Exclude(ipAddressList ...string) *IPPoolBuilder {
 	return b
 }
 
+func (b *IPPoolBuilder) Route(destination, gateway string) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.Routes = append(b.ipPool.Spec.IPv4Config.Routes, networkv1.Route{
+		Destination: destination,
+		Gateway:     gateway,
+	})
+	return b
+}
+
+func (b *IPPoolBuilder) CustomOption(code int, optionType networkv1.CustomOptionType, value string) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.CustomOptions = append(b.ipPool.Spec.IPv4Config.CustomOptions, networkv1.CustomOption{
+		Code:  code,
+		Type:  optionType,
+		Value: value,
+	})
+	return b
+}
+
+func (b *IPPoolBuilder) Boot(nextServer, filename string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv4Config.Boot == nil {
+		b.ipPool.Spec.IPv4Config.Boot = new(networkv1.BootConfig)
+	}
+	b.ipPool.Spec.IPv4Config.Boot.NextServer = nextServer
+	b.ipPool.Spec.IPv4Config.Boot.Filename = filename
+	return b
+}
+
+func (b *IPPoolBuilder) BootArchitecture(arch int, filename string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv4Config.Boot == nil {
+		b.ipPool.Spec.IPv4Config.Boot = new(networkv1.BootConfig)
+	}
+	b.ipPool.Spec.IPv4Config.Boot.Architectures = append(b.ipPool.Spec.IPv4Config.Boot.Architectures, networkv1.BootArchitecture{
+		Arch:     arch,
+		Filename: filename,
+	})
+	return b
+}
+
+func (b *IPPoolBuilder) IPXEScript(url string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv4Config.Boot == nil {
+		b.ipPool.Spec.IPv4Config.Boot = new(networkv1.BootConfig)
+	}
+	b.ipPool.Spec.IPv4Config.Boot.IPXEScriptURL = url
+	return b
+}
+
+func (b *IPPoolBuilder) TFTPConfigMap(name string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv4Config.Boot == nil {
+		b.ipPool.Spec.IPv4Config.Boot = new(networkv1.BootConfig)
+	}
+	if b.ipPool.Spec.IPv4Config.Boot.TFTP == nil {
+		b.ipPool.Spec.IPv4Config.Boot.TFTP = new(networkv1.TFTPConfig)
+	}
+	b.ipPool.Spec.IPv4Config.Boot.TFTP.ConfigMapName = name
+	return b
+}
+
+func (b *IPPoolBuilder) TFTPPersistentVolumeClaim(name string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv4Config.Boot == nil {
+		b.ipPool.Spec.IPv4Config.Boot = new(networkv1.BootConfig)
+	}
+	if b.ipPool.Spec.IPv4Config.Boot.TFTP == nil {
+		b.ipPool.Spec.IPv4Config.Boot.TFTP = new(networkv1.TFTPConfig)
+	}
+	b.ipPool.Spec.IPv4Config.Boot.TFTP.PersistentVolumeClaimName = name
+	return b
+}
+
+func (b *IPPoolBuilder) RelayCircuitID(circuitID string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv4Config.Relay == nil {
+		b.ipPool.Spec.IPv4Config.Relay = new(networkv1.RelayConfig)
+	}
+	b.ipPool.Spec.IPv4Config.Relay.CircuitIDs = append(b.ipPool.Spec.IPv4Config.Relay.CircuitIDs, circuitID)
+	return b
+}
+
+func (b *IPPoolBuilder) RelayRemoteID(remoteID string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv4Config.Relay == nil {
+		b.ipPool.Spec.IPv4Config.Relay = new(networkv1.RelayConfig)
+	}
+	b.ipPool.Spec.IPv4Config.Relay.RemoteIDs = append(b.ipPool.Spec.IPv4Config.Relay.RemoteIDs, remoteID)
+	return b
+}
+
+func (b *IPPoolBuilder) LeaseTime(leaseTime int) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.LeaseTime = &leaseTime
+	return b
+}
+
+func (b *IPPoolBuilder) RenewalTime(renewalTime int) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.RenewalTime = &renewalTime
+	return b
+}
+
+func (b *IPPoolBuilder) RebindingTime(rebindingTime int) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.RebindingTime = &rebindingTime
+	return b
+}
+
+func (b *IPPoolBuilder) InfiniteLease() *IPPoolBuilder {
+	infiniteLease := true
+	b.ipPool.Spec.IPv4Config.InfiniteLease = &infiniteLease
+	return b
+}
+
+func (b *IPPoolBuilder) DynamicRange(start, end string) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.DynamicRange = &networkv1.DynamicRange{
+		Start: start,
+		End:   end,
+	}
+	return b
+}
+
+func (b *IPPoolBuilder) Class(class networkv1.ClientClass) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.Classes = append(b.ipPool.Spec.IPv4Config.Classes, class)
+	return b
+}
+
+func (b *IPPoolBuilder) DomainName(domainName string) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.DomainName = &domainName
+	return b
+}
+
+func (b *IPPoolBuilder) DDNS(ddnsConfig networkv1.DDNSConfig) *IPPoolBuilder {
+	b.ipPool.Spec.IPv4Config.DDNS = &ddnsConfig
+	return b
+}
+
+func (b *IPPoolBuilder) DynamicLease(ipAddress, macAddress string, expiryTime metav1.Time) *IPPoolBuilder {
+	if b.ipPool.Status.IPv4 == nil {
+		b.ipPool.Status.IPv4 = new(networkv1.IPv4Status)
+	}
+	if b.ipPool.Status.IPv4.DynamicLeases == nil {
+		b.ipPool.Status.IPv4.DynamicLeases = make(map[string]networkv1.DynamicLease)
+	}
+	b.ipPool.Status.IPv4.DynamicLeases[ipAddress] = networkv1.DynamicLease{
+		MACAddress: macAddress,
+		ExpiryTime: expiryTime,
+	}
+	return b
+}
+
+func (b *IPPoolBuilder) Quarantined(ipAddress, macAddress string, expiryTime metav1.Time) *IPPoolBuilder {
+	if b.ipPool.Status.IPv4 == nil {
+		b.ipPool.Status.IPv4 = new(networkv1.IPv4Status)
+	}
+	if b.ipPool.Status.IPv4.Quarantined == nil {
+		b.ipPool.Status.IPv4.Quarantined = make(map[string]networkv1.QuarantinedAddress)
+	}
+	b.ipPool.Status.IPv4.Quarantined[ipAddress] = networkv1.QuarantinedAddress{
+		MACAddress: macAddress,
+		ExpiryTime: expiryTime,
+	}
+	return b
+}
+
+func (b *IPPoolBuilder) IPv6Config(cidr, serverIP string) *IPPoolBuilder {
+	if b.ipPool.Spec.IPv6Config == nil {
+		b.ipPool.Spec.IPv6Config = new(networkv1.IPv6Config)
+	}
+	b.ipPool.Spec.IPv6Config.CIDR = cidr
+	b.ipPool.Spec.IPv6Config.ServerIP = serverIP
+	return b
+}
+
+func (b *IPPoolBuilder) IPv6DNS(dnsServers ...string) *IPPoolBuilder {
+	b.ipPool.Spec.IPv6Config.DNS = append(b.ipPool.Spec.IPv6Config.DNS, dnsServers...)
+	return b
+}
+
+func (b *IPPoolBuilder) IPv6Lifetime(preferredLifetime, validLifetime int) *IPPoolBuilder {
+	b.ipPool.Spec.IPv6Config.PreferredLifetime = &preferredLifetime
+	b.ipPool.Spec.IPv6Config.ValidLifetime = &validLifetime
+	return b
+}
+
+func (b *IPPoolBuilder) AllocatedIPv6(ipAddress, duid, macAddress string) *IPPoolBuilder {
+	if b.ipPool.Status.IPv6 == nil {
+		b.ipPool.Status.IPv6 = new(networkv1.IPv6Status)
+	}
+	if b.ipPool.Status.IPv6.Allocated == nil {
+		b.ipPool.Status.IPv6.Allocated = make(map[string]networkv1.IPv6Binding, 2)
+	}
+	b.ipPool.Status.IPv6.Allocated[ipAddress] = networkv1.IPv6Binding{
+		DUID:       duid,
+		MACAddress: macAddress,
+	}
+	return b
+}
+
 func (b *IPPoolBuilder) AgentPodRef(namespace, name, image, uid string) *IPPoolBuilder {
 	if b.ipPool.Status.AgentPodRef == nil {
 		b.ipPool.Status.AgentPodRef = new(networkv1.PodReference)
@@ -265,6 +627,63 @@ func (b *IPPoolBuilder) Allocated(ipAddress, macAddress string) *IPPoolBuilder {
 	return b
 }
 
+func (b *IPPoolBuilder) Lease(macAddress, ipAddress string, state networkv1.LeaseState) *IPPoolBuilder {
+	if b.ipPool.Status.IPv4 == nil {
+		b.ipPool.Status.IPv4 = new(networkv1.IPv4Status)
+	}
+	if b.ipPool.Status.IPv4.Leases == nil {
+		b.ipPool.Status.IPv4.Leases = make(map[string]networkv1.LeaseStatus, 2)
+	}
+	b.ipPool.Status.IPv4.Leases[macAddress] = networkv1.LeaseStatus{
+		IPAddress: ipAddress,
+		State:     state,
+	}
+	return b
+}
+
+func (b *IPPoolBuilder) OverrideCustomOption(macAddress string, code int, optionType networkv1.CustomOptionType, value string) *IPPoolBuilder {
+	if b.ipPool.Status.IPv4 == nil {
+		b.ipPool.Status.IPv4 = new(networkv1.IPv4Status)
+	}
+	if b.ipPool.Status.IPv4.Overrides == nil {
+		b.ipPool.Status.IPv4.Overrides = make(map[string]networkv1.LeaseOverride, 2)
+	}
+	override := b.ipPool.Status.IPv4.Overrides[macAddress]
+	override.CustomOptions = append(override.CustomOptions, networkv1.CustomOption{
+		Code:  code,
+		Type:  optionType,
+		Value: value,
+	})
+	b.ipPool.Status.IPv4.Overrides[macAddress] = override
+	return b
+}
+
+func (b *IPPoolBuilder) OverrideHostname(macAddress, hostname string) *IPPoolBuilder {
+	if b.ipPool.Status.IPv4 == nil {
+		b.ipPool.Status.IPv4 = new(networkv1.IPv4Status)
+	}
+	if b.ipPool.Status.IPv4.Overrides == nil {
+		b.ipPool.Status.IPv4.Overrides = make(map[string]networkv1.LeaseOverride, 2)
+	}
+	override := b.ipPool.Status.IPv4.Overrides[macAddress]
+	override.Hostname = hostname
+	b.ipPool.Status.IPv4.Overrides[macAddress] = override
+	return b
+}
+
+func (b *IPPoolBuilder) OverrideClientID(macAddress, clientID string) *IPPoolBuilder {
+	if b.ipPool.Status.IPv4 == nil {
+		b.ipPool.Status.IPv4 = new(networkv1.IPv4Status)
+	}
+	if b.ipPool.Status.IPv4.Overrides == nil {
+		b.ipPool.Status.IPv4.Overrides = make(map[string]networkv1.LeaseOverride, 2)
+	}
+	override := b.ipPool.Status.IPv4.Overrides[macAddress]
+	override.ClientID = clientID
+	b.ipPool.Status.IPv4.Overrides[macAddress] = override
+	return b
+}
+
 func (b *IPPoolBuilder) Available(count int) *IPPoolBuilder {
 	if b.ipPool.Status.IPv4 == nil {
 		b.ipPool.Status.IPv4 = new(networkv1.IPv4Status)
@@ -440,4 +859,10 @@ func SanitizeStatus(status *networkv1.IPPoolStatus) {
 		status.Conditions[i].LastTransitionTime = ""
 		status.Conditions[i].LastUpdateTime = ""
 	}
+	if status.IPv4 != nil {
+		for ip, quarantined := range status.IPv4.Quarantined {
+			quarantined.ExpiryTime = metav1.NewTime(now)
+			status.IPv4.Quarantined[ip] = quarantined
+		}
+	}
 }
@@ -2,8 +2,12 @@ package ippool
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/rancher/wrangler/v3/pkg/kv"
 	"github.com/rancher/wrangler/v3/pkg/relatedresource"
@@ -31,6 +35,7 @@ const (
 
 	multusNetworksAnnotationKey         = "k8s.v1.cni.cncf.io/networks"
 	holdIPPoolAgentUpgradeAnnotationKey = "network.harvesterhci.io/hold-ippool-agent-upgrade"
+	ipPoolsAnnotationKey                = network.GroupName + "/ippools"
 
 	vmDHCPControllerLabelKey = network.GroupName + "/vm-dhcp-controller"
 	clusterNetworkLabelKey   = network.GroupName + "/clusternetwork"
@@ -38,10 +43,19 @@ const (
 	setIPAddrScript = `
 #!/usr/bin/env sh
 set -ex
-
-ip address flush dev eth1
-ip address add %s/%d dev eth1
 `
+	setIPv4AddrScript = `
+ip address flush dev %[3]s
+ip address add %[1]s/%[2]d dev %[3]s
+`
+	setIPv6AddrScript = `ip -6 address add %[1]s/%[2]d dev %[3]s
+`
+
+	tftpRootVolumeName = "tftp-root"
+	tftpRootPath       = "/var/lib/vm-dhcp-agent/tftp"
+
+	leaseSnapshotVolumeName = "lease-snapshot"
+	leaseSnapshotPath       = "/var/lib/vm-dhcp-agent/leases"
 )
 
 var (
@@ -59,6 +73,8 @@ type Handler struct {
 	agentNamespace          string
 	agentImage              *config.Image
 	agentServiceAccountName string
+	agentPlacement          string
+	agentLeaseSnapshot      *corev1.VolumeSource
 	noAgent                 bool
 	noDHCP                  bool
 
@@ -84,6 +100,8 @@ func Register(ctx context.Context, management *config.Management) error {
 		agentNamespace:          management.Options.AgentNamespace,
 		agentImage:              management.Options.AgentImage,
 		agentServiceAccountName: management.Options.AgentServiceAccountName,
+		agentPlacement:          management.Options.AgentPlacement,
+		agentLeaseSnapshot:      leaseSnapshotVolumeSource(management.Options),
 		noAgent:                 management.Options.NoAgent,
 		noDHCP:                  management.Options.NoDHCP,
 
@@ -132,6 +150,17 @@ func Register(ctx context.Context, management *config.Management) error {
 			return nil, err
 		}
 		for _, pod := range pods {
+			// A shared agent pod serves all the IPPools of a cluster network
+			if refs, ok := pod.Annotations[ipPoolsAnnotationKey]; ok {
+				for _, ref := range strings.Split(refs, ",") {
+					ipPoolNamespace, ipPoolName := kv.RSplit(ref, "/")
+					keys = append(keys, relatedresource.Key{
+						Namespace: ipPoolNamespace,
+						Name:      ipPoolName,
+					})
+				}
+				continue
+			}
 			key := relatedresource.Key{
 				Namespace: pod.Labels[util.IPPoolNamespaceLabelKey],
 				Name:      pod.Labels[util.IPPoolNameLabelKey],
@@ -193,6 +222,16 @@ func (h *Handler) OnChange(key string, ipPool *networkv1.IPPool) (*networkv1.IPP
 		ipv4Status = new(networkv1.IPv4Status)
 	}
 
+	h.syncDynamicLeases(ipPool.Spec.NetworkName, ipv4Status)
+
+	requeueAfter, err := h.syncQuarantinedAddresses(ipPool.Spec.NetworkName, ipv4Status)
+	if err != nil {
+		return nil, err
+	}
+	if requeueAfter > 0 {
+		h.ippoolController.EnqueueAfter(ipPool.Namespace, ipPool.Name, requeueAfter)
+	}
+
 	used, err := h.ipAllocator.GetUsed(ipPool.Spec.NetworkName)
 	if err != nil {
 		return nil, err
@@ -294,6 +333,10 @@ func (h *Handler) DeployAgent(ipPool *networkv1.IPPool, status networkv1.IPPoolS
 		return status, fmt.Errorf("could not find clusternetwork for nad %s", ipPool.Spec.NetworkName)
 	}
 
+	if h.agentPlacement == config.AgentPlacementClusterNetwork {
+		return h.deploySharedAgent(ipPool, status, clusterNetwork)
+	}
+
 	if ipPool.Status.AgentPodRef != nil {
 		status.AgentPodRef.Image = h.getAgentImage(ipPool)
 		pod, err := h.podCache.Get(ipPool.Status.AgentPodRef.Namespace, ipPool.Status.AgentPodRef.Name)
@@ -320,6 +363,7 @@ func (h *Handler) DeployAgent(ipPool *networkv1.IPPool, status networkv1.IPPoolS
 	if err != nil {
 		return status, err
 	}
+	addLeaseSnapshotVolume(agent, h.agentLeaseSnapshot)
 
 	if status.AgentPodRef == nil {
 		status.AgentPodRef = new(networkv1.PodReference)
@@ -398,12 +442,27 @@ func (h *Handler) BuildCache(ipPool *networkv1.IPPool, status networkv1.IPPoolSt
 	// (Re)build caches from IPPool status
 	if ipPool.Status.IPv4 != nil {
 		for ip, mac := range ipPool.Status.IPv4.Allocated {
-			if mac == util.ExcludedMark || mac == util.ReservedMark {
+			if mac == util.ReservedMark {
+				continue
+			}
+			// Leased out by the agent from the dynamic range, which lies
+			// outside of the pool range
+			if mac == util.DynamicMark {
+				continue
+			}
+			if mac == util.ExcludedMark {
+				if err := h.ipAllocator.RevokeIP(ipPool.Spec.NetworkName, ip); err != nil {
+					return status, err
+				}
 				continue
 			}
 			if _, err := h.ipAllocator.AllocateIP(ipPool.Spec.NetworkName, ip); err != nil {
 				return status, err
 			}
+			// Quarantined, not bound to any VM
+			if mac == util.QuarantinedMark {
+				continue
+			}
 			if err := h.cacheAllocator.AddMAC(ipPool.Spec.NetworkName, mac, ip); err != nil {
 				return status, err
 			}
@@ -416,6 +475,82 @@ func (h *Handler) BuildCache(ipPool *networkv1.IPPool, status networkv1.IPPoolSt
 	return status, nil
 }
 
+// syncDynamicLeases marks the addresses the agent leased out from the dynamic
+// range as dynamic, until the leases expire or are reclaimed by the agent. The
+// dynamic range lies outside of the pool range, so the IPAM never hands these
+// addresses out to the VMs and does not need to keep track of them.
+func (h *Handler) syncDynamicLeases(networkName string, ipv4Status *networkv1.IPv4Status) {
+	now := time.Now()
+
+	if ipv4Status.Allocated == nil && len(ipv4Status.DynamicLeases) > 0 {
+		ipv4Status.Allocated = make(map[string]string, len(ipv4Status.DynamicLeases))
+	}
+
+	for ip, lease := range ipv4Status.DynamicLeases {
+		if lease.ExpiryTime.Time.Before(now) {
+			continue
+		}
+		if _, exists := ipv4Status.Allocated[ip]; exists {
+			continue
+		}
+		ipv4Status.Allocated[ip] = util.DynamicMark
+		logrus.Infof("(ippool.syncDynamicLeases) ip %s was leased to %s from the dynamic range of %s", ip, lease.MACAddress, networkName)
+	}
+
+	for ip, mac := range ipv4Status.Allocated {
+		if mac != util.DynamicMark {
+			continue
+		}
+		if lease, ok := ipv4Status.DynamicLeases[ip]; ok && !lease.ExpiryTime.Time.Before(now) {
+			continue
+		}
+		delete(ipv4Status.Allocated, ip)
+		logrus.Infof("(ippool.syncDynamicLeases) dynamically leased ip %s was reclaimed from %s", ip, networkName)
+	}
+}
+
+// syncQuarantinedAddresses returns the IP addresses declined by the clients or
+// found in use by other hosts to the IPAM once their quarantine has expired,
+// or has been lifted as the NIC that declined them went away. The returned
+// duration is the time left until the next quarantine expires, if any.
+func (h *Handler) syncQuarantinedAddresses(networkName string, ipv4Status *networkv1.IPv4Status) (time.Duration, error) {
+	now := time.Now()
+
+	var requeueAfter time.Duration
+	for ip, mac := range ipv4Status.Allocated {
+		if mac != util.QuarantinedMark {
+			continue
+		}
+		if quarantined, ok := ipv4Status.Quarantined[ip]; ok && quarantined.ExpiryTime.Time.After(now) {
+			if left := quarantined.ExpiryTime.Time.Sub(now); requeueAfter == 0 || left < requeueAfter {
+				requeueAfter = left
+			}
+			continue
+		}
+		isAllocated, err := h.ipAllocator.IsAllocated(networkName, ip)
+		if err != nil {
+			return 0, err
+		}
+		if isAllocated {
+			if err := h.ipAllocator.DeallocateIP(networkName, ip); err != nil {
+				return 0, err
+			}
+		}
+		delete(ipv4Status.Allocated, ip)
+		delete(ipv4Status.Quarantined, ip)
+		logrus.Infof("(ippool.syncQuarantinedAddresses) quarantined ip %s was released in ipam %s", ip, networkName)
+	}
+
+	// Quarantines of IP addresses no longer marked as such
+	for ip := range ipv4Status.Quarantined {
+		if ipv4Status.Allocated[ip] != util.QuarantinedMark {
+			delete(ipv4Status.Quarantined, ip)
+		}
+	}
+
+	return requeueAfter, nil
+}
+
 // MonitorAgent reconciles ipPool and keeps an eye on the agent pod. If the
 // running agent pod does not match to the one record in ipPool's status,
 // MonitorAgent tries to delete it. The returned status reports whether the
@@ -440,7 +575,13 @@ func (h *Handler) MonitorAgent(ipPool *networkv1.IPPool, status networkv1.IPPool
 		return status, err
 	}
 
-	if agentPod.GetUID() != ipPool.Status.AgentPodRef.UID || agentPod.Spec.Containers[0].Image != ipPool.Status.AgentPodRef.Image {
+	if h.agentPlacement == config.AgentPlacementClusterNetwork {
+		// The shared agent pod is replaced by DeployAgent only, as it serves
+		// other IPPools as well
+		if agentPod.GetUID() != ipPool.Status.AgentPodRef.UID {
+			return status, fmt.Errorf("agent pod %s uid mismatch", agentPod.Name)
+		}
+	} else if agentPod.GetUID() != ipPool.Status.AgentPodRef.UID || agentPod.Spec.Containers[0].Image != ipPool.Status.AgentPodRef.Image {
 		if agentPod.DeletionTimestamp != nil {
 			return status, fmt.Errorf("agent pod %s marked for deletion", agentPod.Name)
 		}
@@ -459,6 +600,198 @@ func (h *Handler) MonitorAgent(ipPool *networkv1.IPPool, status networkv1.IPPool
 	return status, nil
 }
 
+// deploySharedAgent ensures there's an agent pod serving all the IPPools of
+// the cluster network, and records it in the status of ipPool. The network
+// interfaces of a pod cannot be changed, but the agent stops serving the
+// IPPools which leave the cluster network by itself, so the agent pod is kept
+// as long as it serves all the IPPools with the current image. Otherwise a
+// new agent pod is deployed next to it, and the former one is removed only
+// once the new one is ready.
+func (h *Handler) deploySharedAgent(ipPool *networkv1.IPPool, status networkv1.IPPoolStatus, clusterNetwork string) (networkv1.IPPoolStatus, error) {
+	ipPools, err := h.ipPoolsOf(clusterNetwork)
+	if err != nil {
+		return status, err
+	}
+
+	agent, err := prepareSharedAgentPod(ipPools, h.noDHCP, h.agentNamespace, clusterNetwork, h.agentServiceAccountName, h.agentImage)
+	if err != nil {
+		return status, err
+	}
+	addLeaseSnapshotVolume(agent, h.agentLeaseSnapshot)
+
+	agentPods, err := h.sharedAgentPodsOf(clusterNetwork)
+	if err != nil {
+		return status, err
+	}
+
+	var agentPod *corev1.Pod
+	for _, pod := range agentPods {
+		if pod.DeletionTimestamp != nil || pod.Spec.Containers[0].Image != agent.Spec.Containers[0].Image || !servesIPPools(pod, ipPools) {
+			continue
+		}
+		if agentPod == nil || (!isPodReady(agentPod) && isPodReady(pod)) {
+			agentPod = pod
+		}
+	}
+
+	if agentPod == nil {
+		agentPod, err = h.podCache.Get(agent.Namespace, agent.Name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return status, err
+			}
+
+			agentPod, err = h.podClient.Create(agent)
+			if err != nil {
+				return status, err
+			}
+
+			logrus.Infof("(ippool.DeployAgent) agent %s for clusternetwork %s has been deployed", agentPod.Name, clusterNetwork)
+		} else if agentPod.DeletionTimestamp != nil {
+			return status, fmt.Errorf("agent pod %s marked for deletion", agentPod.Name)
+		}
+	}
+
+	if isPodReady(agentPod) {
+		for _, pod := range agentPods {
+			if pod.Name == agentPod.Name || pod.DeletionTimestamp != nil {
+				continue
+			}
+			if err := h.podClient.Delete(pod.Namespace, pod.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return status, err
+			}
+			logrus.Infof("(ippool.DeployAgent) agent %s for clusternetwork %s has been superseded by %s", pod.Name, clusterNetwork, agentPod.Name)
+		}
+	} else {
+		// Stay with the former agent pod until the new one is ready
+		for _, pod := range agentPods {
+			if pod.DeletionTimestamp == nil && isPodReady(pod) && servesIPPools(pod, []*networkv1.IPPool{ipPool}) {
+				agentPod = pod
+				break
+			}
+		}
+	}
+
+	logrus.Debugf("(ippool.DeployAgent) ippool %s/%s is served by agent %s", ipPool.Namespace, ipPool.Name, agentPod.Name)
+
+	status.AgentPodRef = &networkv1.PodReference{
+		Namespace: agentPod.Namespace,
+		Name:      agentPod.Name,
+		Image:     agentPod.Spec.Containers[0].Image,
+		UID:       agentPod.GetUID(),
+	}
+
+	return status, nil
+}
+
+// cleanupSharedAgent removes the agent pods of the cluster network once none
+// of its IPPools is left. Otherwise they keep running for the other IPPools,
+// and stop serving ipPool by themselves.
+func (h *Handler) cleanupSharedAgent(ipPool *networkv1.IPPool) error {
+	agentPod, err := h.podCache.Get(ipPool.Status.AgentPodRef.Namespace, ipPool.Status.AgentPodRef.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	clusterNetwork := agentPod.Labels[clusterNetworkLabelKey]
+	ipPools, err := h.ipPoolsOf(clusterNetwork)
+	if err != nil {
+		return err
+	}
+	for _, other := range ipPools {
+		if other.Namespace != ipPool.Namespace || other.Name != ipPool.Name {
+			logrus.Infof("(ippool.cleanup) agent %s keeps serving the other ippools of clusternetwork %s", agentPod.Name, clusterNetwork)
+			return nil
+		}
+	}
+
+	agentPods, err := h.sharedAgentPodsOf(clusterNetwork)
+	if err != nil {
+		return err
+	}
+	for _, pod := range agentPods {
+		logrus.Infof("(ippool.cleanup) remove the agent %s/%s of clusternetwork %s", pod.Namespace, pod.Name, clusterNetwork)
+		if err := h.podClient.Delete(pod.Namespace, pod.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sharedAgentPodsOf returns the agent pods of the cluster network, including
+// the ones being replaced.
+func (h *Handler) sharedAgentPodsOf(clusterNetwork string) ([]*corev1.Pod, error) {
+	return h.podCache.List(h.agentNamespace, labels.SelectorFromSet(labels.Set{
+		vmDHCPControllerLabelKey: "agent",
+		clusterNetworkLabelKey:   clusterNetwork,
+	}))
+}
+
+// servesIPPools reports whether the agent pod has a network interface for
+// each of the IPPools, attached to the network of the IPPool.
+func servesIPPools(pod *corev1.Pod, ipPools []*networkv1.IPPool) bool {
+	var networks []Network
+	if err := json.Unmarshal([]byte(pod.Annotations[multusNetworksAnnotationKey]), &networks); err != nil {
+		return false
+	}
+	refs := strings.Split(pod.Annotations[ipPoolsAnnotationKey], ",")
+	if len(refs) != len(networks) {
+		return false
+	}
+
+	served := make(map[string]string, len(refs))
+	for i, ref := range refs {
+		served[ref] = networks[i].Namespace + "/" + networks[i].Name
+	}
+	for _, ipPool := range ipPools {
+		if served[ipPool.Namespace+"/"+ipPool.Name] != ipPool.Spec.NetworkName {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ipPoolsOf returns the active IPPools of the cluster network, sorted by
+// their namespaced names so that they keep their network interfaces.
+func (h *Handler) ipPoolsOf(clusterNetwork string) ([]*networkv1.IPPool, error) {
+	ipPools, err := h.ippoolCache.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*networkv1.IPPool
+	for _, ipPool := range ipPools {
+		if ipPool.DeletionTimestamp != nil || (ipPool.Spec.Paused != nil && *ipPool.Spec.Paused) {
+			continue
+		}
+		nadNamespace, nadName := kv.RSplit(ipPool.Spec.NetworkName, "/")
+		nad, err := h.nadCache.Get(nadNamespace, nadName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if nad.Labels[clusterNetworkLabelKey] == clusterNetwork {
+			result = append(result, ipPool)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
 func isPodReady(pod *corev1.Pod) bool {
 	for _, c := range pod.Status.Conditions {
 		if c.Type == corev1.PodReady {
@@ -481,9 +814,15 @@ func (h *Handler) cleanup(ipPool *networkv1.IPPool) error {
 		return nil
 	}
 
-	logrus.Infof("(ippool.cleanup) remove the backing agent %s/%s for ippool %s/%s", ipPool.Status.AgentPodRef.Namespace, ipPool.Status.AgentPodRef.Name, ipPool.Namespace, ipPool.Name)
-	if err := h.podClient.Delete(ipPool.Status.AgentPodRef.Namespace, ipPool.Status.AgentPodRef.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
-		return err
+	if h.agentPlacement == config.AgentPlacementClusterNetwork {
+		if err := h.cleanupSharedAgent(ipPool); err != nil {
+			return err
+		}
+	} else {
+		logrus.Infof("(ippool.cleanup) remove the backing agent %s/%s for ippool %s/%s", ipPool.Status.AgentPodRef.Namespace, ipPool.Status.AgentPodRef.Name, ipPool.Namespace, ipPool.Name)
+		if err := h.podClient.Delete(ipPool.Status.AgentPodRef.Namespace, ipPool.Status.AgentPodRef.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
 	}
 
 	h.ipAllocator.DeleteIPSubnet(ipPool.Spec.NetworkName)
@@ -3,13 +3,17 @@ package ippool
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 
+	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	"github.com/harvester/vm-dhcp-controller/pkg/cache"
 	"github.com/harvester/vm-dhcp-controller/pkg/config"
 	"github.com/harvester/vm-dhcp-controller/pkg/generated/clientset/versioned/fake"
@@ -268,6 +272,70 @@ func TestHandler_OnChange(t *testing.T) {
 		assert.Equal(t, fmt.Sprintf("pods \"%s\" not found", testPodName), err.Error())
 	})
 
+	t.Run("pause ippool sharing the agent pod", func(t *testing.T) {
+		key := testIPPoolNamespace + "/" + testIPPoolName
+		givenIPPool1 := newTestIPPoolBuilder().
+			ServerIP(testServerIP1).
+			CIDR(testCIDR).
+			NetworkName(testNetworkName).Build()
+		givenIPPool2 := NewIPPoolBuilder(testIPPoolNamespace, "net-2").
+			ServerIP("10.0.0.2").
+			CIDR("10.0.0.0/24").
+			NetworkName(testNADNamespace + "/net-2").Build()
+		givenNAD1 := newTestNetworkAttachmentDefinitionBuilder().
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+		givenNAD2 := NewNetworkAttachmentDefinitionBuilder(testNADNamespace, "net-2").
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+		givenImage := config.NewImage(testImageRepository, testImageTag)
+		givenPod, err := prepareSharedAgentPod([]*networkv1.IPPool{givenIPPool1, givenIPPool2}, false, testPodNamespace, testClusterNetwork, testServiceAccountName, givenImage)
+		assert.Nil(t, err)
+		givenIPPool1 = newTestIPPoolBuilder().
+			ServerIP(testServerIP1).
+			CIDR(testCIDR).
+			NetworkName(testNetworkName).
+			Paused().
+			AgentPodRef(testPodNamespace, givenPod.Name, testImage, "").Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset(givenIPPool1, givenIPPool2)
+		for _, nad := range []*cniv1.NetworkAttachmentDefinition{givenNAD1, givenNAD2} {
+			err := clientset.Tracker().Create(nadGVR, nad, nad.Namespace)
+			assert.Nil(t, err, "mock resource should add into fake controller tracker")
+		}
+
+		k8sclientset := k8sfake.NewSimpleClientset()
+		err = k8sclientset.Tracker().Add(givenPod)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		handler := Handler{
+			agentNamespace:   testPodNamespace,
+			agentImage:       givenImage,
+			agentPlacement:   config.AgentPlacementClusterNetwork,
+			ipAllocator:      ipam.New(),
+			cacheAllocator:   cache.New(),
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			podClient:        fakeclient.PodClient(k8sclientset.CoreV1().Pods),
+			podCache:         fakeclient.PodCache(k8sclientset.CoreV1().Pods),
+			nadClient:        fakeclient.NetworkAttachmentDefinitionClient(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		ipPool, err := handler.OnChange(key, givenIPPool1)
+		assert.Nil(t, err)
+		assert.Nil(t, ipPool.Status.AgentPodRef)
+
+		// The agent pod keeps serving net-2
+		_, err = handler.podClient.Get(testPodNamespace, givenPod.Name, metav1.GetOptions{})
+		assert.Nil(t, err)
+	})
+
 	t.Run("resume ippool", func(t *testing.T) {
 		key := testIPPoolNamespace + "/" + testIPPoolName
 		givenIPAllocator := newTestIPAllocatorBuilder().
@@ -384,6 +452,54 @@ func TestHandler_DeployAgent(t *testing.T) {
 		assert.Equal(t, expectedPod, pod)
 	})
 
+	t.Run("lease snapshot on persistent volume claim", func(t *testing.T) {
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP1).
+			CIDR(testCIDR).
+			NetworkName(testNetworkName).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		k8sclientset := k8sfake.NewSimpleClientset()
+
+		handler := Handler{
+			agentNamespace: testPodNamespace,
+			agentImage: &config.Image{
+				Repository: testImageRepository,
+				Tag:        testImageTag,
+			},
+			agentServiceAccountName: testServiceAccountName,
+			agentLeaseSnapshot: leaseSnapshotVolumeSource(&config.ControllerOptions{
+				AgentLeaseSnapshot:          config.AgentLeaseSnapshotPVC,
+				AgentLeaseSnapshotClaimName: "leases",
+			}),
+			nadCache:  fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			podClient: fakeclient.PodClient(k8sclientset.CoreV1().Pods),
+			podCache:  fakeclient.PodCache(k8sclientset.CoreV1().Pods),
+		}
+
+		_, err = handler.DeployAgent(givenIPPool, givenIPPool.Status)
+		assert.Nil(t, err)
+
+		pod, err := handler.podClient.Get(testPodNamespace, testPodName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		volume := pod.Spec.Volumes[len(pod.Spec.Volumes)-1]
+		assert.Equal(t, leaseSnapshotVolumeName, volume.Name)
+		assert.Equal(t, "leases", volume.PersistentVolumeClaim.ClaimName)
+		args := pod.Spec.Containers[0].Args
+		assert.Equal(t, []string{"--lease-snapshot-dir", leaseSnapshotPath}, args[len(args)-2:])
+	})
+
 	t.Run("ippool paused", func(t *testing.T) {
 		givenIPPool := newTestIPPoolBuilder().
 			Paused().Build()
@@ -725,6 +841,206 @@ func TestHandler_DeployAgent(t *testing.T) {
 		_, err = handler.DeployAgent(givenIPPool, givenIPPool.Status)
 		assert.Equal(t, fmt.Sprintf("agent pod %s uid mismatch", testPodName), err.Error())
 	})
+
+	t.Run("ippools packed per cluster network", func(t *testing.T) {
+		givenIPPool1 := newTestIPPoolBuilder().
+			ServerIP(testServerIP1).
+			CIDR(testCIDR).
+			NetworkName(testNetworkName).Build()
+		givenIPPool2 := NewIPPoolBuilder(testIPPoolNamespace, "net-2").
+			ServerIP("10.0.0.2").
+			CIDR("10.0.0.0/24").
+			NetworkName(testNADNamespace + "/net-2").Build()
+		givenIPPool3 := NewIPPoolBuilder(testIPPoolNamespace, "net-3").
+			ServerIP("10.0.1.2").
+			CIDR("10.0.1.0/24").
+			NetworkName(testNADNamespace + "/net-3").Build()
+		givenNAD1 := newTestNetworkAttachmentDefinitionBuilder().
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+		givenNAD2 := NewNetworkAttachmentDefinitionBuilder(testNADNamespace, "net-2").
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+		givenNAD3 := NewNetworkAttachmentDefinitionBuilder(testNADNamespace, "net-3").
+			Label(clusterNetworkLabelKey, "other").Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset(givenIPPool1, givenIPPool2, givenIPPool3)
+		for _, nad := range []*cniv1.NetworkAttachmentDefinition{givenNAD1, givenNAD2, givenNAD3} {
+			err := clientset.Tracker().Create(nadGVR, nad, nad.Namespace)
+			assert.Nil(t, err, "mock resource should add into fake controller tracker")
+		}
+
+		k8sclientset := k8sfake.NewSimpleClientset()
+
+		handler := Handler{
+			agentNamespace: testPodNamespace,
+			agentImage: &config.Image{
+				Repository: testImageRepository,
+				Tag:        testImageTag,
+			},
+			agentServiceAccountName: testServiceAccountName,
+			agentPlacement:          config.AgentPlacementClusterNetwork,
+			agentLeaseSnapshot:      leaseSnapshotVolumeSource(&config.ControllerOptions{}),
+			ippoolCache:             fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:                fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			podClient:               fakeclient.PodClient(k8sclientset.CoreV1().Pods),
+			podCache:                fakeclient.PodCache(k8sclientset.CoreV1().Pods),
+		}
+
+		status, err := handler.DeployAgent(givenIPPool2, givenIPPool2.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, testPodNamespace, status.AgentPodRef.Namespace)
+		assert.Equal(t, testImage, status.AgentPodRef.Image)
+		expectedPodName := status.AgentPodRef.Name
+
+		pod, err := handler.podClient.Get(testPodNamespace, expectedPodName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, testClusterNetwork, pod.Labels[clusterNetworkLabelKey])
+		assert.Equal(t, testIPPoolNamespace+"/"+testIPPoolName+","+testIPPoolNamespace+"/net-2", pod.Annotations[ipPoolsAnnotationKey])
+		assert.Equal(t, `[{"namespace":"default","name":"net-1","interface":"eth1"},{"namespace":"default","name":"net-2","interface":"eth2"}]`, pod.Annotations[multusNetworksAnnotationKey])
+		assert.Equal(t, []string{
+			"--ippool-ref", testIPPoolNamespace + "/" + testIPPoolName + "=eth1",
+			"--ippool-ref", testIPPoolNamespace + "/net-2=eth2",
+			"--lease-snapshot-dir", leaseSnapshotPath,
+		}, pod.Spec.Containers[0].Args)
+		assert.NotNil(t, pod.Spec.Volumes[len(pod.Spec.Volumes)-1].EmptyDir)
+
+		// The other ippool of the cluster network shares the agent pod
+		status, err = handler.DeployAgent(givenIPPool1, givenIPPool1.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedPodName, status.AgentPodRef.Name)
+	})
+
+	t.Run("shared agent pod kept when ippools leave", func(t *testing.T) {
+		givenIPPool1 := newTestIPPoolBuilder().
+			ServerIP(testServerIP1).
+			CIDR(testCIDR).
+			NetworkName(testNetworkName).Build()
+		givenIPPool2 := NewIPPoolBuilder(testIPPoolNamespace, "net-2").
+			ServerIP("10.0.0.2").
+			CIDR("10.0.0.0/24").
+			NetworkName(testNADNamespace + "/net-2").Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+		givenImage := config.NewImage(testImageRepository, testImageTag)
+		givenPod, err := prepareSharedAgentPod([]*networkv1.IPPool{givenIPPool1, givenIPPool2}, false, testPodNamespace, testClusterNetwork, testServiceAccountName, givenImage)
+		assert.Nil(t, err)
+		givenPod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		// net-2 has left the cluster network
+		clientset := fake.NewSimpleClientset(givenIPPool1)
+		err = clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		k8sclientset := k8sfake.NewSimpleClientset()
+		err = k8sclientset.Tracker().Add(givenPod)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		handler := Handler{
+			agentNamespace:          testPodNamespace,
+			agentImage:              givenImage,
+			agentServiceAccountName: testServiceAccountName,
+			agentPlacement:          config.AgentPlacementClusterNetwork,
+			ippoolCache:             fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:                fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			podClient:               fakeclient.PodClient(k8sclientset.CoreV1().Pods),
+			podCache:                fakeclient.PodCache(k8sclientset.CoreV1().Pods),
+		}
+
+		status, err := handler.DeployAgent(givenIPPool1, givenIPPool1.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, givenPod.Name, status.AgentPodRef.Name)
+
+		_, err = handler.podClient.Get(testPodNamespace, givenPod.Name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		pods, err := handler.podCache.List(testPodNamespace, labels.Everything())
+		assert.Nil(t, err)
+		assert.Len(t, pods, 1)
+	})
+
+	t.Run("shared agent pod replaced when ippools join", func(t *testing.T) {
+		givenIPPool1 := newTestIPPoolBuilder().
+			ServerIP(testServerIP1).
+			CIDR(testCIDR).
+			NetworkName(testNetworkName).Build()
+		givenIPPool2 := NewIPPoolBuilder(testIPPoolNamespace, "net-2").
+			ServerIP("10.0.0.2").
+			CIDR("10.0.0.0/24").
+			NetworkName(testNADNamespace + "/net-2").Build()
+		givenNAD1 := newTestNetworkAttachmentDefinitionBuilder().
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+		givenNAD2 := NewNetworkAttachmentDefinitionBuilder(testNADNamespace, "net-2").
+			Label(clusterNetworkLabelKey, testClusterNetwork).Build()
+		givenImage := config.NewImage(testImageRepository, testImageTag)
+		givenPod, err := prepareSharedAgentPod([]*networkv1.IPPool{givenIPPool1}, false, testPodNamespace, testClusterNetwork, testServiceAccountName, givenImage)
+		assert.Nil(t, err)
+		givenPod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset(givenIPPool1, givenIPPool2)
+		for _, nad := range []*cniv1.NetworkAttachmentDefinition{givenNAD1, givenNAD2} {
+			err := clientset.Tracker().Create(nadGVR, nad, nad.Namespace)
+			assert.Nil(t, err, "mock resource should add into fake controller tracker")
+		}
+
+		k8sclientset := k8sfake.NewSimpleClientset()
+		err = k8sclientset.Tracker().Add(givenPod)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		handler := Handler{
+			agentNamespace:          testPodNamespace,
+			agentImage:              givenImage,
+			agentServiceAccountName: testServiceAccountName,
+			agentPlacement:          config.AgentPlacementClusterNetwork,
+			ippoolCache:             fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:                fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			podClient:               fakeclient.PodClient(k8sclientset.CoreV1().Pods),
+			podCache:                fakeclient.PodCache(k8sclientset.CoreV1().Pods),
+		}
+
+		// The former agent pod keeps serving net-1 until the new one is ready
+		status, err := handler.DeployAgent(givenIPPool1, givenIPPool1.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, givenPod.Name, status.AgentPodRef.Name)
+
+		status, err = handler.DeployAgent(givenIPPool2, givenIPPool2.Status)
+		assert.Nil(t, err)
+		assert.NotEqual(t, givenPod.Name, status.AgentPodRef.Name)
+
+		newPod, err := handler.podClient.Get(testPodNamespace, status.AgentPodRef.Name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, testIPPoolNamespace+"/"+testIPPoolName+","+testIPPoolNamespace+"/net-2", newPod.Annotations[ipPoolsAnnotationKey])
+
+		_, err = handler.podClient.Get(testPodNamespace, givenPod.Name, metav1.GetOptions{})
+		assert.Nil(t, err)
+
+		// The former agent pod goes away once the new one is ready
+		newPod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		_, err = handler.podClient.UpdateStatus(newPod)
+		assert.Nil(t, err)
+
+		status, err = handler.DeployAgent(givenIPPool1, givenIPPool1.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, newPod.Name, status.AgentPodRef.Name)
+
+		_, err = handler.podClient.Get(testPodNamespace, givenPod.Name, metav1.GetOptions{})
+		assert.Equal(t, fmt.Sprintf("pods \"%s\" not found", givenPod.Name), err.Error())
+	})
 }
 
 func TestHandler_BuildCache(t *testing.T) {
@@ -837,6 +1153,133 @@ func TestHandler_BuildCache(t *testing.T) {
 	})
 }
 
+func TestHandler_SyncDynamicLeases(t *testing.T) {
+	t.Run("dynamic lease reported", func(t *testing.T) {
+		givenIPPool := newTestIPPoolBuilder().
+			Allocated(testAllocatedIP1, testMAC1).
+			DynamicLease(testAllocatedIP2, testMAC2, metav1.NewTime(time.Now().Add(time.Hour))).Build()
+
+		expectedAllocated := map[string]string{
+			testAllocatedIP1: testMAC1,
+			testAllocatedIP2: util.DynamicMark,
+		}
+
+		handler := Handler{}
+
+		handler.syncDynamicLeases(testNetworkName, givenIPPool.Status.IPv4)
+
+		assert.Equal(t, expectedAllocated, givenIPPool.Status.IPv4.Allocated)
+	})
+
+	t.Run("dynamic lease expired", func(t *testing.T) {
+		givenIPPool := newTestIPPoolBuilder().
+			Allocated(testAllocatedIP1, testMAC1).
+			Allocated(testAllocatedIP2, util.DynamicMark).
+			DynamicLease(testAllocatedIP2, testMAC2, metav1.NewTime(time.Now().Add(-time.Hour))).Build()
+
+		expectedAllocated := map[string]string{
+			testAllocatedIP1: testMAC1,
+		}
+
+		handler := Handler{}
+
+		handler.syncDynamicLeases(testNetworkName, givenIPPool.Status.IPv4)
+
+		assert.Equal(t, expectedAllocated, givenIPPool.Status.IPv4.Allocated)
+	})
+}
+
+func TestHandler_SyncQuarantinedAddresses(t *testing.T) {
+	t.Run("quarantine in effect", func(t *testing.T) {
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testAllocatedIP1).
+			Allocate(testNetworkName, testAllocatedIP2).
+			Build()
+		givenIPPool := newTestIPPoolBuilder().
+			Allocated(testAllocatedIP1, testMAC1).
+			Allocated(testAllocatedIP2, util.QuarantinedMark).
+			Quarantined(testAllocatedIP2, testMAC1, metav1.NewTime(time.Now().Add(time.Hour))).Build()
+
+		expectedIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testAllocatedIP1).
+			Allocate(testNetworkName, testAllocatedIP2).
+			Build()
+		expectedAllocated := map[string]string{
+			testAllocatedIP1: testMAC1,
+			testAllocatedIP2: util.QuarantinedMark,
+		}
+
+		handler := Handler{
+			ipAllocator: givenIPAllocator,
+		}
+
+		requeueAfter, err := handler.syncQuarantinedAddresses(testNetworkName, givenIPPool.Status.IPv4)
+		assert.Nil(t, err)
+
+		assert.True(t, requeueAfter > 0 && requeueAfter <= time.Hour)
+		assert.Equal(t, expectedAllocated, givenIPPool.Status.IPv4.Allocated)
+		assert.Len(t, givenIPPool.Status.IPv4.Quarantined, 1)
+		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
+	})
+
+	t.Run("quarantine expired", func(t *testing.T) {
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testAllocatedIP1).
+			Allocate(testNetworkName, testAllocatedIP2).
+			Build()
+		givenIPPool := newTestIPPoolBuilder().
+			Allocated(testAllocatedIP1, testMAC1).
+			Allocated(testAllocatedIP2, util.QuarantinedMark).
+			Quarantined(testAllocatedIP2, testMAC1, metav1.NewTime(time.Now().Add(-time.Hour))).Build()
+
+		expectedIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testAllocatedIP1).
+			Build()
+		expectedAllocated := map[string]string{
+			testAllocatedIP1: testMAC1,
+		}
+
+		handler := Handler{
+			ipAllocator: givenIPAllocator,
+		}
+
+		requeueAfter, err := handler.syncQuarantinedAddresses(testNetworkName, givenIPPool.Status.IPv4)
+		assert.Nil(t, err)
+
+		assert.Equal(t, time.Duration(0), requeueAfter)
+		assert.Equal(t, expectedAllocated, givenIPPool.Status.IPv4.Allocated)
+		assert.Empty(t, givenIPPool.Status.IPv4.Quarantined)
+		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
+	})
+
+	t.Run("quarantine lifted", func(t *testing.T) {
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testAllocatedIP2).
+			Build()
+		givenIPPool := newTestIPPoolBuilder().
+			Allocated(testAllocatedIP2, util.QuarantinedMark).Build()
+
+		expectedIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Build()
+
+		handler := Handler{
+			ipAllocator: givenIPAllocator,
+		}
+
+		_, err := handler.syncQuarantinedAddresses(testNetworkName, givenIPPool.Status.IPv4)
+		assert.Nil(t, err)
+
+		assert.Empty(t, givenIPPool.Status.IPv4.Allocated)
+		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
+	})
+}
+
 func TestHandler_MonitorAgent(t *testing.T) {
 	t.Run("agent pod not found", func(t *testing.T) {
 		givenIPPool := newTestIPPoolBuilder().AgentPodRef(testPodNamespace, testPodName, testImage, "").Build()
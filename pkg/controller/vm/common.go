@@ -2,9 +2,13 @@ package vm
 
 import (
 	"encoding/json"
+	"strings"
+
+	"github.com/sirupsen/logrus"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	"github.com/harvester/harvester/pkg/util"
@@ -37,11 +41,26 @@ func prepareVmNetCfg(vm *kubevirtv1.VirtualMachine, ncm map[string]networkv1.Net
 		},
 		Spec: networkv1.VirtualMachineNetworkConfigSpec{
 			VMName:         vm.Name,
+			Hostname:       hostnameOf(vm),
 			NetworkConfigs: ncs,
 		},
 	}
 }
 
+// hostnameOf returns the host name overridden by the annotation of the VM,
+// or an empty string if there is none, in which case the VM name is used.
+func hostnameOf(vm *kubevirtv1.VirtualMachine) string {
+	hostname, ok := vm.Annotations[hostnameAnnotationKey]
+	if !ok {
+		return ""
+	}
+	if errs := validation.IsDNS1123Label(hostname); len(errs) > 0 {
+		logrus.Warnf("(vm.hostnameOf) ignore annotation %s of vm %s/%s: %s", hostnameAnnotationKey, vm.Namespace, vm.Name, strings.Join(errs, ", "))
+		return ""
+	}
+	return hostname
+}
+
 type vmBuilder struct {
 	vm              *kubevirtv1.VirtualMachine
 	nicToMacAddress map[string]string
@@ -113,6 +132,15 @@ func (b *vmBuilder) WithNetwork(nicName, networkName string) *vmBuilder {
 	return b
 }
 
+// WithHostname overrides the host name of the VM by annotation.
+func (b *vmBuilder) WithHostname(hostname string) *vmBuilder {
+	if b.vm.Annotations == nil {
+		b.vm.Annotations = make(map[string]string)
+	}
+	b.vm.Annotations[hostnameAnnotationKey] = hostname
+	return b
+}
+
 func (b *vmBuilder) Build() *kubevirtv1.VirtualMachine {
 	return b.vm
 }
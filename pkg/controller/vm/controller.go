@@ -21,6 +21,8 @@ const (
 	controllerName = "vm-dhcp-vm-controller"
 
 	vmLabelKey = "harvesterhci.io/vmName"
+
+	hostnameAnnotationKey = "network.harvesterhci.io/hostname"
 )
 
 type Handler struct {
@@ -132,6 +134,7 @@ func (h *Handler) OnChange(key string, vm *kubevirtv1.VirtualMachine) (*kubevirt
 
 	vmNetCfgCpy := oldVmNetCfg.DeepCopy()
 	vmNetCfgCpy.Spec.NetworkConfigs = vmNetCfg.Spec.NetworkConfigs
+	vmNetCfgCpy.Spec.Hostname = vmNetCfg.Spec.Hostname
 
 	// The following block is a two-step process. Ideally,
 	// 1. if the network config of the VirtualMachine has been changed, update the status of the VirtualMachineNetworkConfig
@@ -160,6 +163,15 @@ func (h *Handler) OnChange(key string, vm *kubevirtv1.VirtualMachine) (*kubevirt
 
 		// Enqueue the VirtualMachine in order to update the network config of its corresponding VirtualMachineNetworkConfig
 		h.vmController.Enqueue(vm.Namespace, vm.Name)
+		return vm, nil
+	}
+
+	// Changing the hostname does not affect the IP allocation, so there is no need to go out-of-sync
+	if vmNetCfgCpy.Spec.Hostname != oldVmNetCfg.Spec.Hostname {
+		logrus.Infof("(vm.OnChange) update hostname of vmnetcfg %s/%s to %q", vmNetCfgCpy.Namespace, vmNetCfgCpy.Name, vmNetCfgCpy.Spec.Hostname)
+		if _, err := h.vmnetcfgClient.Update(vmNetCfgCpy); err != nil {
+			return vm, err
+		}
 	}
 
 	return vm, nil
@@ -215,6 +215,78 @@ func TestHandler_OnChange(t *testing.T) {
 		assert.Equal(t, givenVmNetCfg, vmNetCfg)
 	})
 
+	t.Run("vm hostname annotation changed", func(t *testing.T) {
+		givenVM := newTestVMBuilder().
+			WithInterfaceInAnnotation(testMACAddress1, testNICName).
+			WithNetwork(testNICName, testNetworkName).
+			WithHostname("frontend").Build()
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			Label(vmLabelKey, testVMName).
+			WithVMName(testVMName).
+			WithNetworkConfig("", testMACAddress1, testNetworkName).Build()
+
+		expectedVmNetCfg := newTestVmNetCfgBuilder().
+			Label(vmLabelKey, testVMName).
+			WithVMName(testVMName).
+			WithHostname("frontend").
+			WithNetworkConfig("", testMACAddress1, testNetworkName).Build()
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Add(givenVM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = clientset.Tracker().Add(givenVmNetCfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			vmnetcfgCache:  fakeclient.VirtualMachineNetworkConfigCache(clientset.NetworkV1alpha1().VirtualMachineNetworkConfigs),
+			vmnetcfgClient: fakeclient.VirtualMachineNetworkConfigClient(clientset.NetworkV1alpha1().VirtualMachineNetworkConfigs),
+		}
+
+		_, err = handler.OnChange(testKey, givenVM)
+		assert.Nil(t, err)
+
+		vmNetCfg, err := handler.vmnetcfgClient.Get(testVmNetCfgNamespace, testVmNetCfgName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, expectedVmNetCfg, vmNetCfg)
+	})
+
+	t.Run("invalid vm hostname annotation is ignored", func(t *testing.T) {
+		givenVM := newTestVMBuilder().
+			WithInterfaceInAnnotation(testMACAddress1, testNICName).
+			WithNetwork(testNICName, testNetworkName).
+			WithHostname("Front_End").Build()
+
+		expectedVmNetCfg := newTestVmNetCfgBuilder().
+			Label(vmLabelKey, testVMName).
+			OwnerRef(metav1.OwnerReference{
+				Name: testVMName,
+			}).
+			WithVMName(testVMName).
+			WithNetworkConfig("", testMACAddress1, testNetworkName).Build()
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Add(givenVM)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			vmnetcfgCache:  fakeclient.VirtualMachineNetworkConfigCache(clientset.NetworkV1alpha1().VirtualMachineNetworkConfigs),
+			vmnetcfgClient: fakeclient.VirtualMachineNetworkConfigClient(clientset.NetworkV1alpha1().VirtualMachineNetworkConfigs),
+		}
+
+		_, err = handler.OnChange(testKey, givenVM)
+		assert.Nil(t, err)
+
+		vmNetCfg, err := handler.vmnetcfgClient.Get(testVmNetCfgNamespace, testVmNetCfgName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, expectedVmNetCfg, vmNetCfg)
+	})
+
 	t.Run("vm and vmnetcfg found inconsistent in network configs should be flagged (first iteration)", func(t *testing.T) {
 		givenVM := newTestVMBuilder().
 			WithInterfaceInAnnotation(testMACAddress2, testNICName).
@@ -13,6 +13,15 @@ func updateAllNetworkConfigState(ncStatuses []networkv1.NetworkConfigStatus, sta
 	}
 }
 
+// hostnameOf returns the host name handed out to the VM, which defaults to
+// the VM name.
+func hostnameOf(vmNetCfg *networkv1.VirtualMachineNetworkConfig) string {
+	if vmNetCfg.Spec.Hostname != "" {
+		return vmNetCfg.Spec.Hostname
+	}
+	return vmNetCfg.Spec.VMName
+}
+
 func setAllocatedCondition(vmNetCfg *networkv1.VirtualMachineNetworkConfig, status corev1.ConditionStatus, reason, message string) {
 	networkv1.Allocated.SetStatus(vmNetCfg, string(status))
 	networkv1.Allocated.Reason(vmNetCfg, reason)
@@ -67,6 +76,11 @@ func (b *VmNetCfgBuilder) WithVMName(name string) *VmNetCfgBuilder {
 	return b
 }
 
+func (b *VmNetCfgBuilder) WithHostname(hostname string) *VmNetCfgBuilder {
+	b.vmNetCfg.Spec.Hostname = hostname
+	return b
+}
+
 func (b *VmNetCfgBuilder) Paused() *VmNetCfgBuilder {
 	b.vmNetCfg.Spec.Paused = func(b bool) *bool { return &b }(true)
 	return b
@@ -91,6 +105,40 @@ func (b *VmNetCfgBuilder) WithNetworkConfig(ipAddress, macAddress, networkName s
 	return b
 }
 
+func (b *VmNetCfgBuilder) WithIPv6Address(ipv6Address, duid string) *VmNetCfgBuilder {
+	i := len(b.vmNetCfg.Spec.NetworkConfigs) - 1
+	if i < 0 {
+		return b
+	}
+	b.vmNetCfg.Spec.NetworkConfigs[i].IPv6Address = &ipv6Address
+	if duid != "" {
+		b.vmNetCfg.Spec.NetworkConfigs[i].DUID = &duid
+	}
+	return b
+}
+
+func (b *VmNetCfgBuilder) WithClientID(clientID string) *VmNetCfgBuilder {
+	i := len(b.vmNetCfg.Spec.NetworkConfigs) - 1
+	if i < 0 {
+		return b
+	}
+	b.vmNetCfg.Spec.NetworkConfigs[i].ClientID = &clientID
+	return b
+}
+
+func (b *VmNetCfgBuilder) WithCustomOption(code int, optionType networkv1.CustomOptionType, value string) *VmNetCfgBuilder {
+	i := len(b.vmNetCfg.Spec.NetworkConfigs) - 1
+	if i < 0 {
+		return b
+	}
+	b.vmNetCfg.Spec.NetworkConfigs[i].CustomOptions = append(b.vmNetCfg.Spec.NetworkConfigs[i].CustomOptions, networkv1.CustomOption{
+		Code:  code,
+		Type:  optionType,
+		Value: value,
+	})
+	return b
+}
+
 func (b *VmNetCfgBuilder) WithNetworkConfigStatus(ipAddress, macAddress, networkName string, state networkv1.NetworkConfigState) *VmNetCfgBuilder {
 	ncStatus := networkv1.NetworkConfigStatus{
 		AllocatedIPAddress: ipAddress,
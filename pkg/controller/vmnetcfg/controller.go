@@ -5,23 +5,36 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/rancher/wrangler/v3/pkg/kv"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/util/retry"
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	"github.com/harvester/vm-dhcp-controller/pkg/cache"
 	"github.com/harvester/vm-dhcp-controller/pkg/config"
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns"
+	ctlcorev1 "github.com/harvester/vm-dhcp-controller/pkg/generated/controllers/core/v1"
 	ctlcniv1 "github.com/harvester/vm-dhcp-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlnetworkv1 "github.com/harvester/vm-dhcp-controller/pkg/generated/controllers/network.harvesterhci.io/v1alpha1"
+	"github.com/harvester/vm-dhcp-controller/pkg/indexer"
 	"github.com/harvester/vm-dhcp-controller/pkg/ipam"
 	"github.com/harvester/vm-dhcp-controller/pkg/metrics"
 	"github.com/harvester/vm-dhcp-controller/pkg/util"
 )
 
-const controllerName = "vm-dhcp-vmnetcfg-controller"
+const (
+	controllerName = "vm-dhcp-vmnetcfg-controller"
+
+	// tsigSecretKey is the key of the TSIG secret in the Secret referenced
+	// by the DDNS configuration of the IPPool
+	tsigSecretKey = "secret"
+)
 
 type Handler struct {
 	cacheAllocator   *cache.CacheAllocator
@@ -35,12 +48,14 @@ type Handler struct {
 	ippoolClient       ctlnetworkv1.IPPoolClient
 	ippoolCache        ctlnetworkv1.IPPoolCache
 	nadCache           ctlcniv1.NetworkAttachmentDefinitionCache
+	secretClient       ctlcorev1.SecretClient
 }
 
 func Register(ctx context.Context, management *config.Management) error {
 	vmnetcfgs := management.HarvesterNetworkFactory.Network().V1alpha1().VirtualMachineNetworkConfig()
 	ippools := management.HarvesterNetworkFactory.Network().V1alpha1().IPPool()
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	secrets := management.CoreFactory.Core().V1().Secret()
 
 	handler := &Handler{
 		cacheAllocator:   management.CacheAllocator,
@@ -54,6 +69,7 @@ func Register(ctx context.Context, management *config.Management) error {
 		ippoolClient:       ippools,
 		ippoolCache:        ippools.Cache(),
 		nadCache:           nads.Cache(),
+		secretClient:       secrets,
 	}
 
 	ctlnetworkv1.RegisterVirtualMachineNetworkConfigStatusHandler(
@@ -72,6 +88,18 @@ func Register(ctx context.Context, management *config.Management) error {
 		handler.Sync,
 	)
 
+	ctlnetworkv1.RegisterVirtualMachineNetworkConfigStatusHandler(
+		ctx,
+		vmnetcfgs,
+		networkv1.DNSRegistered,
+		"vmnetcfg-ddns",
+		handler.SyncDNS,
+	)
+
+	vmnetcfgs.Cache().AddIndexer(indexer.VmNetCfgByNetworkIndex, indexer.VmNetCfgByNetwork)
+
+	relatedresource.Watch(ctx, "vmnetcfg-lease-trigger", handler.leaseStateChanged, vmnetcfgs, ippools)
+
 	vmnetcfgs.OnChange(ctx, controllerName, handler.OnChange)
 	vmnetcfgs.OnRemove(ctx, controllerName, handler.OnRemove)
 
@@ -168,12 +196,37 @@ func (h *Handler) Allocate(vmNetCfg *networkv1.VirtualMachineNetworkConfig, stat
 			}
 		}
 
+		// Reallocate if the client or the agent found the IP address already
+		// in use
+		if lease, ok := getLeaseStatus(ipPool, nc.MACAddress, ip); ok && isAddressInUse(lease) {
+			ip, err = h.reallocate(nc, ip)
+			if err != nil {
+				return status, err
+			}
+		}
+
+		var ipv6 string
+		if nc.IPv6Address != nil {
+			if err := checkIPv6Address(ipPool, nc); err != nil {
+				return status, err
+			}
+			ipv6 = *nc.IPv6Address
+		}
+
 		// Prepare VirtualMachineNetworkConfig status
 		ncStatus := networkv1.NetworkConfigStatus{
-			AllocatedIPAddress: ip,
-			MACAddress:         nc.MACAddress,
-			NetworkName:        nc.NetworkName,
-			State:              networkv1.AllocatedState,
+			AllocatedIPAddress:   ip,
+			AllocatedIPv6Address: ipv6,
+			MACAddress:           nc.MACAddress,
+			NetworkName:          nc.NetworkName,
+			State:                networkv1.AllocatedState,
+		}
+		if lease, ok := getLeaseStatus(ipPool, nc.MACAddress, ip); ok {
+			setLeaseStatus(&ncStatus, lease)
+		}
+		// Keep track of the DNS record registered for the NIC, if any
+		if dnsRecord := findDNSRecordFromNetworkConfigStatusByMACAddress(vmNetCfg.Status.NetworkConfigs, nc.MACAddress); dnsRecord != nil {
+			ncStatus.DNSRecord = dnsRecord.DeepCopy()
 		}
 
 		ncStatuses = append(ncStatuses, ncStatus)
@@ -202,9 +255,71 @@ func (h *Handler) Allocate(vmNetCfg *networkv1.VirtualMachineNetworkConfig, stat
 
 		allocated[ip] = nc.MACAddress
 
+		// Keep the declined IP address away from being allocated again until
+		// the quarantine expires
+		if lease, ok := ipv4Status.Leases[nc.MACAddress]; ok && isAddressInUse(lease) &&
+			lease.IPAddress != ip && allocated[lease.IPAddress] == nc.MACAddress {
+			allocated[lease.IPAddress] = util.QuarantinedMark
+			if ipv4Status.Quarantined == nil {
+				ipv4Status.Quarantined = make(map[string]networkv1.QuarantinedAddress)
+			}
+			ipv4Status.Quarantined[lease.IPAddress] = networkv1.QuarantinedAddress{
+				MACAddress: nc.MACAddress,
+				ExpiryTime: metav1.NewTime(time.Now().Add(util.QuarantineDuration)),
+			}
+		}
+
 		ipv4Status.Allocated = allocated
+
+		// Pass the host name, the client identifier and the per-NIC custom
+		// options down to the agent
+		override := networkv1.LeaseOverride{
+			Hostname:      hostnameOf(vmNetCfg),
+			CustomOptions: nc.CustomOptions,
+		}
+		if nc.ClientID != nil {
+			override.ClientID = *nc.ClientID
+		}
+		if override.Hostname != "" || override.ClientID != "" || len(override.CustomOptions) > 0 {
+			if ipv4Status.Overrides == nil {
+				ipv4Status.Overrides = make(map[string]networkv1.LeaseOverride)
+			}
+			ipv4Status.Overrides[nc.MACAddress] = override
+		} else {
+			delete(ipv4Status.Overrides, nc.MACAddress)
+		}
+
 		ipPoolCpy.Status.IPv4 = ipv4Status
 
+		// Drop the binding of the IPv6 address the NIC had before, if it
+		// changed or was removed
+		if ipv6Status := ipPoolCpy.Status.IPv6; ipv6Status != nil {
+			for allocatedIPv6, binding := range ipv6Status.Allocated {
+				if allocatedIPv6 != ipv6 && binding.MACAddress == nc.MACAddress && reflect.DeepEqual(binding.IAID, nc.IAID) {
+					delete(ipv6Status.Allocated, allocatedIPv6)
+				}
+			}
+		}
+
+		if ipv6 != "" {
+			ipv6Status := ipPoolCpy.Status.IPv6
+			if ipv6Status == nil {
+				ipv6Status = new(networkv1.IPv6Status)
+			}
+			if ipv6Status.Allocated == nil {
+				ipv6Status.Allocated = make(map[string]networkv1.IPv6Binding)
+			}
+			binding := networkv1.IPv6Binding{
+				IAID:       nc.IAID,
+				MACAddress: nc.MACAddress,
+			}
+			if nc.DUID != nil {
+				binding.DUID = *nc.DUID
+			}
+			ipv6Status.Allocated[ipv6] = binding
+			ipPoolCpy.Status.IPv6 = ipv6Status
+		}
+
 		if !reflect.DeepEqual(ipPoolCpy, ipPool) {
 			logrus.Infof("(vmnetcfg.Allocate) update ippool %s/%s", ipPool.Namespace, ipPool.Name)
 			ipPoolCpy.Status.LastUpdate = metav1.Now()
@@ -259,6 +374,7 @@ func (h *Handler) Sync(vmNetCfg *networkv1.VirtualMachineNetworkConfig, status n
 	}
 
 	// Cleanup the stale records
+	h.unregisterDNS(vmNetCfg, true)
 	if err := h.cleanup(vmNetCfg, true); err != nil {
 		return status, err
 	}
@@ -276,6 +392,7 @@ func (h *Handler) OnRemove(key string, vmNetCfg *networkv1.VirtualMachineNetwork
 
 	logrus.Debugf("(vmnetcfg.OnRemove) vmnetcfg configuration %s/%s has been removed", vmNetCfg.Namespace, vmNetCfg.Name)
 
+	h.unregisterDNS(vmNetCfg, false)
 	if err := h.cleanup(vmNetCfg, false); err != nil {
 		return vmNetCfg, err
 	}
@@ -283,6 +400,158 @@ func (h *Handler) OnRemove(key string, vmNetCfg *networkv1.VirtualMachineNetwork
 	return vmNetCfg, nil
 }
 
+// SyncDNS registers the name of the VM in the DNS server configured in the
+// IPPool once the guest has bound its IP address, and registers it again
+// whenever the address changes.
+func (h *Handler) SyncDNS(vmNetCfg *networkv1.VirtualMachineNetworkConfig, status networkv1.VirtualMachineNetworkConfigStatus) (networkv1.VirtualMachineNetworkConfigStatus, error) {
+	for i := range status.NetworkConfigs {
+		ncStatus := &status.NetworkConfigs[i]
+		if ncStatus.State != networkv1.AllocatedState {
+			continue
+		}
+
+		ipPool, err := h.getIPPoolFromNetworkConfigStatus(*ncStatus)
+		if err != nil {
+			return status, err
+		}
+
+		// Forget about the record once DDNS is turned off for the IPPool. It
+		// cannot be unregistered anymore as the DNS server is not known, so
+		// it is left to the operator to remove it.
+		if ipPool.Spec.IPv4Config.DDNS == nil {
+			if ncStatus.DNSRecord != nil {
+				logrus.Warnf("(vmnetcfg.SyncDNS) ddns turned off for ippool %s/%s, record %s with ip %s of vmnetcfg %s/%s has to be removed manually",
+					ipPool.Namespace, ipPool.Name, ncStatus.DNSRecord.Name, ncStatus.DNSRecord.IPAddress, vmNetCfg.Namespace, vmNetCfg.Name)
+			}
+			ncStatus.DNSRecord = nil
+			continue
+		}
+
+		if ncStatus.LeaseState != networkv1.LeaseBound {
+			continue
+		}
+
+		dnsRecord := networkv1.DNSRecord{
+			Name:      dnsNameOf(vmNetCfg, ipPool.Spec.IPv4Config),
+			IPAddress: ncStatus.AllocatedIPAddress,
+		}
+		if ncStatus.DNSRecord != nil && *ncStatus.DNSRecord == dnsRecord {
+			continue
+		}
+
+		client, err := h.newDDNSClient(ipPool)
+		if err != nil {
+			return status, err
+		}
+
+		if ncStatus.DNSRecord != nil {
+			logrus.Infof("(vmnetcfg.SyncDNS) unregister %s with ip %s of vmnetcfg %s/%s",
+				ncStatus.DNSRecord.Name, ncStatus.DNSRecord.IPAddress, vmNetCfg.Namespace, vmNetCfg.Name)
+			if err := client.Unregister(context.TODO(), ddnsRecordOf(ipPool, *ncStatus.DNSRecord)); err != nil {
+				logrus.Warnf("(vmnetcfg.SyncDNS) failed to unregister %s: %s", ncStatus.DNSRecord.Name, err.Error())
+			}
+		}
+
+		logrus.Infof("(vmnetcfg.SyncDNS) register %s with ip %s of vmnetcfg %s/%s",
+			dnsRecord.Name, dnsRecord.IPAddress, vmNetCfg.Namespace, vmNetCfg.Name)
+		if err := client.Register(context.TODO(), ddnsRecordOf(ipPool, dnsRecord)); err != nil {
+			return status, err
+		}
+		ncStatus.DNSRecord = &dnsRecord
+	}
+
+	return status, nil
+}
+
+// unregisterDNS removes the DNS records of the VirtualMachineNetworkConfig,
+// or only those of the stale network configs. Failures are only logged, as
+// they must not hold up the cleanup.
+func (h *Handler) unregisterDNS(vmNetCfg *networkv1.VirtualMachineNetworkConfig, staleOnly bool) {
+	for _, ncStatus := range vmNetCfg.Status.NetworkConfigs {
+		if ncStatus.DNSRecord == nil || (staleOnly && ncStatus.State != networkv1.StaleState) {
+			continue
+		}
+
+		ipPool, err := h.getIPPoolFromNetworkConfigStatus(ncStatus)
+		if err != nil {
+			logrus.Warnf("(vmnetcfg.unregisterDNS) failed to unregister %s: %s", ncStatus.DNSRecord.Name, err.Error())
+			continue
+		}
+		if ipPool.Spec.IPv4Config.DDNS == nil {
+			continue
+		}
+
+		client, err := h.newDDNSClient(ipPool)
+		if err != nil {
+			logrus.Warnf("(vmnetcfg.unregisterDNS) failed to unregister %s: %s", ncStatus.DNSRecord.Name, err.Error())
+			continue
+		}
+
+		logrus.Infof("(vmnetcfg.unregisterDNS) unregister %s with ip %s of vmnetcfg %s/%s",
+			ncStatus.DNSRecord.Name, ncStatus.DNSRecord.IPAddress, vmNetCfg.Namespace, vmNetCfg.Name)
+		if err := client.Unregister(context.TODO(), ddnsRecordOf(ipPool, *ncStatus.DNSRecord)); err != nil {
+			logrus.Warnf("(vmnetcfg.unregisterDNS) failed to unregister %s: %s", ncStatus.DNSRecord.Name, err.Error())
+		}
+	}
+}
+
+// newDDNSClient returns a client of the DNS server configured in the IPPool,
+// with the TSIG key read from the referenced Secret.
+func (h *Handler) newDDNSClient(ipPool *networkv1.IPPool) (*ddns.Client, error) {
+	ddnsConfig := ipPool.Spec.IPv4Config.DDNS
+
+	var key *ddns.Key
+	if ddnsConfig.TSIG != nil {
+		secret, err := h.secretClient.Get(ipPool.Namespace, ddnsConfig.TSIG.SecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		data, ok := secret.Data[tsigSecretKey]
+		if !ok {
+			return nil, fmt.Errorf("secret %s/%s has no key %s", secret.Namespace, secret.Name, tsigSecretKey)
+		}
+		key, err = ddns.NewKey(ddnsConfig.TSIG.KeyName, ddnsConfig.TSIG.Algorithm, string(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ddns.NewClient(ddnsConfig.Server, key), nil
+}
+
+// ddnsRecordOf returns the A and PTR records to be updated for the DNS record
+// of a NIC. The reverse zone defaults to the one of the subnet, if any.
+func ddnsRecordOf(ipPool *networkv1.IPPool, dnsRecord networkv1.DNSRecord) ddns.Record {
+	ddnsConfig := ipPool.Spec.IPv4Config.DDNS
+
+	record := ddns.Record{
+		Zone:        ddnsConfig.Zone,
+		ReverseZone: ddnsConfig.ReverseZone,
+		Name:        dnsRecord.Name,
+		IP:          net.ParseIP(dnsRecord.IPAddress),
+	}
+	if record.ReverseZone == "" {
+		if reverseZone, err := ddns.ReverseZone(ipPool.Spec.IPv4Config.CIDR); err == nil {
+			record.ReverseZone = reverseZone
+		}
+	}
+	if ddnsConfig.TTL != nil {
+		record.TTL = uint32(*ddnsConfig.TTL)
+	}
+	return record
+}
+
+// dnsNameOf returns the fully qualified name of the VM, the same as the one
+// handed out in option 81. The zone stands in for the domain name of IPPools
+// which have none, as the name must be within the zone.
+func dnsNameOf(vmNetCfg *networkv1.VirtualMachineNetworkConfig, ipv4Config networkv1.IPv4Config) string {
+	domainName := ipv4Config.DDNS.Zone
+	if ipv4Config.DomainName != nil && *ipv4Config.DomainName != "" {
+		domainName = *ipv4Config.DomainName
+	}
+	return strings.ToLower(util.FQDN(hostnameOf(vmNetCfg), domainName) + ".")
+}
+
 func (h *Handler) cleanup(vmNetCfg *networkv1.VirtualMachineNetworkConfig, cleanupStaleOnly bool) error {
 	if !cleanupStaleOnly {
 		h.metricsAllocator.DeleteVmNetCfgStatus(vmNetCfg.Namespace + "/" + vmNetCfg.Name)
@@ -322,6 +591,17 @@ func (h *Handler) cleanup(vmNetCfg *networkv1.VirtualMachineNetworkConfig, clean
 
 				// Remove record in IPPool status
 				delete(ipPoolCpy.Status.IPv4.Allocated, ncStatus.AllocatedIPAddress)
+				delete(ipPoolCpy.Status.IPv4.Overrides, ncStatus.MACAddress)
+				// Lift the quarantines of the IP addresses the NIC declined,
+				// the IPPool controller returns them to the IPAM
+				for ip, quarantined := range ipPoolCpy.Status.IPv4.Quarantined {
+					if quarantined.MACAddress == ncStatus.MACAddress {
+						delete(ipPoolCpy.Status.IPv4.Quarantined, ip)
+					}
+				}
+				if ncStatus.AllocatedIPv6Address != "" && ipPoolCpy.Status.IPv6 != nil {
+					delete(ipPoolCpy.Status.IPv6.Allocated, ncStatus.AllocatedIPv6Address)
+				}
 
 				if !reflect.DeepEqual(ipPoolCpy, ipPool) {
 					logrus.Infof("(vmnetcfg.cleanup) update ippool %s/%s", ipPool.Namespace, ipPool.Name)
@@ -339,6 +619,130 @@ func (h *Handler) cleanup(vmNetCfg *networkv1.VirtualMachineNetworkConfig, clean
 	return nil
 }
 
+// leaseStateChanged enqueues the VirtualMachineNetworkConfigs whose lease
+// recorded in the status differs from the one reported by the agent.
+func (h *Handler) leaseStateChanged(_, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	ipPool, ok := obj.(*networkv1.IPPool)
+	if !ok || ipPool.Status.IPv4 == nil || len(ipPool.Status.IPv4.Leases) == 0 {
+		return nil, nil
+	}
+
+	vmNetCfgs, err := h.vmnetcfgCache.GetByIndex(indexer.VmNetCfgByNetworkIndex, ipPool.Spec.NetworkName)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []relatedresource.Key
+	for _, vmNetCfg := range vmNetCfgs {
+		for _, ncStatus := range vmNetCfg.Status.NetworkConfigs {
+			lease, ok := ipPool.Status.IPv4.Leases[ncStatus.MACAddress]
+			if !ok || lease.IPAddress != ncStatus.AllocatedIPAddress {
+				continue
+			}
+			ncStatusCpy := ncStatus.DeepCopy()
+			setLeaseStatus(ncStatusCpy, lease)
+			if reflect.DeepEqual(ncStatusCpy, &ncStatus) {
+				continue
+			}
+			keys = append(keys, relatedresource.Key{
+				Namespace: vmNetCfg.Namespace,
+				Name:      vmNetCfg.Name,
+			})
+			break
+		}
+	}
+
+	return keys, nil
+}
+
+// reallocate quarantines the declined or conflicting IP address of the
+// NetworkConfig and allocates a new one in place of it. The quarantined IP
+// address stays allocated in the IPAM until the IPPool controller releases
+// it.
+func (h *Handler) reallocate(nc networkv1.NetworkConfig, declinedIP string) (string, error) {
+	if nc.IPAddress != nil && *nc.IPAddress == declinedIP {
+		return declinedIP, fmt.Errorf("designated ip %s was declined by %s", declinedIP, nc.MACAddress)
+	}
+
+	ip, err := h.ipAllocator.AllocateIP(nc.NetworkName, net.IPv4zero.String())
+	if err != nil {
+		return declinedIP, err
+	}
+
+	logrus.Warnf("(vmnetcfg.reallocate) ip %s of %s is in use by another host; quarantine it and reallocate ip %s", declinedIP, nc.MACAddress, ip)
+
+	if err := h.cacheAllocator.DeleteMAC(nc.NetworkName, nc.MACAddress); err != nil {
+		return declinedIP, err
+	}
+
+	if err := h.cacheAllocator.AddMAC(nc.NetworkName, nc.MACAddress, ip); err != nil {
+		return declinedIP, err
+	}
+
+	return ip, nil
+}
+
+// isAddressInUse reports whether the IP address of the lease was found in use
+// by another host, either declined by the client or probed by the agent.
+func isAddressInUse(lease networkv1.LeaseStatus) bool {
+	return lease.State == networkv1.LeaseDeclined || lease.State == networkv1.LeaseConflict
+}
+
+// getLeaseStatus returns the lease state reported by the agent for the given
+// MAC address, as long as it still refers to the given IP address.
+func getLeaseStatus(ipPool *networkv1.IPPool, macAddress, ipAddress string) (networkv1.LeaseStatus, bool) {
+	if ipPool.Status.IPv4 == nil {
+		return networkv1.LeaseStatus{}, false
+	}
+	lease, ok := ipPool.Status.IPv4.Leases[macAddress]
+	if !ok || lease.IPAddress != ipAddress {
+		return networkv1.LeaseStatus{}, false
+	}
+	return lease, true
+}
+
+// setLeaseStatus copies what the agent observed of the lease, i.e. whether
+// the guest actually bound the allocated IP address, to the status.
+func setLeaseStatus(ncStatus *networkv1.NetworkConfigStatus, lease networkv1.LeaseStatus) {
+	ncStatus.LeaseState = lease.State
+	ncStatus.ClientHostname = lease.ClientHostname
+	ncStatus.BoundTime = lease.BoundTime.DeepCopy()
+	ncStatus.RenewTime = lease.RenewTime.DeepCopy()
+	ncStatus.ExpiryTime = lease.ExpiryTime.DeepCopy()
+}
+
+// checkIPv6Address makes sure the static IPv6 address requested by the
+// NetworkConfig is usable in the IPPool and not yet bound to another NIC.
+func checkIPv6Address(ipPool *networkv1.IPPool, nc networkv1.NetworkConfig) error {
+	if ipPool.Spec.IPv6Config == nil {
+		return fmt.Errorf("ippool %s/%s has no ipv6 config", ipPool.Namespace, ipPool.Name)
+	}
+
+	ip := net.ParseIP(*nc.IPv6Address)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Errorf("%s is not a valid ipv6 address", *nc.IPv6Address)
+	}
+
+	_, ipNet, err := net.ParseCIDR(ipPool.Spec.IPv6Config.CIDR)
+	if err != nil {
+		return err
+	}
+	if !ipNet.Contains(ip) {
+		return fmt.Errorf("ipv6 address %s is not within subnet %s", *nc.IPv6Address, ipPool.Spec.IPv6Config.CIDR)
+	}
+	if ip.Equal(net.ParseIP(ipPool.Spec.IPv6Config.ServerIP)) {
+		return fmt.Errorf("ipv6 address %s is the server ip of ippool %s/%s", *nc.IPv6Address, ipPool.Namespace, ipPool.Name)
+	}
+
+	if ipPool.Status.IPv6 != nil {
+		if binding, ok := ipPool.Status.IPv6.Allocated[*nc.IPv6Address]; ok && binding.MACAddress != nc.MACAddress {
+			return fmt.Errorf("ipv6 address %s is already allocated to %s", *nc.IPv6Address, binding.MACAddress)
+		}
+	}
+
+	return nil
+}
+
 func findIPAddressFromNetworkConfigStatusByMACAddress(ncStatuses []networkv1.NetworkConfigStatus, macAddress string) (ipAddress string, err error) {
 	for _, ncStatus := range ncStatuses {
 		if ncStatus.MACAddress == macAddress && ncStatus.AllocatedIPAddress != "" {
@@ -348,6 +752,15 @@ func findIPAddressFromNetworkConfigStatusByMACAddress(ncStatuses []networkv1.Net
 	return net.IPv4zero.String(), fmt.Errorf("could not find allocated ip for mac %s", macAddress)
 }
 
+func findDNSRecordFromNetworkConfigStatusByMACAddress(ncStatuses []networkv1.NetworkConfigStatus, macAddress string) *networkv1.DNSRecord {
+	for _, ncStatus := range ncStatuses {
+		if ncStatus.MACAddress == macAddress {
+			return ncStatus.DNSRecord
+		}
+	}
+	return nil
+}
+
 func (h *Handler) getIPPoolFromNetworkName(networkName string) (*networkv1.IPPool, error) {
 	nadNamespace, nadName := kv.RSplit(networkName, "/")
 	nad, err := h.nadCache.Get(nadNamespace, nadName)
@@ -3,15 +3,19 @@ package vmnetcfg
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	"github.com/harvester/vm-dhcp-controller/pkg/cache"
 	"github.com/harvester/vm-dhcp-controller/pkg/controller/ippool"
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns"
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns/ddnstest"
 	"github.com/harvester/vm-dhcp-controller/pkg/generated/clientset/versioned/fake"
 	"github.com/harvester/vm-dhcp-controller/pkg/ipam"
 	"github.com/harvester/vm-dhcp-controller/pkg/metrics"
@@ -38,10 +42,18 @@ const (
 	testIPAddress2  = "192.168.0.177"
 	testIPAddress3  = "192.168.0.189"
 	testIPAddress4  = "192.168.0.199"
+	testIPAddress5  = "192.168.0.112"
 	testMACAddress1 = "11:22:33:44:55:66"
 	testMACAddress2 = "22:33:44:55:66:77"
 	testMACAddress3 = "33:44:55:66:77:88"
 	testMACAddress4 = "44:55:66:77:88:99"
+
+	testIPv6ServerIP = "fd00::2"
+	testIPv6CIDR     = "fd00::/64"
+	testIPv6Address1 = "fd00::111"
+	testIPv6Address2 = "fd00::177"
+	testDUID1        = "00:03:00:01:11:22:33:44:55:66"
+	testDUID2        = "00:03:00:01:22:33:44:55:66:77"
 )
 
 func newTestVmNetCfgBuilder() *VmNetCfgBuilder {
@@ -225,15 +237,779 @@ func TestHandler_Allocate(t *testing.T) {
 			PoolRange(testStartIP, testEndIP).
 			NetworkName(testNetworkName).
 			Allocated(testIPAddress1, testMACAddress1).
-			Allocated(testIPAddress2, testMACAddress2).
+			Allocated(testIPAddress2, testMACAddress2).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		expectedCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).
+			Add(testNetworkName, testMACAddress2, testIPAddress2).Build()
+		expectedIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testIPAddress1, testIPAddress2).Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenVmNetCfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
+
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+		assert.Equal(t, expectedIPPool, ipPool)
+
+		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
+		assert.Equal(t, expectedCacheAllocator, handler.cacheAllocator)
+	})
+
+	t.Run("rebuild caches", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			Allocated(testIPAddress2, testMACAddress2).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).
+			Add(testNetworkName, testMACAddress2, testIPAddress2).Build()
+		expectedIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testIPAddress1, testIPAddress2).Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
+		assert.Equal(t, expectedCacheAllocator, handler.cacheAllocator)
+	})
+
+	t.Run("pause vmnetcfg", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			Paused().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+
+		handler := Handler{}
+
+		_, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Equal(t, fmt.Sprintf("vmnetcfg %s/%s was administratively disabled", testVmNetCfgNamespace, testVmNetCfgName), err.Error())
+	})
+
+	t.Run("recover ips from cache", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).
+			Add(testNetworkName, testMACAddress2, testIPAddress2).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).
+			WithNetworkConfigStatus(testIPAddress2, testMACAddress2, testNetworkName, networkv1.AllocatedState).Build()
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			Allocated(testIPAddress2, testMACAddress2).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
+
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+
+		assert.Equal(t, expectedIPPool, ipPool)
+	})
+
+	t.Run("ippool cache not ready", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			CacheReadyCondition(corev1.ConditionFalse, "", "").Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			ippoolClient: fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:  fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:     fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.NotNil(t, fmt.Sprintf("ippool %s/%s is not ready", testIPPoolNamespace, testIPPoolName), err)
+	})
+
+	t.Run("ippool not found", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		handler := Handler{
+			ippoolClient: fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:  fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:     fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.NotNil(t, fmt.Sprintf("ippool %s/%s not found", testIPPoolNamespace, testIPPoolName), err)
+	})
+
+	t.Run("nad not labeled with ippool info", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			ippoolClient: fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:  fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:     fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.NotNil(t, fmt.Errorf("network attachment definition %s/%s has no labels", testNADNamespace, testNADName), err)
+	})
+
+	t.Run("declined ip", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig("", testMACAddress1, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testIPAddress1, testIPAddress5).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			Lease(testMACAddress1, testIPAddress1, networkv1.LeaseDeclined).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testIPAddress1, testIPAddress5).
+			Allocate(testNetworkName, testIPAddress1).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress5, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testIPAddress1, testIPAddress5).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, util.QuarantinedMark).
+			Allocated(testIPAddress5, testMACAddress1).
+			Quarantined(testIPAddress1, testMACAddress1, metav1.Now()).
+			Lease(testMACAddress1, testIPAddress1, networkv1.LeaseDeclined).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		expectedCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress5).Build()
+		expectedIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testIPAddress1, testIPAddress5).
+			Allocate(testNetworkName, testIPAddress1).
+			Allocate(testNetworkName, testIPAddress5).Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
+
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+		assert.Equal(t, expectedIPPool, ipPool)
+
+		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
+		assert.Equal(t, expectedCacheAllocator, handler.cacheAllocator)
+	})
+
+	t.Run("conflicting ip", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig("", testMACAddress1, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testIPAddress1, testIPAddress5).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			Lease(testMACAddress1, testIPAddress1, networkv1.LeaseConflict).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testIPAddress1, testIPAddress5).
+			Allocate(testNetworkName, testIPAddress1).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress5, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testIPAddress1, testIPAddress5).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, util.QuarantinedMark).
+			Allocated(testIPAddress5, testMACAddress1).
+			Quarantined(testIPAddress1, testMACAddress1, metav1.Now()).
+			Lease(testMACAddress1, testIPAddress1, networkv1.LeaseConflict).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		expectedCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress5).Build()
+		expectedIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testIPAddress1, testIPAddress5).
+			Allocate(testNetworkName, testIPAddress1).
+			Allocate(testNetworkName, testIPAddress5).Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
+
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+		assert.Equal(t, expectedIPPool, ipPool)
+
+		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
+		assert.Equal(t, expectedCacheAllocator, handler.cacheAllocator)
+	})
+
+	t.Run("released ip", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			Lease(testMACAddress1, testIPAddress1, networkv1.LeaseReleased).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testIPAddress1).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedStatus.NetworkConfigs[0].LeaseState = networkv1.LeaseReleased
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+	})
+
+	t.Run("bound ip", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			Lease(testMACAddress1, testIPAddress1, networkv1.LeaseBound).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		boundTime := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		renewTime := metav1.NewTime(boundTime.Add(time.Hour))
+		expiryTime := metav1.NewTime(renewTime.Add(24 * time.Hour))
+		lease := givenIPPool.Status.IPv4.Leases[testMACAddress1]
+		lease.ClientHostname = "vm-1"
+		lease.BoundTime = &boundTime
+		lease.RenewTime = &renewTime
+		lease.ExpiryTime = &expiryTime
+		givenIPPool.Status.IPv4.Leases[testMACAddress1] = lease
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testIPAddress1).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedStatus.NetworkConfigs[0].LeaseState = networkv1.LeaseBound
+		expectedStatus.NetworkConfigs[0].ClientHostname = "vm-1"
+		expectedStatus.NetworkConfigs[0].BoundTime = &boundTime
+		expectedStatus.NetworkConfigs[0].RenewTime = &renewTime
+		expectedStatus.NetworkConfigs[0].ExpiryTime = &expiryTime
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+	})
+
+	t.Run("static ipv6 address", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithIPv6Address(testIPv6Address1, testDUID1).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			IPv6Config(testIPv6CIDR, testIPv6ServerIP).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedStatus.NetworkConfigs[0].AllocatedIPv6Address = testIPv6Address1
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			IPv6Config(testIPv6CIDR, testIPv6ServerIP).
+			Allocated(testIPAddress1, testMACAddress1).
+			AllocatedIPv6(testIPv6Address1, testDUID1, testMACAddress1).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
+
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+		assert.Equal(t, expectedIPPool, ipPool)
+	})
+
+	t.Run("static ipv6 address changed", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithIPv6Address(testIPv6Address2, testDUID1).Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			IPv6Config(testIPv6CIDR, testIPv6ServerIP).
+			Allocated(testIPAddress1, testMACAddress1).
+			AllocatedIPv6(testIPv6Address1, testDUID1, testMACAddress1).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).
+			Add(testNetworkName, testMACAddress1, testIPAddress1).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+			Allocate(testNetworkName, testIPAddress1).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedStatus.NetworkConfigs[0].AllocatedIPv6Address = testIPv6Address2
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			IPv6Config(testIPv6CIDR, testIPv6ServerIP).
+			Allocated(testIPAddress1, testMACAddress1).
+			AllocatedIPv6(testIPv6Address2, testDUID1, testMACAddress1).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := Handler{
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+		}
+
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
+
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
+
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+		assert.Equal(t, expectedIPPool, ipPool)
+	})
+
+	t.Run("custom options", func(t *testing.T) {
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithCustomOption(26, networkv1.CustomOptionTypeUint16, "9000").Build()
+		givenIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			OverrideCustomOption(testMACAddress1, 26, networkv1.CustomOptionTypeUint16, "9000").
 			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
-		expectedCacheAllocator := newTestCacheAllocatorBuilder().
-			MACSet(testNetworkName).
-			Add(testNetworkName, testMACAddress1, testIPAddress1).
-			Add(testNetworkName, testMACAddress2, testIPAddress2).Build()
-		expectedIPAllocator := newTestIPAllocatorBuilder().
-			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
-			Allocate(testNetworkName, testIPAddress1, testIPAddress2).Build()
 
 		nadGVR := schema.GroupVersionResource{
 			Group:    "k8s.cni.cncf.io",
@@ -245,10 +1021,6 @@ func TestHandler_Allocate(t *testing.T) {
 		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
 		assert.Nil(t, err, "mock resource should add into fake controller tracker")
 
-		err = clientset.Tracker().Add(givenVmNetCfg)
-		if err != nil {
-			t.Fatal(err)
-		}
 		err = clientset.Tracker().Add(givenIPPool)
 		if err != nil {
 			t.Fatal(err)
@@ -276,24 +1048,17 @@ func TestHandler_Allocate(t *testing.T) {
 		ippool.SanitizeStatus(&expectedIPPool.Status)
 		ippool.SanitizeStatus(&ipPool.Status)
 		assert.Equal(t, expectedIPPool, ipPool)
-
-		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
-		assert.Equal(t, expectedCacheAllocator, handler.cacheAllocator)
 	})
 
-	t.Run("rebuild caches", func(t *testing.T) {
+	t.Run("client id", func(t *testing.T) {
 		givenVmNetCfg := newTestVmNetCfgBuilder().
 			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
-			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).
-			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).
-			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+			WithClientID("ff:00:00:00:01:00:01").Build()
 		givenIPPool := newTestIPPoolBuilder().
 			ServerIP(testServerIP).
 			CIDR(testCIDR).
 			PoolRange(testStartIP, testEndIP).
 			NetworkName(testNetworkName).
-			Allocated(testIPAddress1, testMACAddress1).
-			Allocated(testIPAddress2, testMACAddress2).
 			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
 		givenCacheAllocator := newTestCacheAllocatorBuilder().
 			MACSet(testNetworkName).Build()
@@ -303,13 +1068,16 @@ func TestHandler_Allocate(t *testing.T) {
 			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
 			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
 
-		expectedCacheAllocator := newTestCacheAllocatorBuilder().
-			MACSet(testNetworkName).
-			Add(testNetworkName, testMACAddress1, testIPAddress1).
-			Add(testNetworkName, testMACAddress2, testIPAddress2).Build()
-		expectedIPAllocator := newTestIPAllocatorBuilder().
-			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
-			Allocate(testNetworkName, testIPAddress1, testIPAddress2).Build()
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			OverrideClientID(testMACAddress1, "ff:00:00:00:01:00:01").
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
 
 		nadGVR := schema.GroupVersionResource{
 			Group:    "k8s.cni.cncf.io",
@@ -335,31 +1103,25 @@ func TestHandler_Allocate(t *testing.T) {
 			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
 		}
 
-		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
 		assert.Nil(t, err)
 
-		assert.Equal(t, expectedIPAllocator, handler.ipAllocator)
-		assert.Equal(t, expectedCacheAllocator, handler.cacheAllocator)
-	})
-
-	t.Run("pause vmnetcfg", func(t *testing.T) {
-		givenVmNetCfg := newTestVmNetCfgBuilder().
-			Paused().
-			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
-			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).
-			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).
-			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
 
-		handler := Handler{}
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
 
-		_, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
-		assert.Equal(t, fmt.Sprintf("vmnetcfg %s/%s was administratively disabled", testVmNetCfgNamespace, testVmNetCfgName), err.Error())
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+		assert.Equal(t, expectedIPPool, ipPool)
 	})
 
-	t.Run("recover ips from cache", func(t *testing.T) {
+	t.Run("hostname from vm name", func(t *testing.T) {
 		givenVmNetCfg := newTestVmNetCfgBuilder().
-			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
-			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
+			WithVMName("web-01").
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).Build()
 		givenIPPool := newTestIPPoolBuilder().
 			ServerIP(testServerIP).
 			CIDR(testCIDR).
@@ -367,23 +1129,22 @@ func TestHandler_Allocate(t *testing.T) {
 			NetworkName(testNetworkName).
 			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
 		givenCacheAllocator := newTestCacheAllocatorBuilder().
-			MACSet(testNetworkName).
-			Add(testNetworkName, testMACAddress1, testIPAddress1).
-			Add(testNetworkName, testMACAddress2, testIPAddress2).Build()
+			MACSet(testNetworkName).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).Build()
 		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
 			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
 			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
 
 		expectedStatus := newTestVmNetCfgStatusBuilder().
-			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).
-			WithNetworkConfigStatus(testIPAddress2, testMACAddress2, testNetworkName, networkv1.AllocatedState).Build()
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
 		expectedIPPool := newTestIPPoolBuilder().
 			ServerIP(testServerIP).
 			CIDR(testCIDR).
 			PoolRange(testStartIP, testEndIP).
 			NetworkName(testNetworkName).
 			Allocated(testIPAddress1, testMACAddress1).
-			Allocated(testIPAddress2, testMACAddress2).
+			OverrideHostname(testMACAddress1, "web-01").
 			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
 
 		nadGVR := schema.GroupVersionResource{
@@ -403,6 +1164,7 @@ func TestHandler_Allocate(t *testing.T) {
 
 		handler := Handler{
 			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
 			metricsAllocator: metrics.New(),
 			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
 			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
@@ -421,24 +1183,39 @@ func TestHandler_Allocate(t *testing.T) {
 
 		ippool.SanitizeStatus(&expectedIPPool.Status)
 		ippool.SanitizeStatus(&ipPool.Status)
-
 		assert.Equal(t, expectedIPPool, ipPool)
 	})
 
-	t.Run("ippool cache not ready", func(t *testing.T) {
+	t.Run("hostname overridden", func(t *testing.T) {
 		givenVmNetCfg := newTestVmNetCfgBuilder().
-			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
-			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
+			WithVMName("web-01").
+			WithHostname("frontend").
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).Build()
 		givenIPPool := newTestIPPoolBuilder().
 			ServerIP(testServerIP).
 			CIDR(testCIDR).
 			PoolRange(testStartIP, testEndIP).
 			NetworkName(testNetworkName).
-			CacheReadyCondition(corev1.ConditionFalse, "", "").Build()
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).Build()
 		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
 			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
 			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
 
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedIPPool := newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			OverrideHostname(testMACAddress1, "frontend").
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+
 		nadGVR := schema.GroupVersionResource{
 			Group:    "k8s.cni.cncf.io",
 			Version:  "v1",
@@ -455,54 +1232,48 @@ func TestHandler_Allocate(t *testing.T) {
 		}
 
 		handler := Handler{
-			ippoolClient: fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
-			ippoolCache:  fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
-			nadCache:     fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
 		}
 
-		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
-		assert.NotNil(t, fmt.Sprintf("ippool %s/%s is not ready", testIPPoolNamespace, testIPPoolName), err)
-	})
-
-	t.Run("ippool not found", func(t *testing.T) {
-		givenVmNetCfg := newTestVmNetCfgBuilder().
-			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
-			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
-		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
-			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
-			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
-
-		nadGVR := schema.GroupVersionResource{
-			Group:    "k8s.cni.cncf.io",
-			Version:  "v1",
-			Resource: "network-attachment-definitions",
-		}
+		status, err := handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
 
-		clientset := fake.NewSimpleClientset()
-		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
-		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+		SanitizeStatus(&expectedStatus)
+		SanitizeStatus(&status)
+		assert.Equal(t, expectedStatus, status)
 
-		handler := Handler{
-			ippoolClient: fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
-			ippoolCache:  fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
-			nadCache:     fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
-		}
+		ipPool, err := handler.ippoolClient.Get(testIPPoolNamespace, testIPPoolName, metav1.GetOptions{})
+		assert.Nil(t, err)
 
-		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
-		assert.NotNil(t, fmt.Sprintf("ippool %s/%s not found", testIPPoolNamespace, testIPPoolName), err)
+		ippool.SanitizeStatus(&expectedIPPool.Status)
+		ippool.SanitizeStatus(&ipPool.Status)
+		assert.Equal(t, expectedIPPool, ipPool)
 	})
 
-	t.Run("nad not labeled with ippool info", func(t *testing.T) {
+	t.Run("static ipv6 address already allocated", func(t *testing.T) {
 		givenVmNetCfg := newTestVmNetCfgBuilder().
 			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
-			WithNetworkConfig(testIPAddress2, testMACAddress2, testNetworkName).Build()
+			WithIPv6Address(testIPv6Address2, testDUID1).Build()
 		givenIPPool := newTestIPPoolBuilder().
 			ServerIP(testServerIP).
 			CIDR(testCIDR).
 			PoolRange(testStartIP, testEndIP).
 			NetworkName(testNetworkName).
+			IPv6Config(testIPv6CIDR, testIPv6ServerIP).
+			AllocatedIPv6(testIPv6Address2, testDUID2, testMACAddress2).
 			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
-		givenNAD := newTestNetworkAttachmentDefinitionBuilder().Build()
+		givenCacheAllocator := newTestCacheAllocatorBuilder().
+			MACSet(testNetworkName).Build()
+		givenIPAllocator := newTestIPAllocatorBuilder().
+			IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).Build()
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
 
 		nadGVR := schema.GroupVersionResource{
 			Group:    "k8s.cni.cncf.io",
@@ -520,13 +1291,16 @@ func TestHandler_Allocate(t *testing.T) {
 		}
 
 		handler := Handler{
-			ippoolClient: fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
-			ippoolCache:  fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
-			nadCache:     fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			cacheAllocator:   givenCacheAllocator,
+			ipAllocator:      givenIPAllocator,
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
 		}
 
 		_, err = handler.Allocate(givenVmNetCfg, givenVmNetCfg.Status)
-		assert.NotNil(t, fmt.Errorf("network attachment definition %s/%s has no labels", testNADNamespace, testNADName), err)
+		assert.Equal(t, fmt.Errorf("ipv6 address %s is already allocated to %s", testIPv6Address2, testMACAddress2), err)
 	})
 }
 
@@ -1099,3 +1873,186 @@ func TestHandler_Sync(t *testing.T) {
 		assert.Equal(t, expectedCacheAllocator, handler.cacheAllocator)
 	})
 }
+
+func TestHandler_SyncDNS(t *testing.T) {
+	const (
+		testZone       = "example.com"
+		testDomainName = "lab.example.com"
+		testDNSName    = "web-01.lab.example.com."
+		testKeyName    = "ddns-key"
+		testSecretName = "ddns-tsig"
+		testSecret     = "c2VjcmV0LWtleS1mb3ItdGVzdGluZy1kZG5zLXVwZGF0ZXM="
+	)
+
+	newTestDNSServer := func(t *testing.T) *ddnstest.Server {
+		key, err := ddns.NewKey(testKeyName, "", testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server, err := ddnstest.NewServer(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = server.Close() })
+		return server
+	}
+
+	newTestHandler := func(t *testing.T, givenIPPool *networkv1.IPPool) *Handler {
+		givenNAD := newTestNetworkAttachmentDefinitionBuilder().
+			Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+			Label(util.IPPoolNameLabelKey, testIPPoolName).Build()
+		givenSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testIPPoolNamespace,
+				Name:      testSecretName,
+			},
+			Data: map[string][]byte{
+				tsigSecretKey: []byte(testSecret),
+			},
+		}
+
+		nadGVR := schema.GroupVersionResource{
+			Group:    "k8s.cni.cncf.io",
+			Version:  "v1",
+			Resource: "network-attachment-definitions",
+		}
+
+		clientset := fake.NewSimpleClientset()
+		err := clientset.Tracker().Create(nadGVR, givenNAD, givenNAD.Namespace)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		err = clientset.Tracker().Add(givenIPPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		k8sclientset := k8sfake.NewSimpleClientset()
+		err = k8sclientset.Tracker().Add(givenSecret)
+		assert.Nil(t, err, "mock resource should add into fake controller tracker")
+
+		return &Handler{
+			cacheAllocator: newTestCacheAllocatorBuilder().
+				MACSet(testNetworkName).
+				Add(testNetworkName, testMACAddress1, testIPAddress1).Build(),
+			ipAllocator: newTestIPAllocatorBuilder().
+				IPSubnet(testNetworkName, testCIDR, testStartIP, testEndIP).
+				Allocate(testNetworkName, testIPAddress1).Build(),
+			metricsAllocator: metrics.New(),
+			ippoolClient:     fakeclient.IPPoolClient(clientset.NetworkV1alpha1().IPPools),
+			ippoolCache:      fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools),
+			nadCache:         fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions),
+			secretClient:     fakeclient.SecretClient(k8sclientset.CoreV1().Secrets),
+		}
+	}
+
+	newTestIPPoolWithDDNS := func(server *ddnstest.Server) *networkv1.IPPool {
+		return newTestIPPoolBuilder().
+			ServerIP(testServerIP).
+			CIDR(testCIDR).
+			PoolRange(testStartIP, testEndIP).
+			NetworkName(testNetworkName).
+			Allocated(testIPAddress1, testMACAddress1).
+			DomainName(testDomainName).
+			DDNS(networkv1.DDNSConfig{
+				Server: server.Addr(),
+				Zone:   testZone,
+				TSIG: &networkv1.TSIGConfig{
+					KeyName:    testKeyName,
+					SecretName: testSecretName,
+				},
+			}).
+			CacheReadyCondition(corev1.ConditionTrue, "", "").Build()
+	}
+
+	t.Run("register bound ip", func(t *testing.T) {
+		server := newTestDNSServer(t)
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithVMName("web-01").
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		givenVmNetCfg.Status.NetworkConfigs[0].LeaseState = networkv1.LeaseBound
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		expectedStatus.NetworkConfigs[0].LeaseState = networkv1.LeaseBound
+		expectedStatus.NetworkConfigs[0].DNSRecord = &networkv1.DNSRecord{
+			Name:      testDNSName,
+			IPAddress: testIPAddress1,
+		}
+
+		handler := newTestHandler(t, newTestIPPoolWithDDNS(server))
+
+		status, err := handler.SyncDNS(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedStatus, status)
+
+		assert.Equal(t, []string{testIPAddress1}, server.A(testDNSName))
+		assert.Equal(t, []string{testDNSName}, server.PTR("111.0.168.192.in-addr.arpa"))
+	})
+
+	t.Run("do not register ip not yet bound", func(t *testing.T) {
+		server := newTestDNSServer(t)
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithVMName("web-01").
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+
+		expectedStatus := newTestVmNetCfgStatusBuilder().
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+
+		handler := newTestHandler(t, newTestIPPoolWithDDNS(server))
+
+		status, err := handler.SyncDNS(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedStatus, status)
+
+		assert.Empty(t, server.A(testDNSName))
+	})
+
+	t.Run("register changed ip", func(t *testing.T) {
+		server := newTestDNSServer(t)
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithVMName("web-01").
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		givenVmNetCfg.Status.NetworkConfigs[0].LeaseState = networkv1.LeaseBound
+
+		handler := newTestHandler(t, newTestIPPoolWithDDNS(server))
+
+		status, err := handler.SyncDNS(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+
+		givenVmNetCfg.Status = status
+		givenVmNetCfg.Status.NetworkConfigs[0].AllocatedIPAddress = testIPAddress5
+
+		status, err = handler.SyncDNS(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, &networkv1.DNSRecord{Name: testDNSName, IPAddress: testIPAddress5}, status.NetworkConfigs[0].DNSRecord)
+
+		assert.Equal(t, []string{testIPAddress5}, server.A(testDNSName))
+		assert.Empty(t, server.PTR("111.0.168.192.in-addr.arpa"))
+		assert.Equal(t, []string{testDNSName}, server.PTR("112.0.168.192.in-addr.arpa"))
+	})
+
+	t.Run("unregister removed vmnetcfg", func(t *testing.T) {
+		server := newTestDNSServer(t)
+		givenVmNetCfg := newTestVmNetCfgBuilder().
+			WithVMName("web-01").
+			WithNetworkConfig(testIPAddress1, testMACAddress1, testNetworkName).
+			WithNetworkConfigStatus(testIPAddress1, testMACAddress1, testNetworkName, networkv1.AllocatedState).Build()
+		givenVmNetCfg.Status.NetworkConfigs[0].LeaseState = networkv1.LeaseBound
+
+		handler := newTestHandler(t, newTestIPPoolWithDDNS(server))
+
+		status, err := handler.SyncDNS(givenVmNetCfg, givenVmNetCfg.Status)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{testIPAddress1}, server.A(testDNSName))
+
+		givenVmNetCfg.Status = status
+		_, err = handler.OnRemove(testKey, givenVmNetCfg)
+		assert.Nil(t, err)
+
+		assert.Empty(t, server.A(testDNSName))
+		assert.Empty(t, server.PTR("111.0.168.192.in-addr.arpa"))
+	})
+}
@@ -79,7 +79,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _chartCrdsNetworkHarvesterhciIo_ippoolsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x19\x5d\x6f\xe3\xc6\xf1\x5d\xbf\x62\x8a\x3e\x38\x01\x4c\x19\x87\x14\x45\x21\xe0\xd0\x3a\xb2\x9a\x08\x71\x1d\x43\xb2\xaf\x08\x8a\x3e\x8c\xb8\x23\x69\xe3\xe5\x2e\xb3\x1f\xb2\xdd\x5c\xfe\x7b\x31\x4b\xd2\xa2\x28\x92\xa6\x75\x77\x41\xf6\x49\x9a\xdd\x9d\xef\x99\x9d\x19\x26\x49\x32\xc2\x5c\x7e\x20\xeb\xa4\xd1\x13\xc0\x5c\xd2\x93\x27\xcd\xff\xdc\xf8\xe1\x6f\x6e\x2c\xcd\xc5\xee\xdd\xe8\x41\x6a\x31\x81\x69\x70\xde\x64\x0b\x72\x26\xd8\x94\xae\x68\x2d\xb5\xf4\xd2\xe8\x51\x46\x1e\x05\x7a\x9c\x8c\x00\x50\x6b\xe3\x91\xc1\x8e\xff\x02\xfc\xfa\xdb\x08\x40\x63\x46\x13\x90\x79\x6e\x8c\x72\x63\x4d\xfe\xd1\xd8\x87\xf1\x16\xed\x8e\x9c\x27\xbb\x4d\xe5\x58\x9a\x91\xcb\x29\xe5\x4b\x1b\x6b\x42\x3e\x81\xae\x63\x05\xba\x12\x7d\xc1\xda\xfc\xf6\xd6\x18\x15\x01\x4a\x3a\xff\x43\x0d\x78\x2d\x9d\x8f\x1b\xb9\x0a\x16\xd5\x0b\x17\x11\xe6\xb6\xc6\xfa\x9b\x3d\xb6\x84\x77\x55\xed\x67\x79\x4c\xea\x4d\x50\x68\xab\xcb\x23\x00\x97\x9a\x9c\x26\x10\xef\xe6\x98\x92\x18\x01\xec\x0a\x3d\x46\x5c\x09\xa0\x10\x51\x3d\xa8\x6e\xad\xd4\x9e\xec\xd4\xa8\x90\xe9\x17\x4a\x3f\x3b\xa3\x6f\xd1\x6f\x27\x30\x66\xc1\x2b\xad\x30\xc6\x78\xa2\xd2\xda\xcd\xec\xee\xdf\x3f\x2e\x7e\x28\x61\xfe\x99\xc9\x3a\x6f\xa5\xde\xb4\x20\xf2\xe8\x83\x1b\xcb\x7c\xf7\x97\x31\xee\x50\x2a\x5c\xa9\x43\x6c\x97\x1f\x2e\xe7\xd7\x97\xdf\x5e\xcf\x0e\xf0\x31\x7f\x1b\xb2\xfd\x08\x83\x8b\x52\xee\x71\xdd\x2f\x67\x57\x6f\x42\x93\x1a\x5d\xe8\xc4\xfd\xe7\xef\x5f\xfd\x63\xcc\x97\xde\xbf\x3f\x5b\xd0\x46\xb2\x79\x49\x9c\x7d\xfd\xdf\xf2\xe8\x01\x9d\xc5\xec\xbb\xf9\xf2\x6e\xb6\x68\x50\x7b\x45\x09\xed\xc4\xa6\x98\x6e\x69\x41\x28\x9e\x3b\x88\x4d\x2f\xa7\xdf\xcf\x16\xb3\xcb\xab\x9f\x3e\x9d\xd8\xe5\x86\xb4\xef\x23\x76\xf9\xdd\xec\xe6\x6e\x38\xb1\x2a\xd0\xc6\xa9\xa5\x18\x63\x77\x32\x23\xe7\x31\xcb\x9b\x58\x0f\xd0\x09\xf4\x85\x13\x14\xdb\xbb\x77\xa8\xf2\x2d\xbe\x2b\x5c\x3b\xdd\x52\x86\x93\xf2\xbc\xc9\x49\x5f\xde\xce\x3f\x7c\xb3\x3c\x00\x03\xe4\xd6\xe4\x64\xbd\xac\x02\xa5\x58\xb5\xdc\x51\x83\x02\x08\x72\xa9\x95\xb9\x8f\x49\xe5\x63\x72\xb0\x07\xc0\x04\x8a\x5b\x20\x38\x89\x90\x03\xbf\xa5\x2a\x7a\x48\x94\x3c\x81\x59\x83\xdf\x4a\x07\x96\x72\x4b\x8e\x74\x91\x56\x18\x8c\x1a\xcc\xea\x67\x4a\xfd\xb8\x81\x7a\x49\x96\xd1\x70\x5c\x07\x25\x20\x35\x7a\x47\xd6\x83\xa5\xd4\x6c\xb4\xfc\xdf\x0b\x6e\x07\xde\x44\xa2\x0a\x3d\x39\x1f\x1d\xd7\x6a\x54\xb0\x43\x15\xe8\x1c\x50\x8b\x06\xe6\x0c\x9f\xc1\x12\xd3\x84\xa0\x6b\xf8\xe2\x05\xd7\xe4\xe3\x5f\xc6\x12\x48\xbd\x36\x13\xd8\x7a\x9f\xbb\xc9\xc5\xc5\x46\xfa\x2a\xa3\xa6\x26\xcb\x82\x96\xfe\xf9\x22\x35\xda\x5b\xb9\x0a\xde\x58\x77\x21\x68\x47\xea\xc2\xc9\x4d\x82\x36\xdd\x4a\x4f\xa9\x0f\x96\x2e\x30\x97\x49\x14\x44\x47\xff\x1a\x67\xe2\xcf\xb6\xcc\xc1\xee\x80\xec\x91\xef\x14\x2b\x66\xc8\x37\x98\x87\x93\x27\x48\x07\x58\xa2\x2a\x44\xdc\x5b\x81\x41\xac\xba\xc5\x6c\x79\x07\x15\x27\x85\xa5\x0a\xa3\xec\x8f\x1e\xe9\xa5\xb2\x0f\x6b\x53\xea\x35\xd9\xe2\xde\xda\x9a\x2c\xe2\x24\x2d\x72\x23\xb5\x8f\x7f\x52\x25\x49\x7b\x70\x61\x95\x49\xcf\x6e\xf0\x4b\x20\xe7\xd9\x74\x4d\xb4\xd3\xf8\xea\xc0\x8a\x20\xe4\xec\xec\xa2\x79\x60\xae\x61\x8a\x19\xa9\x29\x3a\xfa\x9d\x6d\xc5\x56\x71\x09\x1b\x61\x90\xb5\xea\x6f\x69\xf3\x70\xa1\xde\xda\x46\xf5\x60\xee\x57\x7b\x9c\xf2\xe2\x14\x3e\x35\x7a\x2d\x37\xcd\x9d\xbe\x5b\xbc\x52\x29\x6c\x1b\xbc\x53\x86\xfd\x7a\x4a\x1e\xc2\x8a\xac\x26\x4f\x2e\xd9\xa1\x92\xa2\x5e\x1a\x34\x57\x02\x19\x39\x87\x1b\xce\xc2\xf3\xab\x05\x3b\xa1\xcc\xb2\xe0\x6b\x8f\x58\x73\xd9\xa0\x98\x03\x52\x6b\x78\xff\x1e\x8c\x12\x4b\x52\xeb\x96\xb3\xa2\x8b\xe6\xda\xd8\x0c\xfd\x24\xaa\xa7\xf5\x80\xf4\x94\x75\xdc\x1d\xa0\x80\x0c\x9f\xe6\x11\x01\x7c\xd3\xa3\x41\xb4\x16\x9f\xdb\xb8\x36\x19\x4a\xcd\x15\xc1\x49\xfa\x2f\xae\x2f\x89\x5d\xb4\x1d\xc1\xa7\x09\xd7\xcf\xbc\x22\x74\xc4\x0f\x54\x1f\xef\xf5\x8a\xe1\x70\x69\x9f\x7f\x09\x9e\xf7\x06\x69\x37\x77\xbf\x4c\x5c\xfc\xb5\x93\xee\x0f\x21\x5e\xd4\x4c\xc3\xf5\xf5\xaa\x1b\x0e\x12\x0e\xde\x1c\x72\x70\x18\x76\xb3\x22\xf5\xbf\x16\x75\xf0\x86\xc8\x8b\xa2\x3f\xa5\x2a\x88\x0e\x47\x18\x2c\x7e\xaf\xe1\x61\xa8\x7e\xfa\x0d\x5c\xac\x4f\xd3\x61\x21\xec\x97\xd0\xa3\xf3\x68\xfd\x1f\xde\x89\x96\xcc\xe5\xe7\x17\x9f\xdf\x7f\x69\xa9\x23\x88\x12\x8e\xaf\x8e\x9d\xa8\xb6\x9e\x68\x3f\x7a\x57\xf7\xeb\xe4\x07\xac\x8c\xa4\x8a\x69\x30\x3a\x25\x70\xd4\x4e\xa5\x52\xc3\xd9\x9f\xb6\xe8\xbe\x2a\x95\x30\x2e\xa3\xe6\x6b\xf8\xf8\x11\x18\xee\xea\xc0\xb3\x16\x44\xd6\x04\x4f\x1d\x4f\xf5\xab\xbe\xf1\xe5\xde\xf2\x45\x64\xeb\x73\xbe\xe6\x2e\x96\x91\xf3\xdb\x3f\x9c\xa8\xcb\x92\xb1\xcf\x27\x6c\xb7\xd7\x27\xb1\x30\x6b\x01\xe7\xd5\x28\xe4\x10\x5c\x29\xed\x68\xab\x37\x08\x86\xab\xa2\xd5\xe2\x43\xfc\xbf\xcd\xf7\x0b\x57\x3e\x74\xfd\x12\xd6\xf4\xfc\xda\xdc\xe4\x98\xa9\x0c\x9f\xae\x49\x6f\xb8\x7b\xfe\xeb\xb1\x33\xf4\x3a\xc2\x49\x92\xdf\xec\x99\x79\xcd\x07\x86\xd8\x3f\xc7\xe0\xda\x6c\x5f\x30\xbe\x32\x46\x11\xea\x83\xdd\x76\x7f\x49\xe0\x78\xba\x54\xc7\x74\xdc\x59\xc4\x59\xc5\xd0\xde\x02\x37\xa4\xfd\xad\x11\x0b\x5a\xbf\xb5\xb9\x90\x19\xeb\xed\x94\x30\xd5\xa7\x96\xc5\xba\x9a\xd9\x9d\x74\x3b\xc8\x8e\x17\xa8\xbf\xab\xae\xd6\xfd\xfc\xaa\x68\xae\x99\x0c\xf8\x2d\x7a\xd8\x1a\x25\x1c\x04\x2d\x7f\x09\x04\xf3\xab\x72\xa4\x70\x0e\x52\x73\xaa\xe7\x76\xfb\xfe\x7e\x7e\xe5\xc6\x00\xdf\x52\xca\x0e\x01\x8f\x5d\x39\x45\x18\x7d\xe6\xe1\xc7\x9b\xeb\x9f\x80\xcf\xc5\x7b\xe7\x45\x8f\xcd\x44\x35\xa0\x92\xc5\xf0\xa3\x90\x2f\xe2\x64\x0a\x25\x3f\x29\xe6\xdc\xc7\xba\x0e\xf4\x5c\xad\x6b\x0f\xa8\x05\x6c\x49\xe5\xdc\xc7\x3f\x10\xb8\x60\x4b\x49\x98\x5c\xdc\x8d\x2a\x06\x61\x80\xdb\xf2\x0d\x79\x48\x8d\x5e\xab\xb6\xce\x7c\x80\xce\x7b\xf2\xd3\x7e\xec\x76\x6c\x93\xce\x72\xf1\xb5\x52\x5d\xa1\xf3\x77\x16\xb5\x93\xd5\x88\xad\xab\xec\x39\x30\xf9\x35\x3a\x0f\x5e\x66\x54\x0c\x2f\x2a\xce\xc0\xbf\xa0\x22\x51\x4c\x3a\x8c\x26\x38\x18\x06\xb6\x28\xc4\x00\x6a\xe3\xb7\x64\xdb\x15\x36\xe0\x11\x63\x31\xee\xe3\x38\x64\xb0\x08\x77\x71\x22\xb6\x17\x43\xba\x9a\x1c\x8f\xe8\xba\xc6\x2b\x83\x79\xaa\xf2\xe4\x10\x66\xbe\x0f\x19\xea\xc4\x12\x0a\x4e\xa0\xd5\x55\x90\x5a\xc8\x14\xe3\x14\x4a\x90\x47\xa9\x1c\xe0\xca\x84\xae\xc2\x0a\x4a\x81\x5e\x8c\x70\x2a\xeb\x96\xd0\x35\xe7\x9c\x1d\x9c\xb3\x1a\x8b\xe3\x5c\x8b\x1c\xba\xc3\x99\x6b\x32\x74\xb2\x32\xdb\x72\x74\x07\x47\xcb\x78\xb4\x98\xa8\xd6\x98\x39\x8f\xae\x68\xd6\x70\x67\x03\x9d\xc3\x3f\x51\x39\x3a\x87\x7b\xfd\xa0\xcd\xe3\xe9\x7c\xc5\x03\x83\xf4\xc4\x29\xc7\xac\x21\x55\xc1\x71\xbd\xf0\xc2\xd7\x89\xa4\xfb\x3a\x84\xa4\x3b\xe2\x92\x88\xb7\x65\xa3\xb7\x30\xea\xee\x23\xb9\xee\x7c\xeb\x33\x88\x4a\x99\x94\x43\xab\x5d\x71\xf5\x6f\x49\xaf\xcd\x19\x06\x8e\x6d\x3a\x9b\x9e\x97\xef\x46\xa7\xcd\x6d\xda\x0b\x96\xd7\x6f\xf6\x15\xba\xcd\x4f\x59\xf5\xbd\xda\x57\xa9\x41\x22\xee\xd3\xe2\x31\xa5\xaa\x73\xe0\xdd\x84\x73\xe0\xf0\x8a\xb1\x95\xe2\x11\x30\xd6\xe0\x62\x02\xde\x86\x02\xb7\xf3\xc6\xc6\xc2\x71\x0f\x09\xab\x97\xf1\x7e\xc5\x61\x19\xe9\xf0\xeb\x6f\xa3\xff\x07\x00\x00\xff\xff\x51\x01\x92\x53\xb3\x1d\x00\x00")
+var _chartCrdsNetworkHarvesterhciIo_ippoolsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x5c\x5f\x6f\xe3\x36\x12\x7f\xd7\xa7\x98\xc3\x3d\x6c\x0b\xac\x9c\x66\x77\x13\x14\x02\x16\x77\x69\x92\x6b\x8d\xa6\xdb\xc0\x49\xf6\xd0\x3b\xdc\x03\x2d\x8d\x6d\x36\x14\xa9\x92\x94\x37\xe9\x9f\xef\x7e\x18\x4a\x8a\x65\xd7\x12\x69\x39\xc9\x2e\x8a\xb5\xfc\x10\x53\xa3\xe1\xfc\xfd\x0d\x49\x91\x89\xe3\x38\x62\x05\x7f\x8f\xda\x70\x25\x13\x60\x05\xc7\x3b\x8b\x92\x7e\x99\xd1\xed\xd7\x66\xc4\xd5\xc1\xf2\x30\xba\xe5\x32\x4b\xe0\xb4\x34\x56\xe5\x13\x34\xaa\xd4\x29\x9e\xe1\x8c\x4b\x6e\xb9\x92\x51\x8e\x96\x65\xcc\xb2\x24\x02\x60\x52\x2a\xcb\xa8\xd9\xd0\x4f\x80\xdf\xfe\x88\x00\x24\xcb\x31\x01\x5e\x14\x4a\x09\x33\x92\x68\x3f\x28\x7d\x3b\x5a\x30\xbd\x44\x63\x51\x2f\x52\x3e\xe2\x2a\x32\x05\xa6\xf4\xd0\x5c\xab\xb2\x48\xa0\x8b\xac\x62\x57\xb3\xaf\x44\x1b\x5f\x5e\x2a\x25\x5c\x83\xe0\xc6\x7e\xdf\x6a\xbc\xe0\xc6\xba\x1b\x85\x28\x35\x13\x0f\x52\xb8\x36\xb3\x50\xda\xbe\x5b\x71\x8b\xe9\xae\x68\xfd\x69\xdc\xdf\x86\xcb\x79\x29\x98\x6e\x1e\x8e\x00\x4c\xaa\x0a\x4c\xc0\x3d\x5b\xb0\x14\xb3\x08\x60\x59\xd9\xd1\x49\x16\x03\xcb\x32\x67\x1e\x26\x2e\x35\x97\x16\xf5\xa9\x12\x65\xde\x98\x25\x86\x9f\x8d\x92\x97\xcc\x2e\x12\x18\x91\xe2\x8d\x55\x88\xa3\xeb\xb4\xb1\xda\xbb\xf3\xeb\x7f\xff\x38\xf9\xbe\x6e\xb3\xf7\xd4\xad\xb1\x9a\xcb\xf9\x16\x46\x96\xd9\xd2\x8c\x78\xb1\x7c\x33\x62\x4b\xc6\x05\x9b\x8a\x75\x6e\x27\xef\x4f\xc6\x17\x27\xdf\x5c\x9c\xaf\xf1\x23\xf9\xe6\xa8\xfb\x19\x96\x06\xb3\x35\x5e\x37\x57\xe7\x67\x3b\xb1\x49\x95\xac\x6c\x62\xfe\xfb\x8f\x2f\xfe\x39\x22\x5d\xde\xbe\x7d\x31\xc1\x39\xa7\x28\xc0\xec\xc5\x97\xff\xab\x49\xd7\xfa\x99\x9c\x7f\x3b\xbe\xba\x3e\x9f\x9c\x9f\xed\x62\x84\xed\x9d\x9d\xb2\x74\x81\x13\x64\xd9\x7d\x47\x67\xa7\x27\xa7\xdf\x9d\x4f\xce\x4f\xce\x7e\xda\xbf\xb3\x93\x39\x4a\xdb\xd7\xd9\xc9\xb7\xe7\xef\xae\xc3\x3b\x6b\x12\x6d\x94\x6a\x74\x39\x76\xcd\x73\x34\x96\xe5\xc5\x26\xd7\x35\x76\x19\xb3\x55\x10\x54\x9d\x2e\x0f\x99\x28\x16\xec\xd0\x35\x99\x74\x81\xb9\xcb\x5c\xfa\xa5\x0a\x94\x27\x97\xe3\xf7\xaf\xaf\xd6\x9a\x01\x0a\xad\x0a\xd4\x96\x37\x89\x52\x5d\x2d\xec\x68\xb5\x02\x64\x68\x52\xcd\x0b\x92\x30\x81\xdf\xe3\xb5\x7b\x00\xd4\x41\xf5\x14\x64\x04\x22\x68\xc0\x2e\xb0\xc9\x1e\xcc\x6a\x99\x40\xcd\xc0\x2e\xb8\x01\x8d\x85\x46\x83\xb2\x82\x15\x6a\x66\x12\xd4\xf4\x67\x4c\xed\x68\x83\xf5\x15\x6a\x62\x03\x66\xa1\x4a\x91\x41\xaa\xe4\x12\xb5\x05\x8d\xa9\x9a\x4b\xfe\xeb\x03\x6f\x03\x56\xb9\x4e\x05\xb3\x68\xac\x0b\x5c\x2d\x99\x80\x25\x13\x25\xbe\x04\x26\xb3\x68\x8d\x31\xe4\xec\x1e\x34\x52\x9f\x50\xca\x16\x3f\xf7\x80\xd9\x94\xe3\x07\xa5\x11\xb8\x9c\xa9\x04\x16\xd6\x16\x26\x39\x38\x98\x73\xdb\x20\x6a\xaa\xf2\xbc\x94\xdc\xde\x1f\xa4\x4a\x5a\xcd\xa7\xa5\x55\xda\x1c\x64\xb8\x44\x71\x60\xf8\x3c\x66\x3a\x5d\x70\x8b\xa9\x2d\x35\x1e\xb0\x82\xc7\x4e\x11\x49\xea\x9b\x51\x9e\xfd\x5d\xd7\x18\xdc\x04\x53\x47\xec\x54\x5f\x87\x90\x3b\xb8\x87\xc0\x13\xb8\x01\x56\xb3\xaa\x6c\xb2\xf2\x02\x35\x91\xe9\x26\xe7\x57\xd7\xd0\x48\x52\x79\xaa\x72\xca\x8a\xd4\x74\xf9\x87\xac\xc9\xe5\x0c\x75\xf5\xdc\x4c\xab\xdc\xb9\x03\x65\x56\x28\x2e\xad\xfb\x91\x0a\x8e\xd2\x82\x29\xa7\x39\xb7\x14\x06\xbf\x94\x68\x2c\xb9\x6e\x93\xed\xa9\xab\x3a\x30\x45\x28\x0b\x0a\xf6\x6c\x93\x60\x2c\xe1\x94\xe5\x28\x4e\x99\xc1\x67\xf6\x15\x79\xc5\xc4\xe4\x84\x20\x6f\xb5\x6b\xe9\xea\x53\x11\x57\xe6\x6d\xdd\x68\x0a\x26\x40\x7f\x9e\xd2\x45\x35\xe1\x54\xc9\x19\x9f\x6f\xde\xe9\x7b\x8a\xae\xa9\x52\x76\x5b\xbb\xef\x39\xba\xda\xd6\xe9\x24\x02\xe0\x16\xf3\x9e\xdb\x21\x3d\xad\xfa\xeb\xa7\x00\xc8\xd9\x1d\xcf\xcb\x3c\x81\xe3\xa3\xa3\xd7\x47\x3e\x62\x2e\x2b\xe2\xaf\x3c\x84\x7f\xae\x80\x5d\x9f\x19\x17\xe8\x90\xd8\xc3\x31\x67\x77\x17\x28\xe7\x54\x66\x0e\x5f\x7d\xed\x21\xee\x08\xa7\xcd\x8b\x92\x88\x6b\xdc\x00\x84\xf5\x2b\x76\x56\xec\x25\x68\x54\xe8\x21\xea\x08\xd9\xf5\xab\x22\x62\x5a\xb3\xfb\x68\xa8\xb1\x02\xcd\x14\x60\x20\x5e\xdc\xe1\x95\x43\xc6\x9b\xc9\x45\xb2\x0f\x27\x89\x77\xb6\xaa\x45\xdd\x6c\x66\x4a\xe7\xcc\xd2\x90\x72\xf9\x66\x9f\xbe\xec\xcc\x16\x49\xb4\x5f\xe2\xa4\x0e\x18\x7e\x60\xc5\x3b\x6f\x5c\x06\x48\x44\xdf\x82\xaa\xb9\xb1\x28\xed\x7b\x1a\xf6\xe2\xa9\x60\x3c\x7f\x24\xee\xde\xd0\xf2\x10\xa4\x3c\xeb\xf0\x8b\xb7\xfb\xbb\xf8\xb6\x9c\xa2\x96\x68\xd1\xc4\x4b\x26\x78\xd6\x9e\xeb\x6c\x7e\x62\xc8\xd1\x18\x36\xa7\x61\xe5\xf8\x6c\x42\x55\x95\xe7\x79\x69\x5b\xa3\xf2\xcd\x4b\x97\x82\x1c\x8e\x62\x06\x6f\xdf\x82\x12\xd9\x15\x8a\xd9\x16\xda\x54\x30\x63\xba\x9c\xda\x0b\xa7\x21\x11\x91\xba\xa9\xde\x8f\x45\x8f\x6e\x01\x3d\x85\xf7\x57\xf7\xaa\x32\x4f\x78\xd4\xf9\x5e\x21\xf2\xab\xa3\x37\x91\x87\x76\x05\xdf\x87\x1e\xf8\xde\x05\xc0\x6b\x5a\x2f\x47\x94\x65\xee\xd7\x87\x26\x9a\x41\x44\xb1\x68\xa6\xb2\x7d\x57\xec\xcb\x9e\xe6\x13\x43\xc9\xa5\xfd\x3a\x90\xee\xf0\x38\x90\xf0\xf5\xab\x00\xc2\x05\xde\x79\xa9\x82\xa0\xa0\xfa\xba\x11\x6a\xf2\x78\x1c\x43\xaa\x24\x19\x26\x55\x59\x5f\x09\x24\x12\xea\xd3\x43\xe2\xa4\xef\xa5\xf1\x02\x5e\x68\x35\x0d\x1b\x7c\x04\x0f\x3c\x82\x0c\x9a\xb3\xf4\x52\xe3\x8c\xdf\xe1\xbe\x48\x12\xe8\xbf\x10\x33\xec\x60\x82\xe3\x37\xd1\x9e\x22\x85\x8c\x06\x02\xc7\x03\x81\x3d\x96\x06\xf5\x69\x5f\x85\xf8\x18\x36\x5f\xa2\xcc\xd4\xa7\x26\x96\x2f\xd5\x63\xe8\x19\xe7\x7a\xf3\x32\x67\x77\x63\x57\x8b\xa1\x03\x16\xfb\x05\xa4\x61\x99\xe0\xa9\x3d\x43\x9a\x60\xfe\x69\x99\xa5\xb9\x2a\x26\x53\xa5\x04\x32\x19\x0d\xaa\xe6\xfb\x8f\x18\x3c\xb5\x3b\xb0\x6a\x07\xd5\xeb\xb0\x4a\xed\xab\xd1\xbe\xea\xec\xa9\xcb\x21\x15\x39\xa0\x16\xfb\xab\x70\x40\xfd\x0d\xa8\xbc\xbe\x9a\x1b\x94\x50\xde\x3a\x1b\xc0\xc5\x9f\x70\x3d\x55\xb5\xb7\x9e\xf6\x57\x52\x6f\xae\xf6\xa7\x62\x96\x75\xa5\x8e\x3f\x3b\x34\xd2\xa2\x17\xfe\x47\xc9\x1e\xe3\xb5\x2a\xce\xab\xa3\xd7\xd1\x1e\x16\x36\x9e\x52\x13\xc0\xc2\x9a\x6d\xcb\x44\xe1\x1a\xd3\xc5\xc4\x5c\x69\x6e\x17\x9e\x21\xb0\x7f\x90\x1c\xc3\x22\x67\x69\x6c\x9a\x25\x6b\x3f\xdd\xab\xa3\xe3\x40\xca\xa3\xc3\xbe\xa4\x09\xb2\x15\x7d\x6f\xf1\xfe\xd1\x66\xce\x06\x53\x8d\xf6\x91\xd8\xf9\xb2\x8d\x6c\x51\x0b\xdf\x43\xb1\x12\xa9\x93\xc8\x9b\x5e\x00\xd6\x8a\x24\xf2\x02\xff\x57\xd1\x3e\xb0\xff\xeb\xb3\xe4\x58\xbf\x55\xe3\x3a\x03\x3b\x6e\x92\x88\xd1\x00\x13\x76\x02\x90\x77\xe0\xd8\x5b\xdc\xbd\xca\xb6\xc6\x30\x43\x70\x53\xe5\x8c\xcb\xee\x68\xf6\x74\x5f\x3d\x7e\x85\xdd\xeb\xba\xfb\x29\xe7\x11\xfe\x5e\xb2\x9c\xa7\x13\x26\xe7\x38\x14\xfc\x71\xf3\xed\xcb\x4e\xae\x0b\x52\x02\x40\x20\x33\x48\x2f\x03\x03\x12\xec\x78\xbf\x0c\x33\x96\x69\xfb\xd4\x1a\xf9\x12\x0c\x65\xd6\x71\xc7\x89\x37\x24\xbd\xb8\x74\xfb\x19\xf0\x82\x4c\x39\x6c\x9c\xed\xf1\x82\xcf\xba\xd2\x16\x4f\x11\xe2\xab\xfc\x7d\x33\x20\x05\x68\xbb\xc3\x27\x1d\xfa\xbb\xae\xc9\x6e\xac\xcb\x9e\xcb\x2c\x64\x59\x76\x97\xa5\x59\xba\xf0\x2e\x15\x65\x86\x7b\xaa\xdf\xeb\xf8\x60\xfb\xf4\x3b\xf8\x31\x6c\x58\x29\xfb\x14\x76\x7c\x16\xb4\xd9\xdb\x00\x57\x24\xe5\xe3\xab\xff\x11\x50\x70\x57\x43\xb4\xa3\xa0\xca\xa4\x46\x68\x50\x32\x45\x30\x68\xa3\x3e\x33\xbc\xf8\xdb\x82\x99\x2f\x6a\x23\x8c\xea\xac\xf9\x12\x7e\xff\x1d\xa8\xdd\xb4\x1b\x5f\x6c\x61\xa4\x59\xc1\xb3\x53\x95\xe7\xdc\x0e\x83\x6c\x8d\x53\x2e\x33\x2e\xe7\xdd\xb0\xed\x59\x92\xf0\xa1\xba\x46\xc1\xee\x87\x22\x68\xca\x75\x5a\x72\x3b\x3e\x33\xc9\x47\x07\x09\x8d\xb9\xb2\xf8\x09\x88\xe2\x09\x61\x8d\x12\x3f\x30\xf1\x74\x0e\x55\xa5\xed\x9a\x5e\x7b\xf1\xc8\x6b\x80\xc1\xe9\x37\x71\x62\x85\x80\x50\x28\x00\x69\xe2\xd8\xd1\x71\xaf\xa7\xfd\x71\xed\xb6\x88\x59\x2e\x59\xf7\xa2\x66\xa0\xbd\x68\xcf\xd2\x9c\x59\xfc\xd0\x95\x64\x3b\x14\x8a\xa0\xee\xfa\x41\x99\x5c\xd2\x52\xad\x93\xa6\x16\xb9\xe3\xbe\x17\xa4\x57\x63\xba\x8e\x75\xc1\xfe\x1c\xaa\xe6\xa7\xe3\xcb\x24\x1a\x64\xab\xa7\x0b\xe2\xab\x5a\xb0\xc7\x0b\xe3\x6e\x77\xc5\x6e\x1f\xc0\x96\xe6\x7a\x87\xef\xf6\x49\xfd\xf8\x32\xda\xc9\x5b\xe1\xa6\xd8\x9a\xcb\x21\xd5\x74\x5b\x25\x75\xb9\xab\xd7\x0b\x69\xdd\xb6\x59\x47\x79\xb1\x3c\x1e\xb6\x31\xec\xaf\xb0\x8f\x22\x93\x43\x10\xae\x95\x22\x5d\x2b\x8d\x3b\xcc\xcb\x5e\x0f\x48\xe1\x8f\xbb\x30\x52\x68\x9c\xa1\xd6\x98\x5d\xf0\x19\xda\xce\x4a\xeb\x2b\xa5\xc1\x38\x74\x1c\x0d\x52\xe2\x13\xc2\x21\xb7\x49\x81\xef\x65\xaf\x01\x50\x36\x04\xb3\x5a\xe7\x10\x92\xa8\x67\x05\x75\xcb\x7b\xf1\x5e\x87\x84\x3b\xa3\xe5\x88\x77\x2b\x61\x7c\xbe\x08\xf1\x43\xc1\xe8\x10\x43\x12\x85\x4f\x13\xb6\x1b\x3d\x6e\x5b\x29\x0a\x30\x6c\x75\xd0\x20\x89\xc2\xc0\x95\xd1\xb9\x81\x4b\x95\x4d\x70\xb6\x2b\x26\xf3\x9c\x75\xad\x55\x7a\xd2\xa5\x7b\x4b\x44\xc0\x83\xee\x0c\xcc\xa0\xa7\x4b\xbe\xc5\x1f\xfe\x5d\xea\xcd\xe7\x66\x7c\x46\x81\xc1\x9c\xe1\xc1\x2e\x98\x85\x85\x12\x99\x81\x52\xf2\x5f\x4a\x84\xf1\x19\x25\x5e\x89\xe6\x25\x70\x49\xb3\x4b\xda\xbe\x7e\x73\x33\x3e\x33\x23\x80\x6f\x30\xa5\x80\x80\x0f\xdb\xe2\x89\xae\x4c\xc9\x17\x16\x7e\x7c\x77\xf1\x13\x10\x9d\x7b\xee\x65\xb5\x67\x9d\x3a\x95\xc0\x04\x67\xb4\x23\xbd\xd6\xcf\xf1\xa4\x1e\x6a\x79\x52\x56\xb8\x9d\xcf\x1d\xec\x09\x19\xa5\xa5\xd3\x06\xb0\x40\x51\x18\xc8\xd9\x2d\x82\x29\x75\xad\x09\x75\xe7\xee\x92\x6f\x0c\x64\x0a\x68\x9b\xfb\x1c\x2d\x9d\x6c\x98\x89\x6d\x3b\xdd\x03\x6c\xde\x93\xfb\xab\x63\x2c\x49\x14\x5c\x4f\xfa\x03\x12\x40\x30\x63\xaf\x35\x93\xc6\x71\xee\x9e\x97\x6d\xb8\xfc\x82\x19\x0b\x54\x5b\xaa\xc3\x00\x8d\x64\x60\x1f\x58\x61\x56\x9d\x1c\x50\x12\xeb\x04\xeb\xe0\x0b\xe4\x21\x26\x95\x5d\xa0\xde\x6e\x30\x8f\xc9\x1a\x35\x6e\xdc\xf1\x82\x60\x15\xae\xdd\x09\x93\x95\x1a\xdc\xb4\xf4\xf8\xc0\x4c\xd7\x71\x85\x60\x99\x1a\x9c\x0c\x11\xe6\xbb\x32\x67\x32\xd6\xc8\x32\x2a\x66\x0d\xc4\x02\x2d\x7f\xa4\xcc\x52\xd0\x66\x68\x19\x17\x06\xd8\x54\x95\x36\xda\xca\xb1\xb6\x43\xcb\x09\x43\x45\xd7\xc8\x8c\x92\x41\x92\x93\x19\x2b\x72\x5a\xf0\x5b\x0f\x87\x17\x66\x53\xa0\xc1\xc6\xdc\x86\xd1\x1d\x12\x5d\x39\x52\x3a\x8a\xb4\x26\xcc\x4b\x17\x8a\x6a\x06\xd7\x9a\x4e\x11\xfd\x8b\x09\x83\x2f\xe1\x46\xde\x4a\xf5\x61\xb8\x5c\x7d\x1b\x59\xd6\xed\x44\x10\xa8\x66\x90\x8a\x92\xce\xd3\xad\xe4\x1a\xd8\x75\xf7\x80\xa3\x5e\x63\xdc\x9e\x71\x9d\x9b\x34\x7a\x80\xa7\x6f\xc0\xb9\x40\x26\xec\xe2\x52\xab\x29\xee\x5a\x0d\x29\x34\xae\xca\x34\x45\x63\xba\x93\xb6\x19\x67\x52\x6a\xc7\x04\x38\xd1\x00\x5b\x11\x18\xcb\xf4\x3e\xd9\xfd\xd9\x1e\xab\xd0\x0c\x7c\x57\x95\x99\x10\x2a\x25\x50\xd9\x76\x13\xd6\x4e\xa5\xf6\xb1\xf1\x8a\xed\x11\x9d\xbe\x0f\x27\x50\x87\x8c\x76\x1f\xde\xbe\xba\x17\x72\x66\x7f\x6d\xfa\xcd\xd6\xbc\xb6\x29\xb8\xbe\xef\xc3\xf7\xf0\x80\x09\xb4\x61\xbd\x45\xf6\x24\xcb\x34\x1a\x93\xec\xc7\xaa\x2f\x63\xeb\x77\x06\x0f\x1a\x76\x92\xac\xa4\xe9\x20\xf1\xb8\xdd\x4b\x20\x9e\xd1\xa3\x53\x55\xca\xec\xd9\x1d\x5a\x1d\x5d\xfc\x4e\x19\xeb\xdb\x68\x1c\xc4\xee\x23\x85\x25\x2f\x1e\x27\x2a\xdb\x63\xa7\x67\x55\xc0\xbd\x0a\x78\x76\xb3\x19\xeb\xd1\xf3\x51\x12\xf9\xc1\x39\x9d\x14\x4e\x8e\x27\x4a\x61\xb5\x44\xad\x79\xf6\x5c\x59\x5c\xe5\xd3\xf8\xac\x9b\x22\xc8\xaa\x9f\x0f\x36\x7d\x3e\xd8\xf4\xf9\x60\xd3\x5f\xf3\x60\xd3\xe2\x71\x8a\xad\x57\x22\x0f\xc1\x2f\x25\xd3\x8c\xfe\x49\x02\x66\xcf\x03\x8d\x9f\x87\xac\xcf\x31\x64\xdd\xbe\x6c\xec\x47\xd7\x6e\xed\xe2\xd5\xf4\x68\xcb\xbd\xd6\xff\xda\x09\x92\x91\xde\x91\x24\xd1\x6e\xf1\xf3\x88\x33\xc5\x90\x40\xcd\x3a\x57\x7a\x83\x63\x05\x80\x33\x9e\x85\xc4\xb9\x0f\xaf\xc3\x6a\xe2\x33\x06\xf9\x13\x47\x70\xcf\xcd\xbe\xb1\xb9\x1f\x36\x3a\x95\xdf\xda\xe3\x9f\x1a\xdd\x0b\xaa\x2c\x01\xab\xeb\x4a\x61\xac\xd2\xb4\xa2\xd9\x6a\x29\xa7\x0f\xff\xef\xa6\x91\xd0\x58\x66\x4b\x93\xc0\x6f\x7f\x44\xff\x1f\x00\x43\x85\x7b\xb9\xc4\x4c\x00\x00")
 
 func chartCrdsNetworkHarvesterhciIo_ippoolsYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -94,12 +94,12 @@ func chartCrdsNetworkHarvesterhciIo_ippoolsYaml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "chart/crds/network.harvesterhci.io_ippools.yaml", size: 7603, mode: os.FileMode(420), modTime: time.Unix(1769152639, 0)}
+	info := bindataFileInfo{name: "chart/crds/network.harvesterhci.io_ippools.yaml", size: 19652, mode: os.FileMode(420), modTime: time.Unix(1792204442, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _chartCrdsNetworkHarvesterhciIo_virtualmachinenetworkconfigsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x57\xdd\x6f\x23\x35\x10\x7f\xcf\x5f\x31\x12\x0f\x07\xd2\x6d\xa2\x0a\x04\x28\x52\x05\x21\x3d\x20\xa2\x2d\xd5\xa5\x57\x09\x21\x1e\x26\xeb\x49\xe2\xab\x3f\x16\x7b\x9c\xbb\x72\xdc\xff\x8e\x6c\xef\x36\x9b\x8f\x4d\x73\xa1\xe0\xb7\xb5\x67\xe6\x37\xdf\x33\x5b\x14\x45\x0f\x2b\x79\x47\xce\x4b\x6b\x86\x80\x95\xa4\xf7\x4c\x26\x7e\xf9\xfe\xfd\xb7\xbe\x2f\xed\x60\x75\xd6\xbb\x97\x46\x0c\x61\x1c\x3c\x5b\xfd\x9a\xbc\x0d\xae\xa4\x0b\x9a\x4b\x23\x59\x5a\xd3\xd3\xc4\x28\x90\x71\xd8\x03\x40\x63\x2c\x63\xbc\xf6\xf1\x13\xe0\xc3\xc7\x1e\x80\x41\x4d\x43\x58\x49\xc7\x01\x95\xc6\x72\x29\x0d\x19\xe2\x77\xd6\xdd\x97\xd6\xcc\xe5\xc2\xf7\xeb\xcf\xfe\x12\xdd\x8a\x3c\x93\x5b\x96\xb2\x2f\x6d\xcf\x57\x54\x46\x49\x0b\x67\x43\x35\x84\x2e\xb2\x8c\x51\x63\x66\x7d\xef\x32\xdc\x55\x86\xbb\xce\x8c\xe3\x04\x97\xa8\x94\xf4\xfc\xcb\x53\x94\x97\xd2\x73\xa2\xae\x54\x70\xa8\x0e\x1b\x91\x08\xfd\xd2\x3a\xbe\x5e\x2b\x53\xc0\x4a\x1b\xe2\x72\xbe\xd8\xfa\xac\xc9\xa5\x59\x04\x85\xee\xa0\xe4\x1e\x80\x2f\x6d\x45\x43\x48\x82\x2b\x2c\x49\xf4\x00\x56\x39\x70\x09\xa8\x00\x14\x22\xc5\x03\xd5\x8d\x93\x86\xc9\x8d\xad\x0a\xda\x3c\xaa\xf1\xd6\x5b\x73\x83\xbc\x1c\x42\x3f\x3a\xb5\xbf\xd2\x51\x58\x7a\x6c\x22\x74\x77\x75\x3d\xba\x7a\x55\x5f\xf1\x43\x04\xf4\xec\xa4\x59\xec\x11\xc1\xc8\xc1\xf7\x4b\x6b\x32\xaa\xff\xfd\xbb\xcf\xbf\xef\x47\x9e\xf3\xf3\x17\x23\xa5\x6c\x89\x4c\xe2\xc5\x17\x7f\xd4\x94\x1b\x38\xa3\xcb\xcb\x5f\xc7\xa3\xdb\x57\x17\xff\x1e\xea\x42\x7a\x9c\xa9\x4e\xa4\x8b\xc9\x74\xf4\xc3\xe5\x73\x00\x4d\xcc\xf4\xc1\x94\x9d\x40\x93\xeb\xe9\x6f\xd7\xe3\x23\x81\x9a\x8a\xe9\x97\x8e\x52\xb1\xdc\x4a\x4d\x9e\x51\x57\x9b\x6e\xfa\x69\x33\x16\x02\x39\xc7\xab\xae\xa7\x33\x54\xd5\x12\xcf\x72\x1e\x95\x4b\xd2\x38\xac\xe9\x6d\x45\x66\x74\x33\xb9\xfb\x72\xba\x71\x0d\x50\x39\x5b\x91\x63\xd9\x64\x67\x3e\xad\x26\xd0\xba\x05\x10\xe4\x4b\x27\x2b\x4e\xdd\xe1\xef\x62\xe3\x0d\x20\x02\x64\x2e\x10\xb1\x1b\x90\x07\x5e\x52\x93\x95\x24\x6a\x9d\xc0\xce\x81\x97\xd2\x83\xa3\xca\x91\x27\x93\xfb\x43\xbc\x46\x03\x76\xf6\x96\x4a\xee\x6f\x89\x9e\x92\x8b\x62\x62\x31\x05\x25\xa0\xb4\x66\x45\x8e\xc1\x51\x69\x17\x46\xfe\xf5\x28\xdb\x03\xdb\x04\xaa\x90\xc9\x33\xa4\xbc\x37\xa8\x60\x85\x2a\xd0\x4b\x40\x23\xb6\x24\x6b\x7c\x00\x47\x11\x13\x82\x69\xc9\x4b\x0c\x7e\x5b\x8f\x2b\xeb\x08\xa4\x99\xdb\x21\x2c\x99\x2b\x3f\x1c\x0c\x16\x92\x9b\xd6\x58\x5a\xad\x83\x91\xfc\x30\x28\xad\x61\x27\x67\x81\xad\xf3\x03\x41\x2b\x52\x03\x2f\x17\x05\xba\x72\x29\x99\x4a\x0e\x8e\x06\x58\xc9\x22\x19\x62\x52\x6e\xf5\xb5\xf8\xcc\xd5\xcd\xd4\x6f\xc0\xee\xe4\x4e\x3e\xa9\xab\x7d\x42\x78\x62\x6f\x03\xe9\x01\x6b\x51\xd9\xc4\x75\x14\xe2\x55\x74\xdd\xeb\x57\xd3\x5b\x68\x34\xc9\x91\xca\x41\x59\x93\xee\xf8\xa5\x89\x4f\xf4\xa6\x34\x73\x72\x99\x6f\xee\xac\x4e\x32\xc9\x88\xca\x4a\xc3\xe9\xa3\x54\x92\x0c\x83\x0f\x33\x2d\x39\xa6\xc1\x9f\x81\x3c\xc7\xd0\x6d\x8b\x1d\xa7\xf1\x01\x33\x82\x50\xc5\x64\x17\xdb\x04\x13\x03\x63\xd4\xa4\xc6\xe8\xe9\x7f\x8e\x55\x8c\x8a\x2f\x62\x10\x8e\x8a\x56\x7b\x28\x6e\x13\x67\xf7\xb6\x1e\x9a\x21\xb7\x3e\xfb\xeb\x34\x1e\xd3\x1e\x4f\x3b\xaf\x00\x92\x49\xef\xb9\x3e\x24\xb2\x66\xac\x46\x42\x38\xf2\x1d\xcf\x00\x73\xeb\x34\xf2\x10\x64\xb5\xfa\xaa\x83\xa4\xc3\x19\xeb\xa3\xb1\x7c\x02\x45\xe3\xfb\x4b\x32\x8b\xd8\x27\xcf\xbe\x39\x15\xa6\x76\x52\x1c\x70\x47\xe0\x7c\x7d\xa2\x39\x31\x93\xa5\x23\xb1\x0f\xa2\x68\x99\xba\xf7\xb9\xa5\xe2\x9e\xf7\x8e\x44\x79\x54\x7d\x92\xa2\x0c\xbb\x8a\x67\x46\x74\x0e\x1f\xb6\xde\x2a\x0c\x7e\x9f\xae\x99\x63\x66\xad\x22\x34\x5b\xaf\x79\x47\xd8\xe5\x39\xec\xbc\x83\x6e\x7b\x5f\xdc\x87\x19\x39\x43\x4c\xbe\x58\xa1\x92\xa2\xbd\x2e\x6e\xfa\x48\x93\xf7\xb8\xc8\x8b\x09\x6a\x8a\xdd\x4c\x6a\x1d\x38\x4e\xfc\x7d\xf1\x08\x2a\xe2\x92\x9a\xc3\xf9\x39\x58\x25\xa6\xa4\xe6\x1b\x74\xfb\x23\x56\xc0\xc6\x2e\xd4\x36\x62\xb7\x54\xd3\xe8\x3f\xb6\x58\xd7\xab\xc4\x33\x16\xaa\x42\xcf\xb7\x0e\x8d\x97\xcd\xea\xd0\x95\xe3\x1b\x03\xe2\x12\x3d\x03\x4b\x4d\xb9\x29\x37\x9a\x01\x3f\x8a\x22\x91\x3b\xb8\x35\x04\x1b\x2b\xce\xee\x61\x0b\x68\x2c\x2f\xc9\x6d\xb7\xe1\x47\x8a\xa7\x6a\x34\x9a\xf1\x26\xb5\xf9\xa3\x4d\xb8\x4d\x93\x7e\x6d\x86\xf4\x2d\x3b\xde\xa1\xef\x1a\x1b\x47\xeb\xd4\x24\xdc\x31\xca\xfc\x1c\x34\x9a\xc2\x11\x8a\x98\x8e\x0d\x2b\x48\x23\x64\x89\x69\xba\x0a\x62\x94\xca\x03\xce\x6c\xd8\xad\xe2\xb6\x1f\x5a\x41\x38\x55\x75\x47\xe8\xb7\xf7\xb7\x0e\xcd\xa3\x1b\x33\x79\xec\xe9\x9b\xe9\xf0\xc2\x6f\x2b\x74\xb2\x33\xf7\x95\x4a\x87\x46\xd3\x44\x9a\x37\xc5\x96\x32\x2f\x53\x2a\xda\x39\xdc\xba\xb8\xcd\xfd\x88\xca\xd3\x4b\x78\x63\xee\x8d\x7d\x77\xba\x5e\x89\xe0\x28\x3f\x3d\x54\x09\xbd\x54\x21\xfe\x6a\xae\xf5\x3a\x11\xfa\xf0\xbc\xe8\xac\xb8\x22\xc9\xfd\xd4\x21\xd1\x3d\x08\xfe\xb3\x0d\x02\x9b\xdf\xbe\xc9\xcd\x13\x43\xfe\x19\xf6\x84\xe7\xd8\x01\x8e\x4a\xe1\x53\xb9\x4f\x8a\xce\x5e\xa6\x9d\x4b\x1f\xd7\x6f\x31\x04\x76\x21\xe7\x85\x67\xeb\xd2\xa0\x5c\xdf\x84\xd9\xe3\xdf\x45\x63\x40\x5d\x90\xf0\xe1\x63\xef\x9f\x00\x00\x00\xff\xff\xaa\xdd\x1c\xed\xfb\x11\x00\x00")
+var _chartCrdsNetworkHarvesterhciIo_virtualmachinenetworkconfigsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x58\xff\x6f\xdb\xba\x11\xff\x5d\x7f\xc5\x01\x1b\xd0\x76\xa8\x9c\xa5\xed\xb2\x4e\x40\xb0\x79\x4e\xbb\x1a\x4b\xd2\xa0\x4e\x03\x0c\x45\x07\x9c\xc5\xb3\xcd\x46\x24\x35\x7e\x71\x92\xf5\xf5\x7f\x7f\x20\x69\xc5\xb2\x23\xc9\xaa\xf3\xda\x27\xea\x17\x91\xc7\xfb\xdc\xf1\x8e\xf7\x45\x69\x9a\x26\x58\xf2\x2b\xd2\x86\x2b\x99\x01\x96\x9c\x6e\x2d\x49\xff\x65\x06\xd7\xaf\xcd\x80\xab\x83\xe5\x61\x72\xcd\x25\xcb\x60\xe4\x8c\x55\xe2\x03\x19\xe5\x74\x4e\x27\x34\xe3\x92\x5b\xae\x64\x22\xc8\x22\x43\x8b\x59\x02\x80\x52\x2a\x8b\x7e\xda\xf8\x4f\x80\xaf\xdf\x12\x00\x89\x82\x32\x58\x72\x6d\x1d\x16\x02\xf3\x05\x97\x24\xc9\xde\x28\x7d\x9d\x2b\x39\xe3\x73\x33\x58\x7d\x0e\x16\xa8\x97\x64\x2c\xe9\x45\xce\x07\x5c\x25\xa6\xa4\xdc\x73\x9a\x6b\xe5\xca\x0c\xda\xc8\x22\xc6\x0a\x33\xca\x7b\x15\xe1\xce\x22\xdc\x79\xdc\x38\x0a\x70\x81\xaa\xe0\xc6\xfe\x7b\x17\xe5\x29\x37\x36\x50\x97\x85\xd3\x58\x74\x2b\x11\x08\xcd\x42\x69\x7b\xbe\x16\x26\x85\xa5\x90\x64\xf3\xd9\x7c\xeb\x73\x45\xce\xe5\xdc\x15\xa8\x3b\x39\x27\x00\x26\x57\x25\x65\x10\x18\x97\x98\x13\x4b\x00\x96\xd1\x70\x41\xeb\x14\x90\xb1\x60\x0f\x2c\x2e\x34\x97\x96\xf4\x48\x15\x4e\x54\x76\x48\xe1\x8b\x51\xf2\x02\xed\x22\x83\x81\x3f\xd4\xc1\x52\x78\x66\x41\x88\xca\x42\x57\x67\xe7\xc3\xb3\x37\xab\x29\x7b\xe7\x01\x8d\xd5\x5c\xce\x1b\x58\x58\xb4\xce\x0c\x72\x25\x23\xaa\xf9\xf4\xf7\xa7\xff\x18\xf8\x3d\xc7\xc7\x4f\x86\x45\xa1\x72\xb4\xc4\x9e\x3c\xfb\xbc\xa2\xdc\xc0\x19\x9e\x9e\xbe\x1f\x0d\x2f\xdf\x9c\x3c\x1e\xea\x84\x1b\x9c\x16\xad\x48\x27\xe3\xc9\xf0\x9f\xa7\xbf\x05\xd0\x58\x4e\xee\x64\xde\x0a\x34\x3e\x9f\xfc\xe7\x7c\xd4\x13\xa8\xba\x31\x83\x5c\x53\xb8\x2c\x97\x5c\x90\xb1\x28\xca\x0d\x9e\xc3\x7f\x6d\xda\x82\xa1\xa5\x64\xbd\xbc\x3c\xc4\xa2\x5c\xe0\x61\x98\x32\xf9\x82\x44\xb8\x82\xfe\x4b\x95\x24\x87\x17\xe3\xab\x97\x93\x8d\x69\x80\x52\xab\x92\xb4\xe5\x95\x77\xc6\x51\x0b\x02\xb5\x59\x00\x46\x26\xd7\xbc\xf4\x12\x66\xf0\x4b\xba\xb1\x06\xe0\x01\xe2\x2e\x60\x3e\x1a\x90\x01\xbb\xa0\xca\x2b\x89\xad\x64\x02\x35\x03\xbb\xe0\x06\x34\x95\x9a\x0c\xc9\x18\x1f\xfc\x34\x4a\x50\xd3\x2f\x94\xdb\xc1\x16\xeb\x09\x69\xcf\x06\xcc\x42\xb9\x82\x41\xae\xe4\x92\xb4\x05\x4d\xb9\x9a\x4b\xfe\xff\x7b\xde\x06\xac\x0a\xa0\x05\x5a\x32\x16\x82\xdf\x4b\x2c\x60\x89\x85\xa3\xe7\x80\x92\x6d\x71\x16\x78\x07\x9a\x3c\x26\x38\x59\xe3\x17\x36\x98\x6d\x39\xce\x94\x26\xe0\x72\xa6\x32\x58\x58\x5b\x9a\xec\xe0\x60\xce\x6d\x15\x1a\x73\x25\x84\x93\xdc\xde\x1d\xe4\x4a\x5a\xcd\xa7\xce\x2a\x6d\x0e\x18\x2d\xa9\x38\x30\x7c\x9e\xa2\xce\x17\xdc\x52\x6e\x9d\xa6\x03\x2c\x79\x1a\x14\x91\x5e\x7d\x33\x10\xec\x0f\x7a\x15\x4c\x2b\x57\x6a\xf1\x9d\xf8\x86\xa8\xf6\x1d\xe6\xf1\xb1\x0d\xb8\x01\x5c\xb1\x8a\x67\xb2\xb6\x82\x9f\xf2\x47\xf7\xe1\xcd\xe4\x12\x2a\x49\xa2\xa5\xa2\x51\xd6\xa4\xa6\xcd\x3e\xfe\x34\xb9\x9c\x91\x8e\xfb\x66\x5a\x89\x60\x0e\x92\xac\x54\x5c\xda\xf0\x91\x17\x9c\xa4\x05\xe3\xa6\x82\x5b\xef\x06\xff\x73\x64\xac\x37\xdd\x36\xdb\x51\x48\x1f\x30\x25\x70\xa5\x77\x76\xb6\x4d\x30\x96\x30\x42\x41\xc5\x08\x0d\xfd\x64\x5b\x79\xab\x98\xd4\x1b\xa1\x97\xb5\xea\x49\x71\xfd\x44\xe2\x78\xbc\xb5\x85\x2a\xc9\x01\x74\xdf\x53\x3f\x16\xca\xd8\x10\xad\xb7\xe6\xbd\x6b\xdf\x9e\x92\x9c\xfb\x08\x73\xf4\xf2\xc1\x6a\x8b\x9c\xfe\x5d\xe5\x9a\x98\xf1\x1e\x00\x02\x70\x4b\xa2\x61\xba\x4b\xca\x38\xa2\xe5\xc7\x27\xcd\xab\x00\x25\x5a\x7f\x5f\x33\xf8\xef\xd3\x4f\x7f\x4e\xff\x86\xe9\x6c\x98\xbe\xfd\xfc\xf5\xc5\xb7\xec\xd9\x9f\x36\x27\xfe\xd8\xc2\xa1\x43\xab\x95\x08\xa1\x6a\x79\x5f\xd6\x4a\x12\x80\xde\x0a\xf6\x53\x73\x85\xa4\x58\x83\x51\xea\x43\xe0\x2d\x17\x4e\x64\xf0\xe2\x2f\xaf\xba\x09\xb9\x8c\x84\x87\x9d\x64\x51\x79\x1f\xf3\xe6\xa4\x3b\x28\x03\x5d\x27\x27\x92\x4e\x74\xcb\x9e\x02\x2f\x93\x86\xf9\xfb\xe1\x09\xd2\xa2\x2a\x97\xda\x46\xda\x65\xab\xea\x49\xc1\x71\x69\x5f\xf7\xa0\x39\x3c\xea\x41\xf4\xf2\xc5\x0e\xa2\x05\xdd\x76\x52\xec\x74\xb2\xf8\x86\xf8\x9a\x3d\x9e\x93\x0f\x90\x5c\xd3\x56\xb0\xaf\x8f\x14\x72\xc5\x28\x69\x58\x59\x2d\x7b\x9c\x8e\xe5\x20\x69\xeb\x7a\x4b\x90\xaa\x8f\x48\x82\x5a\xe3\x5d\x23\x05\x73\x9c\xfd\x8e\x97\x9e\x63\x3b\xfc\x4c\x69\x81\x36\x83\x2e\xc7\xd8\x7d\xb1\x78\x39\x64\x4c\x93\x31\x3b\x61\xca\xe5\xab\xbd\xd5\x28\x97\x47\xfd\x61\x8e\xf6\x85\x11\x98\xef\x40\xa9\x65\x96\xc3\xbf\xee\x0b\xb3\xca\x32\xe7\x8d\xb9\xeb\x01\xce\xd1\x9e\xa7\xd6\x75\x79\xd2\x9a\xaa\x8d\xcb\x35\x11\x93\xef\xbc\x17\x02\x6f\xc7\x21\x4d\xc2\xab\xa4\xff\x6d\x29\xd1\x99\x26\x59\xe3\x8e\xa9\x52\x05\xa1\xdc\x5a\x8d\x7d\x5b\x96\x7c\xdf\xe1\x75\x1e\xdb\x6d\x7a\xed\xa6\xa4\x25\x59\x32\xe9\x12\x0b\xce\xea\x2d\x7c\xfd\x49\x41\x90\x31\x38\x8f\xcd\x22\x0a\xf2\x15\x26\x17\xc2\x59\xdf\x85\x3d\x20\x07\xd0\xae\xf0\x6e\x41\xc5\x0c\x8e\x8f\x41\x15\x6c\x42\xc5\x2c\xd9\x6d\xb1\x14\x36\xfa\xd3\x4e\x0b\xc4\x76\x2c\x4b\xfa\xe5\xec\x75\x7b\x97\x25\xbd\x0b\x81\x5d\x25\x40\x81\xc6\x5e\x6a\x94\x26\x70\xf6\xed\x5c\x33\xdd\x56\xd1\x7e\x8a\xc6\x82\xe5\x82\x62\xa1\x5c\x49\x06\xf6\x9e\x15\xb1\x58\x55\x2b\x49\xb0\xd1\x76\x3e\x1c\x56\x01\x4a\x65\x17\xa4\x07\x49\x23\x41\xb7\x13\x54\x6a\x7c\x0c\xa5\x77\x6f\x15\x2e\x43\xf7\xb5\x56\x83\x9b\x9a\x1e\x37\x68\xda\x4a\xf9\xde\x32\x55\x0e\xd7\x47\x98\x77\x4e\xa0\x4c\x35\x21\xf3\xee\x58\xf9\x2a\x70\xc9\x78\x8e\xa1\xe3\x61\x64\x91\x17\x06\x70\xaa\xdc\xc3\x5b\x5c\x3d\xfe\x1c\x6a\x46\xd8\x57\x74\x4d\x68\xb6\x7b\xea\x16\xc9\xfd\x31\x46\x72\x1f\xd3\x37\xdd\xe1\x89\xd9\x16\x68\xef\xc3\x6c\xba\x2a\x2d\x12\x4d\x02\xa9\x6f\xd3\x37\x84\x79\x1e\x5c\x51\xcd\xe0\x52\xfb\x0e\xfb\x2d\x16\x86\x9e\xc3\x47\x79\x2d\xd5\xcd\xfe\x72\x75\x95\xa8\x9b\xe7\x74\x57\x06\xf4\xbc\x70\xfe\x2f\xe1\x5a\xae\xc1\x8f\xc8\x17\xad\x37\xae\xb5\xc4\xea\x4c\x12\xed\x89\xe0\x87\xb5\x60\x58\xfd\x8a\x1b\x5f\xec\x48\xf2\x3b\x6d\x54\x63\xb5\x3c\x7a\x2c\xb3\xa9\x72\x92\x75\x05\x99\xaa\xb2\xf1\xc1\x28\xf5\x21\x72\x5f\xa4\xd8\x84\xbe\x6b\x6d\x9b\x7b\xb2\x61\xd2\x7c\xa0\x5c\xe9\x46\x4f\xe9\x63\x89\x5e\x65\x63\x2f\x51\xee\x7f\x01\x3e\x8e\x49\x97\xeb\x57\x1d\xdd\x4a\xdc\x56\x0a\xd9\x5c\x2a\xed\xbc\x09\xfe\xa5\xdb\x92\xeb\xbb\x9f\xe2\x03\x05\xa1\x21\x1f\xd0\x28\xdb\x97\xc5\xee\x2a\x79\x27\x8b\x5a\x79\xb9\x37\x0f\x4d\x92\x6e\x7e\xca\x99\x99\x47\x1c\xd7\x5e\x51\xb0\x71\xd3\x83\x49\xe3\x7f\x3d\xb2\x0c\xac\x5e\xb5\xb0\xc6\x2a\xed\xeb\x83\xda\x8c\x9b\xde\xff\x59\xad\x14\x30\x16\xad\x33\x19\x7c\xfd\x96\xfc\x3a\x00\xc4\x9a\x68\xb4\xf7\x1a\x00\x00")
 
 func chartCrdsNetworkHarvesterhciIo_virtualmachinenetworkconfigsYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -114,7 +114,7 @@ func chartCrdsNetworkHarvesterhciIo_virtualmachinenetworkconfigsYaml() (*asset,
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "chart/crds/network.harvesterhci.io_virtualmachinenetworkconfigs.yaml", size: 4603, mode: os.FileMode(420), modTime: time.Unix(1769152639, 0)}
+	info := bindataFileInfo{name: "chart/crds/network.harvesterhci.io_virtualmachinenetworkconfigs.yaml", size: 6903, mode: os.FileMode(420), modTime: time.Unix(1792200385, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -0,0 +1,234 @@
+package ddns
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns/internal/wire"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	defaultTTL     = 300
+)
+
+const (
+	AlgorithmHMACSHA1   = wire.AlgorithmHMACSHA1
+	AlgorithmHMACSHA256 = wire.AlgorithmHMACSHA256
+	AlgorithmHMACSHA512 = wire.AlgorithmHMACSHA512
+)
+
+// Key is a TSIG key (RFC 8945) shared with the DNS server.
+type Key = wire.Key
+
+// NewKey returns the TSIG key with the given name, algorithm and base64
+// encoded secret, as found in the key clause of BIND or the output of
+// tsig-keygen. The algorithm defaults to hmac-sha256.
+func NewKey(name, algorithm, secret string) (*Key, error) {
+	return wire.NewKey(name, algorithm, secret)
+}
+
+// Record is the A record of a VM in the zone, along with its PTR record in
+// the reverse zone if one is set. The name is fully qualified.
+type Record struct {
+	Zone        string
+	ReverseZone string
+	Name        string
+	IP          net.IP
+	TTL         uint32
+}
+
+// Client sends dynamic updates (RFC 2136) to a primary DNS server, signed with
+// TSIG if a key is set.
+type Client struct {
+	Server  string
+	Key     *Key
+	Timeout time.Duration
+}
+
+// NewClient returns a client of the DNS server at the given address, with
+// port 53 if none is given.
+func NewClient(server string, key *Key) *Client {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &Client{
+		Server:  server,
+		Key:     key,
+		Timeout: defaultTimeout,
+	}
+}
+
+// Register replaces the A record of the name in the zone with the address of
+// the record, and likewise for the PTR record in the reverse zone.
+func (c *Client) Register(ctx context.Context, r Record) error {
+	name := wire.Fqdn(r.Name)
+	ttl := r.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	ip := r.IP.To4()
+	if ip == nil {
+		return fmt.Errorf("%s is not an ipv4 address", r.IP)
+	}
+
+	m := wire.NewUpdate(newID(), r.Zone)
+	m.Update = []wire.RR{
+		{Name: name, Type: wire.TypeA, Class: wire.ClassAny},
+		{Name: name, Type: wire.TypeA, Class: wire.ClassIN, TTL: ttl, Data: ip},
+	}
+	if err := c.exchange(ctx, m); err != nil {
+		return err
+	}
+
+	if r.ReverseZone == "" {
+		return nil
+	}
+	ptr, err := wire.AppendName(nil, name)
+	if err != nil {
+		return err
+	}
+	reverse := ReverseName(ip)
+	m = wire.NewUpdate(newID(), r.ReverseZone)
+	m.Update = []wire.RR{
+		{Name: reverse, Type: wire.TypePTR, Class: wire.ClassAny},
+		{Name: reverse, Type: wire.TypePTR, Class: wire.ClassIN, TTL: ttl, Data: ptr},
+	}
+	return c.exchange(ctx, m)
+}
+
+// Unregister deletes the A record and the PTR record of the record. Records
+// of the name pointing to other addresses are left untouched.
+func (c *Client) Unregister(ctx context.Context, r Record) error {
+	name := wire.Fqdn(r.Name)
+
+	ip := r.IP.To4()
+	if ip == nil {
+		return fmt.Errorf("%s is not an ipv4 address", r.IP)
+	}
+
+	m := wire.NewUpdate(newID(), r.Zone)
+	m.Update = []wire.RR{{Name: name, Type: wire.TypeA, Class: wire.ClassNone, Data: ip}}
+	if err := c.exchange(ctx, m); err != nil {
+		return err
+	}
+
+	if r.ReverseZone == "" {
+		return nil
+	}
+	ptr, err := wire.AppendName(nil, name)
+	if err != nil {
+		return err
+	}
+	m = wire.NewUpdate(newID(), r.ReverseZone)
+	m.Update = []wire.RR{{Name: ReverseName(ip), Type: wire.TypePTR, Class: wire.ClassNone, Data: ptr}}
+	return c.exchange(ctx, m)
+}
+
+func (c *Client) exchange(ctx context.Context, m *wire.Message) error {
+	zone := m.Zone[0].Name
+
+	var (
+		b          []byte
+		requestMAC []byte
+		err        error
+	)
+	if c.Key != nil {
+		b, requestMAC, err = c.Key.Sign(m, nil, time.Now())
+	} else {
+		b, err = m.Pack()
+	}
+	if err != nil {
+		return err
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", c.Server)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.Write(b); err != nil {
+		return fmt.Errorf("dns update of zone %s failed: %w", zone, err)
+	}
+
+	buf := make([]byte, wire.MaxMessageLen)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return fmt.Errorf("dns update of zone %s failed: %w", zone, err)
+		}
+		resp, _, err := wire.Unpack(buf[:n])
+		if err != nil || resp.ID != m.ID || resp.Flags&0x8000 == 0 {
+			// Not the response to our update, keep waiting
+			continue
+		}
+		if resp.Rcode() != 0 {
+			return fmt.Errorf("dns update of zone %s failed: %s", zone, wire.RcodeName(resp.Rcode()))
+		}
+		if c.Key != nil {
+			if _, err := c.Key.Verify(buf[:n], requestMAC, time.Now()); err != nil {
+				return fmt.Errorf("dns update of zone %s failed: %w", zone, err)
+			}
+		}
+		return nil
+	}
+}
+
+// ReverseName returns the name of the PTR record of the IPv4 address.
+func ReverseName(ip net.IP) string {
+	ip = ip.To4()
+	if ip == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", ip[3], ip[2], ip[1], ip[0])
+}
+
+// ReverseZone returns the in-addr.arpa zone of the network, which is only
+// defined for prefixes on an octet boundary.
+func ReverseZone(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits != 32 {
+		return "", fmt.Errorf("%s is not an ipv4 network", cidr)
+	}
+	if ones%8 != 0 || ones == 0 {
+		return "", fmt.Errorf("the prefix length of %s is not a multiple of 8", cidr)
+	}
+
+	labels := make([]string, 0, ones/8+2)
+	for i := ones/8 - 1; i >= 0; i-- {
+		labels = append(labels, strconv.Itoa(int(ipNet.IP.To4()[i])))
+	}
+	labels = append(labels, "in-addr", "arpa")
+	return strings.Join(labels, ".") + ".", nil
+}
+
+func newID() uint16 {
+	var b [2]byte
+	_, _ = rand.Read(b[:])
+	return binary.BigEndian.Uint16(b[:])
+}
@@ -0,0 +1,130 @@
+package ddns_test
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns"
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns/ddnstest"
+)
+
+const testSecret = "c2VjcmV0LWtleS1mb3ItdGVzdGluZy1kZG5zLXVwZGF0ZXM="
+
+func newTestServer(t *testing.T, key *ddns.Key) *ddnstest.Server {
+	s, err := ddnstest.NewServer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestRegister(t *testing.T) {
+	key, err := ddns.NewKey("ddns-key", "", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, key)
+	c := ddns.NewClient(s.Addr(), key)
+	ctx := context.Background()
+
+	r := ddns.Record{
+		Zone:        "example.com",
+		ReverseZone: "0.168.192.in-addr.arpa",
+		Name:        "vm-1.example.com",
+		IP:          net.ParseIP("192.168.0.10"),
+	}
+	if err := c.Register(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.A("vm-1.example.com"), []string{"192.168.0.10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got a records %v, wanted %v", got, want)
+	}
+	if got, want := s.PTR("10.0.168.192.in-addr.arpa"), []string{"vm-1.example.com."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ptr records %v, wanted %v", got, want)
+	}
+
+	// Registering a new address replaces the A record
+	old := r
+	r.IP = net.ParseIP("192.168.0.20")
+	if err := c.Register(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unregister(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.A("vm-1.example.com"), []string{"192.168.0.20"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got a records %v, wanted %v", got, want)
+	}
+	if got := s.PTR("10.0.168.192.in-addr.arpa"); len(got) != 0 {
+		t.Errorf("got ptr records %v of old address, wanted none", got)
+	}
+	if got, want := s.PTR("20.0.168.192.in-addr.arpa"), []string{"vm-1.example.com."}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ptr records %v, wanted %v", got, want)
+	}
+
+	if err := c.Unregister(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.A("vm-1.example.com"); len(got) != 0 {
+		t.Errorf("got a records %v after unregistering, wanted none", got)
+	}
+	if got := s.PTR("20.0.168.192.in-addr.arpa"); len(got) != 0 {
+		t.Errorf("got ptr records %v after unregistering, wanted none", got)
+	}
+}
+
+func TestRegisterUnauthorized(t *testing.T) {
+	key, err := ddns.NewKey("ddns-key", ddns.AlgorithmHMACSHA512, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKey, err := ddns.NewKey("ddns-key", ddns.AlgorithmHMACSHA512, "d3Jvbmctc2VjcmV0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, key)
+
+	r := ddns.Record{
+		Zone: "example.com",
+		Name: "vm-1.example.com",
+		IP:   net.ParseIP("192.168.0.10"),
+	}
+	for name, c := range map[string]*ddns.Client{
+		"unsigned":  ddns.NewClient(s.Addr(), nil),
+		"wrong key": ddns.NewClient(s.Addr(), wrongKey),
+	} {
+		if err := c.Register(context.Background(), r); err == nil {
+			t.Errorf("%s: got no error, wanted NOTAUTH", name)
+		}
+	}
+	if got := s.A("vm-1.example.com"); len(got) != 0 {
+		t.Errorf("got a records %v, wanted none", got)
+	}
+}
+
+func TestReverseZone(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		want    string
+		wantErr bool
+	}{
+		{cidr: "192.168.0.0/24", want: "0.168.192.in-addr.arpa."},
+		{cidr: "10.0.0.0/8", want: "10.in-addr.arpa."},
+		{cidr: "172.16.0.0/16", want: "16.172.in-addr.arpa."},
+		{cidr: "192.168.0.0/28", wantErr: true},
+		{cidr: "fd00::/64", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ddns.ReverseZone(tc.cidr)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, wanted error %t", tc.cidr, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, wanted %q", tc.cidr, got, tc.want)
+		}
+	}
+}
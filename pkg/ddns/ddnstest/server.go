@@ -0,0 +1,200 @@
+// Package ddnstest provides a DNS server applying dynamic updates, to test
+// the ddns client and its users.
+package ddnstest
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns"
+	"github.com/harvester/vm-dhcp-controller/pkg/ddns/internal/wire"
+)
+
+const (
+	rcodeFormErr  uint16 = 1
+	rcodeNotImp   uint16 = 4
+	rcodeNotAuth  uint16 = 9
+	rcodeNotZone  uint16 = 10
+	typeAny       uint16 = 255
+	flagsResponse uint16 = 0x8000
+)
+
+// Server is a minimal DNS server that only applies dynamic updates. Updates
+// are authenticated with the TSIG key if one is set.
+type Server struct {
+	Key *ddns.Key
+
+	conn    net.PacketConn
+	mutex   sync.Mutex
+	records map[string]map[uint16][]string
+	wg      sync.WaitGroup
+}
+
+// NewServer starts a server listening on a random port of the loopback
+// interface.
+func NewServer(key *ddns.Key) (*Server, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Key:     key,
+		conn:    conn,
+		records: make(map[string]map[uint16][]string),
+	}
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *Server) Close() error {
+	err := s.conn.Close()
+	s.wg.Wait()
+	return err
+}
+
+// A returns the addresses of the A records of the name.
+func (s *Server) A(name string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var ips []string
+	for _, data := range s.records[wire.Fqdn(name)][wire.TypeA] {
+		ips = append(ips, net.IP(data).String())
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// PTR returns the names the PTR records of the name point to.
+func (s *Server) PTR(name string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var names []string
+	for _, data := range s.records[wire.Fqdn(name)][wire.TypePTR] {
+		if ptr, _, err := wire.ReadName([]byte(data), 0); err == nil {
+			names = append(names, ptr)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	buf := make([]byte, wire.MaxMessageLen)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.handle(buf[:n]); resp != nil {
+			_, _ = s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *Server) handle(b []byte) []byte {
+	m, tsigStart, err := wire.Unpack(b)
+	if err != nil {
+		return nil
+	}
+
+	resp := &wire.Message{
+		ID:    m.ID,
+		Flags: flagsResponse | m.Opcode()<<11,
+		Zone:  m.Zone,
+	}
+	reply := func(rcode uint16, requestMAC []byte) []byte {
+		resp.Flags |= rcode
+		if s.Key != nil && requestMAC != nil {
+			b, _, err := s.Key.Sign(resp, requestMAC, time.Now())
+			if err != nil {
+				return nil
+			}
+			return b
+		}
+		b, _ := resp.Pack()
+		return b
+	}
+
+	var requestMAC []byte
+	if s.Key != nil {
+		if tsigStart < 0 {
+			return reply(rcodeNotAuth, nil)
+		}
+		if requestMAC, err = s.Key.Verify(b, nil, time.Now()); err != nil {
+			return reply(rcodeNotAuth, nil)
+		}
+	}
+
+	if m.Opcode() != wire.OpcodeUpdate {
+		return reply(rcodeNotImp, requestMAC)
+	}
+	if len(m.Zone) != 1 || m.Zone[0].Type != wire.TypeSOA {
+		return reply(rcodeFormErr, requestMAC)
+	}
+
+	zone := m.Zone[0].Name
+	for _, r := range m.Update {
+		if r.Name != zone && !strings.HasSuffix(r.Name, "."+zone) {
+			return reply(rcodeNotZone, requestMAC)
+		}
+	}
+
+	s.mutex.Lock()
+	for _, r := range m.Update {
+		s.apply(r)
+	}
+	s.mutex.Unlock()
+
+	return reply(0, requestMAC)
+}
+
+// apply applies an update record as per RFC 2136 section 3.4.2.
+func (s *Server) apply(r wire.RR) {
+	rrsets := s.records[r.Name]
+	switch r.Class {
+	case wire.ClassIN:
+		if rrsets == nil {
+			rrsets = make(map[uint16][]string)
+			s.records[r.Name] = rrsets
+		}
+		for _, data := range rrsets[r.Type] {
+			if data == string(r.Data) {
+				return
+			}
+		}
+		rrsets[r.Type] = append(rrsets[r.Type], string(r.Data))
+	case wire.ClassAny:
+		if r.Type == typeAny {
+			delete(s.records, r.Name)
+			return
+		}
+		delete(rrsets, r.Type)
+	case wire.ClassNone:
+		var kept []string
+		for _, data := range rrsets[r.Type] {
+			if data != string(r.Data) {
+				kept = append(kept, data)
+			}
+		}
+		if len(kept) == 0 {
+			delete(rrsets, r.Type)
+			return
+		}
+		rrsets[r.Type] = kept
+	}
+}
@@ -0,0 +1,269 @@
+// Package wire encodes and decodes the DNS messages of dynamic updates, and
+// signs them with TSIG. It is shared by the ddns client and its test server.
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	TypeA    uint16 = 1
+	TypeSOA  uint16 = 6
+	TypePTR  uint16 = 12
+	TypeTSIG uint16 = 250
+
+	ClassIN   uint16 = 1
+	ClassNone uint16 = 254
+	ClassAny  uint16 = 255
+
+	OpcodeUpdate uint16 = 5
+
+	MaxMessageLen = 65535
+
+	headerLen = 12
+)
+
+var errShortMessage = errors.New("dns message is too short")
+
+var rcodeNames = map[uint16]string{
+	0:  "NOERROR",
+	1:  "FORMERR",
+	2:  "SERVFAIL",
+	3:  "NXDOMAIN",
+	4:  "NOTIMP",
+	5:  "REFUSED",
+	6:  "YXDOMAIN",
+	7:  "YXRRSET",
+	8:  "NXRRSET",
+	9:  "NOTAUTH",
+	10: "NOTZONE",
+}
+
+// RcodeName returns the mnemonic of the response code, e.g. NOTAUTH.
+func RcodeName(rcode uint16) string {
+	if name, ok := rcodeNames[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// RR is a resource record. The zone section of an UPDATE holds records with
+// neither TTL nor data.
+type RR struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte
+}
+
+// Message is a DNS message laid out as an UPDATE (RFC 2136 section 2), i.e.
+// with zone, prerequisite, update and additional sections. The sections of a
+// query are the same on the wire.
+type Message struct {
+	ID         uint16
+	Flags      uint16
+	Zone       []RR
+	Prereq     []RR
+	Update     []RR
+	Additional []RR
+}
+
+// NewUpdate returns an empty UPDATE of the zone.
+func NewUpdate(id uint16, zone string) *Message {
+	return &Message{
+		ID:    id,
+		Flags: OpcodeUpdate << 11,
+		Zone:  []RR{{Name: zone, Type: TypeSOA, Class: ClassIN}},
+	}
+}
+
+func (m *Message) Opcode() uint16 {
+	return m.Flags >> 11 & 0xf
+}
+
+func (m *Message) Rcode() uint16 {
+	return m.Flags & 0xf
+}
+
+// Pack returns the message in wire format.
+func (m *Message) Pack() ([]byte, error) {
+	b := make([]byte, headerLen, 512)
+	binary.BigEndian.PutUint16(b[0:], m.ID)
+	binary.BigEndian.PutUint16(b[2:], m.Flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.Zone)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.Prereq)))
+	binary.BigEndian.PutUint16(b[8:], uint16(len(m.Update)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(m.Additional)))
+
+	var err error
+	for _, z := range m.Zone {
+		if b, err = AppendName(b, z.Name); err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint16(b, z.Type)
+		b = binary.BigEndian.AppendUint16(b, z.Class)
+	}
+	for _, section := range [][]RR{m.Prereq, m.Update, m.Additional} {
+		for _, r := range section {
+			if b, err = appendRR(b, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return b, nil
+}
+
+func appendRR(b []byte, r RR) ([]byte, error) {
+	b, err := AppendName(b, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	b = binary.BigEndian.AppendUint16(b, r.Type)
+	b = binary.BigEndian.AppendUint16(b, r.Class)
+	b = binary.BigEndian.AppendUint32(b, r.TTL)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(r.Data)))
+	return append(b, r.Data...), nil
+}
+
+// AppendName appends the domain name in wire format, uncompressed and in
+// lower case, which is also the canonical form used by TSIG.
+func AppendName(b []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if name == "" {
+		return append(b, 0), nil
+	}
+	if len(name) > 253 {
+		return nil, fmt.Errorf("domain name %s is too long", name)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("domain name %s has an invalid label", name)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0), nil
+}
+
+// Unpack parses a DNS message. It also returns the offset of the TSIG record
+// if it is the last one of the message, or -1.
+func Unpack(b []byte) (*Message, int, error) {
+	if len(b) < headerLen {
+		return nil, -1, errShortMessage
+	}
+
+	m := &Message{
+		ID:    binary.BigEndian.Uint16(b[0:]),
+		Flags: binary.BigEndian.Uint16(b[2:]),
+	}
+	counts := [4]int{
+		int(binary.BigEndian.Uint16(b[4:])),
+		int(binary.BigEndian.Uint16(b[6:])),
+		int(binary.BigEndian.Uint16(b[8:])),
+		int(binary.BigEndian.Uint16(b[10:])),
+	}
+
+	off := headerLen
+	for i := 0; i < counts[0]; i++ {
+		name, n, err := ReadName(b, off)
+		if err != nil {
+			return nil, -1, err
+		}
+		off = n
+		if off+4 > len(b) {
+			return nil, -1, errShortMessage
+		}
+		m.Zone = append(m.Zone, RR{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(b[off:]),
+			Class: binary.BigEndian.Uint16(b[off+2:]),
+		})
+		off += 4
+	}
+
+	tsigStart := -1
+	sections := []*[]RR{&m.Prereq, &m.Update, &m.Additional}
+	for i, section := range sections {
+		for j := 0; j < counts[i+1]; j++ {
+			start := off
+			r, n, err := readRR(b, off)
+			if err != nil {
+				return nil, -1, err
+			}
+			off = n
+			*section = append(*section, r)
+			if i == 2 && j == counts[3]-1 && r.Type == TypeTSIG {
+				tsigStart = start
+			}
+		}
+	}
+
+	return m, tsigStart, nil
+}
+
+func readRR(b []byte, off int) (RR, int, error) {
+	name, off, err := ReadName(b, off)
+	if err != nil {
+		return RR{}, 0, err
+	}
+	if off+10 > len(b) {
+		return RR{}, 0, errShortMessage
+	}
+	r := RR{
+		Name:  name,
+		Type:  binary.BigEndian.Uint16(b[off:]),
+		Class: binary.BigEndian.Uint16(b[off+2:]),
+		TTL:   binary.BigEndian.Uint32(b[off+4:]),
+	}
+	n := int(binary.BigEndian.Uint16(b[off+8:]))
+	off += 10
+	if off+n > len(b) {
+		return RR{}, 0, errShortMessage
+	}
+	r.Data = append([]byte(nil), b[off:off+n]...)
+	return r, off + n, nil
+}
+
+// ReadName reads the possibly compressed domain name at off, and returns it
+// with a trailing dot along with the offset following it.
+func ReadName(b []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for hops := 0; ; hops++ {
+		if off >= len(b) || hops > 127 {
+			return "", 0, errShortMessage
+		}
+		n := int(b[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.ToLower(strings.Join(labels, ".")) + ".", next, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(b) {
+				return "", 0, errShortMessage
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3fff)
+		default:
+			if off+1+n > len(b) {
+				return "", 0, errShortMessage
+			}
+			labels = append(labels, string(b[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+// Fqdn returns the name in lower case with a trailing dot.
+func Fqdn(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".") + "."
+}
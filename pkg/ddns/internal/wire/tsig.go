@@ -0,0 +1,248 @@
+package wire
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // hmac-sha1 is still a common TSIG algorithm
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+)
+
+// tsigFudge is the time error in seconds permitted in signed messages.
+const tsigFudge = 300
+
+const (
+	AlgorithmHMACSHA1   = "hmac-sha1"
+	AlgorithmHMACSHA256 = "hmac-sha256"
+	AlgorithmHMACSHA512 = "hmac-sha512"
+)
+
+var tsigAlgorithms = map[string]func() hash.Hash{
+	AlgorithmHMACSHA1:   sha1.New,
+	AlgorithmHMACSHA256: sha256.New,
+	AlgorithmHMACSHA512: sha512.New,
+}
+
+// Key is a TSIG key (RFC 8945) shared with the DNS server.
+type Key struct {
+	Name      string
+	Algorithm string
+	Secret    []byte
+}
+
+// NewKey returns the TSIG key with the given name, algorithm and base64
+// encoded secret, as found in the key clause of BIND or the output of
+// tsig-keygen. The algorithm defaults to hmac-sha256.
+func NewKey(name, algorithm, secret string) (*Key, error) {
+	if algorithm == "" {
+		algorithm = AlgorithmHMACSHA256
+	}
+	algorithm = strings.TrimSuffix(strings.ToLower(algorithm), ".")
+	if _, ok := tsigAlgorithms[algorithm]; !ok {
+		return nil, fmt.Errorf("tsig algorithm %s is not supported", algorithm)
+	}
+	if _, err := AppendName(nil, name); err != nil {
+		return nil, fmt.Errorf("tsig key name %s is not valid: %w", name, err)
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret))
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("tsig secret of key %s is not valid base64", name)
+	}
+	return &Key{Name: Fqdn(name), Algorithm: algorithm, Secret: b}, nil
+}
+
+type tsigRecord struct {
+	Algorithm  string
+	TimeSigned uint64
+	Fudge      uint16
+	MAC        []byte
+	OriginalID uint16
+	Error      uint16
+	Other      []byte
+}
+
+func (t *tsigRecord) pack() ([]byte, error) {
+	b, err := AppendName(nil, t.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	b = appendUint48(b, t.TimeSigned)
+	b = binary.BigEndian.AppendUint16(b, t.Fudge)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(t.MAC)))
+	b = append(b, t.MAC...)
+	b = binary.BigEndian.AppendUint16(b, t.OriginalID)
+	b = binary.BigEndian.AppendUint16(b, t.Error)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(t.Other)))
+	return append(b, t.Other...), nil
+}
+
+func unpackTSIG(data []byte) (*tsigRecord, error) {
+	algorithm, off, err := ReadName(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if off+10 > len(data) {
+		return nil, errShortMessage
+	}
+	t := &tsigRecord{
+		Algorithm:  strings.TrimSuffix(algorithm, "."),
+		TimeSigned: uint64(binary.BigEndian.Uint16(data[off:]))<<32 | uint64(binary.BigEndian.Uint32(data[off+2:])),
+		Fudge:      binary.BigEndian.Uint16(data[off+6:]),
+	}
+	n := int(binary.BigEndian.Uint16(data[off+8:]))
+	off += 10
+	if off+n+6 > len(data) {
+		return nil, errShortMessage
+	}
+	t.MAC = data[off : off+n]
+	off += n
+	t.OriginalID = binary.BigEndian.Uint16(data[off:])
+	t.Error = binary.BigEndian.Uint16(data[off+2:])
+	n = int(binary.BigEndian.Uint16(data[off+4:]))
+	off += 6
+	if off+n > len(data) {
+		return nil, errShortMessage
+	}
+	t.Other = data[off : off+n]
+	return t, nil
+}
+
+// digest computes the MAC of the message as per RFC 8945 section 4.3. The
+// MAC of the request prefixes the digest of a response. The key name is
+// digested in canonical form, i.e. in lower case, whatever its case in the
+// key.
+func (k *Key) digest(requestMAC, wire []byte, t *tsigRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	if requestMAC != nil {
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(requestMAC)))
+		buf.Write(requestMAC)
+	}
+	buf.Write(wire)
+
+	vars, err := AppendName(nil, Fqdn(k.Name))
+	if err != nil {
+		return nil, err
+	}
+	vars = binary.BigEndian.AppendUint16(vars, ClassAny)
+	vars = binary.BigEndian.AppendUint32(vars, 0)
+	if vars, err = AppendName(vars, t.Algorithm); err != nil {
+		return nil, err
+	}
+	vars = appendUint48(vars, t.TimeSigned)
+	vars = binary.BigEndian.AppendUint16(vars, t.Fudge)
+	vars = binary.BigEndian.AppendUint16(vars, t.Error)
+	vars = binary.BigEndian.AppendUint16(vars, uint16(len(t.Other)))
+	vars = append(vars, t.Other...)
+	buf.Write(vars)
+
+	mac := hmac.New(tsigAlgorithms[k.Algorithm], k.Secret)
+	mac.Write(buf.Bytes())
+	return mac.Sum(nil), nil
+}
+
+// Sign returns the message in wire format with a TSIG record appended, along
+// with its MAC.
+func (k *Key) Sign(m *Message, requestMAC []byte, now time.Time) ([]byte, []byte, error) {
+	wire, err := m.Pack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := &tsigRecord{
+		Algorithm:  k.Algorithm,
+		TimeSigned: uint64(now.Unix()),
+		Fudge:      tsigFudge,
+		OriginalID: m.ID,
+	}
+	if t.MAC, err = k.digest(requestMAC, wire, t); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := t.pack()
+	if err != nil {
+		return nil, nil, err
+	}
+	signed := *m
+	signed.Additional = append(append([]RR(nil), m.Additional...), RR{
+		Name:  Fqdn(k.Name),
+		Type:  TypeTSIG,
+		Class: ClassAny,
+		Data:  data,
+	})
+	b, err := signed.Pack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, t.MAC, nil
+}
+
+// Verify checks the TSIG record of the message in wire format, and returns
+// its MAC.
+func (k *Key) Verify(b []byte, requestMAC []byte, now time.Time) ([]byte, error) {
+	m, tsigStart, err := Unpack(b)
+	if err != nil {
+		return nil, err
+	}
+	if tsigStart < 0 {
+		return nil, errors.New("dns message is not signed")
+	}
+
+	tsig := m.Additional[len(m.Additional)-1]
+	if tsig.Name != Fqdn(k.Name) {
+		return nil, fmt.Errorf("dns message is signed with unknown key %s", tsig.Name)
+	}
+	t, err := unpackTSIG(tsig.Data)
+	if err != nil {
+		return nil, err
+	}
+	if t.Algorithm != k.Algorithm {
+		return nil, fmt.Errorf("dns message is signed with algorithm %s instead of %s", t.Algorithm, k.Algorithm)
+	}
+	if t.Error != 0 {
+		return nil, fmt.Errorf("tsig error %s", tsigErrorName(t.Error))
+	}
+
+	// The MAC covers the message as it was before the TSIG record was added
+	wire := append([]byte(nil), b[:tsigStart]...)
+	binary.BigEndian.PutUint16(wire[0:], t.OriginalID)
+	binary.BigEndian.PutUint16(wire[10:], uint16(len(m.Additional)-1))
+
+	mac, err := k.digest(requestMAC, wire, t)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, t.MAC) {
+		return nil, errors.New("tsig signature does not match")
+	}
+
+	if diff := now.Unix() - int64(t.TimeSigned); diff > int64(t.Fudge) || -diff > int64(t.Fudge) {
+		return nil, fmt.Errorf("tsig time signed is off by %d seconds", diff)
+	}
+
+	return t.MAC, nil
+}
+
+func tsigErrorName(e uint16) string {
+	switch e {
+	case 16:
+		return "BADSIG"
+	case 17:
+		return "BADKEY"
+	case 18:
+		return "BADTIME"
+	default:
+		return RcodeName(e)
+	}
+}
+
+func appendUint48(b []byte, v uint64) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(v>>32))
+	return binary.BigEndian.AppendUint32(b, uint32(v))
+}
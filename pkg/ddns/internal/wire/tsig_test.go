@@ -0,0 +1,49 @@
+package wire
+
+import (
+	"testing"
+	"time"
+)
+
+const testSecret = "c2VjcmV0LWtleS1mb3ItdGVzdGluZy1kZG5zLXVwZGF0ZXM="
+
+func TestVerifyTime(t *testing.T) {
+	key, err := NewKey("ddns-key", AlgorithmHMACSHA1, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	b, _, err := key.Sign(NewUpdate(1, "example.com"), nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := key.Verify(b, nil, now.Add(time.Minute)); err != nil {
+		t.Errorf("got error %v within fudge, wanted none", err)
+	}
+	if _, err := key.Verify(b, nil, now.Add(time.Hour)); err == nil {
+		t.Errorf("got no error outside of fudge, wanted one")
+	}
+}
+
+func TestKeyNameCase(t *testing.T) {
+	key, err := NewKey("ddns-key", AlgorithmHMACSHA256, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixedCaseKey := &Key{Name: "DDNS-Key", Algorithm: key.Algorithm, Secret: key.Secret}
+
+	now := time.Now()
+	for name, keys := range map[string][2]*Key{
+		"signed with mixed case":   {mixedCaseKey, key},
+		"verified with mixed case": {key, mixedCaseKey},
+	} {
+		b, _, err := keys[0].Sign(NewUpdate(1, "example.com"), nil, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := keys[1].Verify(b, nil, now); err != nil {
+			t.Errorf("%s: got error %v, wanted none", name, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package dhcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	arpPacketLen    = 28
+	arpOpRequest    = 1
+	arpHTypeEther   = 1
+	arpPTypeIPv4    = 0x0800
+	arpProbeTimeout = time.Second
+)
+
+// ARPProber probes addresses with ARP probes as per RFC 5227, i.e. ARP
+// requests with an all-zero sender address, and takes any ARP packet sent
+// from the probed address within the timeout as a conflict, unless it is
+// sent by the agent or the client itself. It needs the CAP_NET_RAW
+// capability.
+type ARPProber struct {
+	Timeout time.Duration
+}
+
+func NewARPProber() *ARPProber {
+	return &ARPProber{Timeout: arpProbeTimeout}
+}
+
+func (p *ARPProber) Probe(nic string, ip net.IP, clientHWAddr net.HardwareAddr) (bool, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return false, fmt.Errorf("%s is not an ipv4 address", ip)
+	}
+
+	ifi, err := net.InterfaceByName(nic)
+	if err != nil {
+		return false, err
+	}
+	if len(ifi.HardwareAddr) != 6 {
+		return false, fmt.Errorf("nic %s has no ethernet address", nic)
+	}
+
+	proto := htons(unix.ETH_P_ARP)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(proto))
+	if err != nil {
+		return false, err
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: proto, Ifindex: ifi.Index}); err != nil {
+		return false, err
+	}
+
+	broadcast := &unix.SockaddrLinklayer{
+		Protocol: proto,
+		Ifindex:  ifi.Index,
+		Halen:    6,
+		Addr:     [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	if err := unix.Sendto(fd, arpProbe(ifi.HardwareAddr, ip4), 0, broadcast); err != nil {
+		return false, err
+	}
+
+	deadline := time.Now().Add(p.Timeout)
+	buf := make([]byte, 128)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, nil
+		}
+		tv := unix.NsecToTimeval(remaining.Nanoseconds())
+		if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+			return false, err
+		}
+
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if arpConflict(buf[:n], ip4, ifi.HardwareAddr, clientHWAddr) {
+			return true, nil
+		}
+	}
+}
+
+// arpConflict reports whether the ARP packet is sent from ip by a host other
+// than the agent with hwAddr and the client with clientHWAddr.
+func arpConflict(packet []byte, ip net.IP, hwAddr, clientHWAddr net.HardwareAddr) bool {
+	if len(packet) < arpPacketLen {
+		return false
+	}
+	senderHWAddr, senderIP := packet[8:14], packet[14:18]
+	return bytes.Equal(senderIP, ip) &&
+		!bytes.Equal(senderHWAddr, hwAddr) &&
+		!bytes.Equal(senderHWAddr, clientHWAddr)
+}
+
+// arpProbe returns an ARP request for ip with an all-zero sender protocol
+// address, so that it does not pollute the ARP caches of other hosts.
+func arpProbe(hwAddr net.HardwareAddr, ip net.IP) []byte {
+	b := make([]byte, arpPacketLen)
+	binary.BigEndian.PutUint16(b[0:2], arpHTypeEther)
+	binary.BigEndian.PutUint16(b[2:4], arpPTypeIPv4)
+	b[4] = 6
+	b[5] = 4
+	binary.BigEndian.PutUint16(b[6:8], arpOpRequest)
+	copy(b[8:14], hwAddr)
+	copy(b[24:28], ip)
+	return b
+}
+
+func htons(v uint16) uint16 {
+	return binary.NativeEndian.Uint16(binary.BigEndian.AppendUint16(nil, v))
+}
@@ -0,0 +1,69 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestARPProbe(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	b := arpProbe(hwAddr, net.ParseIP("192.168.0.10").To4())
+
+	want := []byte{
+		0x00, 0x01, 0x08, 0x00, 0x06, 0x04, 0x00, 0x01,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 192, 168, 0, 10,
+	}
+	if string(b) != string(want) {
+		t.Errorf("got arp probe %x, wanted %x", b, want)
+	}
+}
+
+func TestARPConflict(t *testing.T) {
+	ip := net.ParseIP("192.168.0.10").To4()
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	clientHWAddr, _ := net.ParseMAC("52:54:00:00:00:01")
+	otherHWAddr, _ := net.ParseMAC("52:54:00:00:00:02")
+
+	// arpReply returns an ARP reply sent from sender for senderIP
+	arpReply := func(sender net.HardwareAddr, senderIP net.IP) []byte {
+		b := arpProbe(sender, net.IPv4zero.To4())
+		b[7] = 2
+		copy(b[14:18], senderIP.To4())
+		return b
+	}
+
+	testCases := []struct {
+		name   string
+		packet []byte
+		want   bool
+	}{
+		{
+			name:   "reply of another host",
+			packet: arpReply(otherHWAddr, ip),
+			want:   true,
+		},
+		{
+			name:   "reply of the client",
+			packet: arpReply(clientHWAddr, ip),
+		},
+		{
+			name:   "probe of the agent",
+			packet: arpReply(hwAddr, ip),
+		},
+		{
+			name:   "reply for another address",
+			packet: arpReply(otherHWAddr, net.ParseIP("192.168.0.11")),
+		},
+		{
+			name:   "truncated packet",
+			packet: arpReply(otherHWAddr, ip)[:arpPacketLen-1],
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := arpConflict(tc.packet, ip, hwAddr, clientHWAddr); got != tc.want {
+			t.Errorf("%s: got conflict %t, wanted %t", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+const arpProbeTimeout = time.Second
+
+// ARPProber is only supported on Linux.
+type ARPProber struct {
+	Timeout time.Duration
+}
+
+func NewARPProber() *ARPProber {
+	return &ARPProber{Timeout: arpProbeTimeout}
+}
+
+func (p *ARPProber) Probe(nic string, ip net.IP, clientHWAddr net.HardwareAddr) (bool, error) {
+	return false, fmt.Errorf("arp probing is not supported on this platform")
+}
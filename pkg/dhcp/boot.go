@@ -0,0 +1,119 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/sirupsen/logrus"
+)
+
+// iPXE identifies itself with this user class (option 77)
+const ipxeUserClass = "iPXE"
+
+// BootConfig holds the network boot parameters of a lease.
+type BootConfig struct {
+	NextServer    net.IP
+	Filename      string
+	ArchFilenames map[iana.Arch]string
+	IPXEScriptURL string
+}
+
+// WithNextServer sets the server the client loads its boot file from, i.e.
+// siaddr.
+func WithNextServer(nextServer string) LeaseOption {
+	return func(l *DHCPLease) error {
+		ip := net.ParseIP(nextServer).To4()
+		if ip == nil {
+			return fmt.Errorf("next server %s is not a valid ipv4 address", nextServer)
+		}
+		bootConfigOf(l).NextServer = ip
+		return nil
+	}
+}
+
+// WithBootFilename sets the boot file for clients whose architecture has no
+// boot file of its own.
+func WithBootFilename(filename string) LeaseOption {
+	return func(l *DHCPLease) error {
+		bootConfigOf(l).Filename = filename
+		return nil
+	}
+}
+
+// WithArchBootFilename sets the boot file for clients announcing the given
+// architecture type in option 93, e.g. 0 for BIOS or 7 for x64 UEFI.
+func WithArchBootFilename(arch uint16, filename string) LeaseOption {
+	return func(l *DHCPLease) error {
+		bootConfig := bootConfigOf(l)
+		if bootConfig.ArchFilenames == nil {
+			bootConfig.ArchFilenames = make(map[iana.Arch]string)
+		}
+		bootConfig.ArchFilenames[iana.Arch(arch)] = filename
+		return nil
+	}
+}
+
+// WithIPXEScript sets the script URL handed out to iPXE, so that the iPXE
+// binary loaded from the boot file does not chain-load itself again.
+func WithIPXEScript(url string) LeaseOption {
+	return func(l *DHCPLease) error {
+		bootConfigOf(l).IPXEScriptURL = url
+		return nil
+	}
+}
+
+func bootConfigOf(l *DHCPLease) *BootConfig {
+	if l.Boot == nil {
+		l.Boot = &BootConfig{}
+	}
+	return l.Boot
+}
+
+// bootFilename picks the boot file for the client according to its request:
+// the iPXE script if the client is iPXE, then the boot file matching the
+// first known client architecture, and finally the default boot file.
+func (b *BootConfig) bootFilename(m *dhcpv4.DHCPv4) string {
+	if b.IPXEScriptURL != "" && isIPXE(m) {
+		return b.IPXEScriptURL
+	}
+
+	for _, arch := range m.ClientArch() {
+		if filename, ok := b.ArchFilenames[arch]; ok {
+			return filename
+		}
+	}
+
+	return b.Filename
+}
+
+func isIPXE(m *dhcpv4.DHCPv4) bool {
+	for _, userClass := range m.UserClass() {
+		if userClass == ipxeUserClass {
+			return true
+		}
+	}
+	return false
+}
+
+// setBootOptions sets the next server and the boot file of the reply.
+func setBootOptions(reply, m *dhcpv4.DHCPv4, lease DHCPLease) {
+	if lease.Boot == nil {
+		return
+	}
+
+	if lease.Boot.NextServer != nil {
+		reply.ServerIPAddr = lease.Boot.NextServer
+	}
+
+	filename := lease.Boot.bootFilename(m)
+	if filename == "" {
+		return
+	}
+
+	logrus.Debugf("(dhcp.dhcpHandler) hwaddr %s boots %s (arch=%v, userclass=%v)", m.ClientHWAddr, filename, m.ClientArch(), m.UserClass())
+
+	reply.BootFileName = filename
+	reply.UpdateOption(dhcpv4.OptBootFileName(filename))
+}
@@ -0,0 +1,105 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/iana"
+)
+
+func TestDHCPHandlerBoot(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	nextServer := net.ParseIP("192.168.0.5")
+
+	td := New()
+	if err := td.AddLease(
+		testNIC,
+		hwAddr.String(),
+		"192.168.0.2",
+		"192.168.0.10",
+		"192.168.0.0/24",
+		"192.168.0.1",
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		WithNextServer(nextServer.String()),
+		WithBootFilename("pxelinux.0"),
+		WithArchBootFilename(uint16(iana.INTEL_X86PC), "undionly.kpxe"),
+		WithArchBootFilename(uint16(iana.EFI_X86_64), "ipxe.efi"),
+		WithIPXEScript("http://192.168.0.5/boot.ipxe"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		modifiers []dhcpv4.Modifier
+		want      string
+	}{
+		{
+			name: "bios",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithOption(dhcpv4.OptClassIdentifier("PXEClient:Arch:00000:UNDI:002001")),
+				dhcpv4.WithOption(dhcpv4.OptClientArch(iana.INTEL_X86PC)),
+			},
+			want: "undionly.kpxe",
+		},
+		{
+			name: "uefi",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithOption(dhcpv4.OptClassIdentifier("PXEClient:Arch:00007:UNDI:003016")),
+				dhcpv4.WithOption(dhcpv4.OptClientArch(iana.EFI_X86_64)),
+			},
+			want: "ipxe.efi",
+		},
+		{
+			name: "ipxe",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithOption(dhcpv4.OptClassIdentifier("PXEClient:Arch:00007:UNDI:003016")),
+				dhcpv4.WithOption(dhcpv4.OptClientArch(iana.EFI_X86_64)),
+				dhcpv4.WithOption(dhcpv4.OptUserClass("iPXE")),
+			},
+			want: "http://192.168.0.5/boot.ipxe",
+		},
+		{
+			name: "unknown architecture",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithOption(dhcpv4.OptClientArch(iana.EFI_ARM64)),
+			},
+			want: "pxelinux.0",
+		},
+	}
+
+	for _, tc := range tests {
+		m, err := dhcpv4.NewDiscovery(hwAddr, tc.modifiers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		if len(conn.written) != 1 {
+			t.Errorf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+			continue
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reply.BootFileName != tc.want {
+			t.Errorf("%s: got file %q, wanted %q", tc.name, reply.BootFileName, tc.want)
+		}
+		if got := reply.BootFileNameOption(); got != tc.want {
+			t.Errorf("%s: got bootfile name option %q, wanted %q", tc.name, got, tc.want)
+		}
+		if !reply.ServerIPAddr.Equal(nextServer) {
+			t.Errorf("%s: got siaddr %s, wanted %s", tc.name, reply.ServerIPAddr, nextServer)
+		}
+		if got := reply.ServerIdentifier(); !got.Equal(net.ParseIP("192.168.0.2")) {
+			t.Errorf("%s: got server identifier %s, wanted 192.168.0.2", tc.name, got)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package dhcp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/sirupsen/logrus"
+)
+
+// ClientClass groups the clients matching any of its vendor classes (option
+// 60), user classes (option 77) or hardware address prefixes, e.g. PXE
+// clients or Windows guests, and overrides the configuration parameters of
+// the replies sent to them.
+type ClientClass struct {
+	Name string
+
+	// Prefixes of the vendor class identifier, e.g. "PXEClient" or "MSFT"
+	VendorClasses []string
+	UserClasses   []string
+	MACPrefixes   [][]byte
+
+	NextServer net.IP
+	Filename   string
+
+	// Raw option values keyed by option code, taking precedence over the ones
+	// of the lease
+	CustomOptions dhcpv4.Options
+}
+
+// ClassOption sets a match criterion or an override of a client class.
+type ClassOption func(*ClientClass) error
+
+// MatchVendorClass lets the class match the clients whose vendor class
+// identifier (option 60) starts with prefix.
+func MatchVendorClass(prefix string) ClassOption {
+	return func(c *ClientClass) error {
+		if prefix == "" {
+			return fmt.Errorf("vendor class of class %s is empty", c.Name)
+		}
+		c.VendorClasses = append(c.VendorClasses, prefix)
+		return nil
+	}
+}
+
+// MatchUserClass lets the class match the clients sending userClass in
+// option 77.
+func MatchUserClass(userClass string) ClassOption {
+	return func(c *ClientClass) error {
+		if userClass == "" {
+			return fmt.Errorf("user class of class %s is empty", c.Name)
+		}
+		c.UserClasses = append(c.UserClasses, userClass)
+		return nil
+	}
+}
+
+// MatchMACPrefix lets the class match the clients whose hardware address
+// starts with prefix in colon-separated hex, e.g. an OUI like "52:54:00".
+func MatchMACPrefix(prefix string) ClassOption {
+	return func(c *ClientClass) error {
+		b, err := hex.DecodeString(strings.ReplaceAll(prefix, ":", ""))
+		if err != nil || len(b) == 0 || len(b) > 6 {
+			return fmt.Errorf("mac prefix %s of class %s is not valid", prefix, c.Name)
+		}
+		c.MACPrefixes = append(c.MACPrefixes, b)
+		return nil
+	}
+}
+
+// WithClassNextServer sets the server the clients of the class load their
+// boot file from.
+func WithClassNextServer(nextServer string) ClassOption {
+	return func(c *ClientClass) error {
+		ip := net.ParseIP(nextServer).To4()
+		if ip == nil {
+			return fmt.Errorf("next server %s of class %s is not a valid ipv4 address", nextServer, c.Name)
+		}
+		c.NextServer = ip
+		return nil
+	}
+}
+
+// WithClassBootFilename sets the boot file of the clients of the class.
+func WithClassBootFilename(filename string) ClassOption {
+	return func(c *ClientClass) error {
+		c.Filename = filename
+		return nil
+	}
+}
+
+// WithClassCustomOption sets an arbitrary option to the replies sent to the
+// clients of the class.
+func WithClassCustomOption(code uint8, value []byte) ClassOption {
+	return func(c *ClientClass) error {
+		if c.CustomOptions == nil {
+			c.CustomOptions = make(dhcpv4.Options)
+		}
+		c.CustomOptions[code] = value
+		return nil
+	}
+}
+
+// NewClientClass returns the client class with the given match criteria and
+// overrides. A class matches no client unless given a criterion.
+func NewClientClass(name string, opts ...ClassOption) (ClientClass, error) {
+	c := ClientClass{Name: name}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return ClientClass{}, err
+		}
+	}
+	return c, nil
+}
+
+func (c *ClientClass) matches(m *dhcpv4.DHCPv4) bool {
+	if vendorClass := m.ClassIdentifier(); vendorClass != "" {
+		for _, prefix := range c.VendorClasses {
+			if strings.HasPrefix(vendorClass, prefix) {
+				return true
+			}
+		}
+	}
+
+	for _, userClass := range m.UserClass() {
+		for _, uc := range c.UserClasses {
+			if userClass == uc {
+				return true
+			}
+		}
+	}
+
+	for _, prefix := range c.MACPrefixes {
+		if bytes.HasPrefix(m.ClientHWAddr, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetClientClasses replaces the client classes on nic. A client belongs to
+// the first class it matches, if any.
+func (a *DHCPAllocator) SetClientClasses(nic string, classes []ClientClass) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if len(classes) == 0 {
+		delete(a.classes, nic)
+		return
+	}
+	a.classes[nic] = classes
+}
+
+// classOf returns the class the client belongs to, or nil. Must be called
+// with the lock held.
+func (a *DHCPAllocator) classOf(nic string, m *dhcpv4.DHCPv4) *ClientClass {
+	classes := a.classes[nic]
+	for i := range classes {
+		if classes[i].matches(m) {
+			logrus.Debugf("(dhcp.dhcpHandler) hwaddr %s belongs to class %s (vendorclass=%q, userclass=%v)", m.ClientHWAddr, classes[i].Name, m.ClassIdentifier(), m.UserClass())
+			return &classes[i]
+		}
+	}
+	return nil
+}
+
+// setClassConfigOptions overrides the options of the reply with the ones of
+// the class.
+func setClassConfigOptions(reply *dhcpv4.DHCPv4, class *ClientClass) {
+	for code, value := range class.CustomOptions {
+		reply.UpdateOption(dhcpv4.OptGeneric(dhcpv4.GenericOptionCode(code), value))
+	}
+}
+
+// setClassBootOptions overrides the next server and the boot file of the
+// reply with the ones of the class.
+func setClassBootOptions(reply *dhcpv4.DHCPv4, class *ClientClass) {
+	if class.NextServer != nil {
+		reply.ServerIPAddr = class.NextServer
+	}
+	if class.Filename != "" {
+		reply.BootFileName = class.Filename
+		reply.UpdateOption(dhcpv4.OptBootFileName(class.Filename))
+	}
+}
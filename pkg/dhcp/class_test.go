@@ -0,0 +1,174 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+func TestDHCPHandlerClientClass(t *testing.T) {
+	linuxDNS := net.ParseIP("192.168.0.53").To4()
+	windowsDNS := net.ParseIP("192.168.0.54").To4()
+
+	td := New()
+	if err := td.AddLease(
+		testNIC,
+		"aa:bb:cc:dd:ee:ff",
+		"192.168.0.2",
+		"192.168.0.10",
+		"192.168.0.0/24",
+		"192.168.0.1",
+		[]string{linuxDNS.String()},
+		nil,
+		nil,
+		nil,
+		nil,
+		WithBootFilename("undionly.kpxe"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var classes []ClientClass
+	for _, tc := range []struct {
+		name string
+		opts []ClassOption
+	}{
+		{
+			name: "ipxe",
+			opts: []ClassOption{
+				MatchUserClass("iPXE"),
+				WithClassBootFilename("http://192.168.0.5/boot.ipxe"),
+			},
+		},
+		{
+			name: "pxe",
+			opts: []ClassOption{
+				MatchVendorClass("PXEClient"),
+				WithClassNextServer("192.168.0.5"),
+				WithClassBootFilename("ipxe.efi"),
+			},
+		},
+		{
+			name: "windows",
+			opts: []ClassOption{
+				MatchVendorClass("MSFT"),
+				MatchMACPrefix("52:54:00"),
+				WithClassCustomOption(uint8(dhcpv4.OptionDomainNameServer.Code()), windowsDNS),
+			},
+		},
+	} {
+		class, err := NewClientClass(tc.name, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		classes = append(classes, class)
+	}
+	td.SetClientClasses(testNIC, classes)
+
+	tests := []struct {
+		name           string
+		hwAddr         string
+		modifiers      []dhcpv4.Modifier
+		wantFilename   string
+		wantNextServer net.IP
+		wantDNS        net.IP
+	}{
+		{
+			name:           "no class",
+			hwAddr:         "aa:bb:cc:dd:ee:ff",
+			wantFilename:   "undionly.kpxe",
+			wantNextServer: net.ParseIP("192.168.0.2"),
+			wantDNS:        linuxDNS,
+		},
+		{
+			name:   "pxe",
+			hwAddr: "aa:bb:cc:dd:ee:ff",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithOption(dhcpv4.OptClassIdentifier("PXEClient:Arch:00007:UNDI:003016")),
+			},
+			wantFilename:   "ipxe.efi",
+			wantNextServer: net.ParseIP("192.168.0.5"),
+			wantDNS:        linuxDNS,
+		},
+		{
+			name:   "ipxe before pxe",
+			hwAddr: "aa:bb:cc:dd:ee:ff",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithOption(dhcpv4.OptClassIdentifier("PXEClient:Arch:00007:UNDI:003016")),
+				dhcpv4.WithOption(dhcpv4.OptUserClass("iPXE")),
+			},
+			wantFilename:   "http://192.168.0.5/boot.ipxe",
+			wantNextServer: net.ParseIP("192.168.0.2"),
+			wantDNS:        linuxDNS,
+		},
+		{
+			name:   "windows by vendor class",
+			hwAddr: "aa:bb:cc:dd:ee:ff",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithOption(dhcpv4.OptClassIdentifier("MSFT 5.0")),
+			},
+			wantFilename:   "undionly.kpxe",
+			wantNextServer: net.ParseIP("192.168.0.2"),
+			wantDNS:        windowsDNS,
+		},
+	}
+
+	for _, tc := range tests {
+		hwAddr, _ := net.ParseMAC(tc.hwAddr)
+		m, err := dhcpv4.NewDiscovery(hwAddr, tc.modifiers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		if len(conn.written) != 1 {
+			t.Errorf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+			continue
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reply.BootFileName != tc.wantFilename {
+			t.Errorf("%s: got file %q, wanted %q", tc.name, reply.BootFileName, tc.wantFilename)
+		}
+		if !reply.ServerIPAddr.Equal(tc.wantNextServer) {
+			t.Errorf("%s: got siaddr %s, wanted %s", tc.name, reply.ServerIPAddr, tc.wantNextServer)
+		}
+		if dns := reply.DNS(); len(dns) != 1 || !dns[0].Equal(tc.wantDNS) {
+			t.Errorf("%s: got dns %v, wanted %s", tc.name, dns, tc.wantDNS)
+		}
+	}
+}
+
+func TestClientClassMatchMACPrefix(t *testing.T) {
+	class, err := NewClientClass("qemu", MatchMACPrefix("52:54:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		hwAddr string
+		want   bool
+	}{
+		{hwAddr: "52:54:00:12:34:56", want: true},
+		{hwAddr: "52:54:01:12:34:56", want: false},
+	}
+
+	for _, tc := range tests {
+		hwAddr, _ := net.ParseMAC(tc.hwAddr)
+		m, err := dhcpv4.NewDiscovery(hwAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := class.matches(m); got != tc.want {
+			t.Errorf("%s: got %t, wanted %t", tc.hwAddr, got, tc.want)
+		}
+	}
+
+	if _, err := NewClientClass("invalid", MatchMACPrefix("52:54:zz")); err == nil {
+		t.Errorf("invalid mac prefix: got no error, wanted one")
+	}
+}
@@ -2,9 +2,11 @@ package dhcp
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,7 +14,27 @@ import (
 
 	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/dhcpv6/server6"
 	"github.com/insomniacslk/dhcp/rfc1035label"
+
+	"github.com/harvester/vm-dhcp-controller/pkg/util"
+)
+
+// infiniteLeaseTime is the lease time of leases which never expire
+const infiniteLeaseTime = 0xffffffff
+
+// Microsoft Classless Static Route option, same format as option 121
+var optionMSClasslessStaticRoute = dhcpv4.GenericOptionCode(249)
+
+type LeaseState string
+
+const (
+	LeaseStateBound    LeaseState = "Bound"
+	LeaseStateReleased LeaseState = "Released"
+	LeaseStateDeclined LeaseState = "Declined"
+	LeaseStateInformed LeaseState = "Informed"
+	LeaseStateConflict LeaseState = "Conflict"
 )
 
 type DHCPLease struct {
@@ -25,6 +47,135 @@ type DHCPLease struct {
 	DomainSearch []string
 	NTP          []net.IP
 	LeaseTime    int
+	Routes       dhcpv4.Routes
+	Hostname     string
+
+	// T1 and T2 in seconds, sent unless zero
+	RenewalTime   int
+	RebindingTime int
+	InfiniteLease bool
+
+	// The DNS records of the client are updated on its behalf
+	DDNS bool
+
+	// Answer DHCPDISCOVERs with option 80 directly with a DHCPACK
+	RapidCommit bool
+
+	// Probe the address before it is first offered, see Probed
+	ConflictDetection bool
+	Probed            bool
+
+	// Handed out from the dynamic range to a client without a lease of its
+	// own, reclaimed once expired
+	Dynamic bool
+
+	// Client identifier (option 61) matched before the hardware address, in
+	// colon-separated hex
+	ClientID string
+
+	// Raw option values keyed by option code, sent as is
+	CustomOptions dhcpv4.Options
+
+	Boot *BootConfig
+
+	RelayAgentIDs *RelayAgentIDs
+
+	State     LeaseState
+	StateTime time.Time
+
+	// Set by the DHCPACKs sent to the client
+	ClientHostname string
+	BoundTime      time.Time
+	RenewTime      time.Time
+	ExpiryTime     time.Time
+}
+
+// LeaseOption sets an optional configuration parameter of a lease.
+type LeaseOption func(*DHCPLease) error
+
+// WithRoute adds a classless static route to the lease, which is sent in
+// option 121 and its Microsoft counterpart 249.
+func WithRoute(destination, gateway string) LeaseOption {
+	return func(l *DHCPLease) error {
+		_, dest, err := net.ParseCIDR(destination)
+		if err != nil || dest.IP.To4() == nil {
+			return fmt.Errorf("route destination %s is not a valid ipv4 cidr", destination)
+		}
+		router := net.ParseIP(gateway).To4()
+		if router == nil {
+			return fmt.Errorf("route gateway %s is not a valid ipv4 address", gateway)
+		}
+		l.Routes = append(l.Routes, &dhcpv4.Route{Dest: dest, Router: router})
+		return nil
+	}
+}
+
+// WithCustomOption sets an arbitrary option to the lease. It takes
+// precedence over the option derived from the configuration, if any.
+func WithCustomOption(code uint8, value []byte) LeaseOption {
+	return func(l *DHCPLease) error {
+		if l.CustomOptions == nil {
+			l.CustomOptions = make(dhcpv4.Options)
+		}
+		l.CustomOptions[code] = value
+		return nil
+	}
+}
+
+// WithRenewalTime sets the renewal (T1) time of the lease in seconds, which is
+// sent in option 58.
+func WithRenewalTime(renewalTime int) LeaseOption {
+	return func(l *DHCPLease) error {
+		l.RenewalTime = renewalTime
+		return nil
+	}
+}
+
+// WithRebindingTime sets the rebinding (T2) time of the lease in seconds,
+// which is sent in option 59.
+func WithRebindingTime(rebindingTime int) LeaseOption {
+	return func(l *DHCPLease) error {
+		l.RebindingTime = rebindingTime
+		return nil
+	}
+}
+
+// WithInfiniteLease makes the lease never expire. Neither renewal nor
+// rebinding time is sent then.
+func WithInfiniteLease() LeaseOption {
+	return func(l *DHCPLease) error {
+		l.InfiniteLease = true
+		return nil
+	}
+}
+
+// WithClientID lets the client presenting the given client identifier
+// (option 61), in colon-separated hex, get the lease regardless of its
+// hardware address.
+func WithClientID(clientID string) LeaseOption {
+	return func(l *DHCPLease) error {
+		b, err := hex.DecodeString(strings.ReplaceAll(clientID, ":", ""))
+		if err != nil || len(b) == 0 {
+			return fmt.Errorf("client id %s is not valid", clientID)
+		}
+		l.ClientID = formatClientID(b)
+		return nil
+	}
+}
+
+// formatClientID returns the client identifier in colon-separated hex, e.g.
+// "ff:00:00:00:01:00:01".
+func formatClientID(clientID []byte) string {
+	return net.HardwareAddr(clientID).String()
+}
+
+// WithRapidCommit lets clients asking for rapid commit get the lease bound
+// with a two-message exchange.
+func WithRapidCommit() LeaseOption {
+	return func(l *DHCPLease) error {
+		l.RapidCommit = true
+		return nil
+	}
 }
 
 func (l *DHCPLease) String() string {
@@ -35,10 +186,53 @@ func (l *DHCPLease) String() string {
 	return string(b)
 }
 
+// dhcpServers holds the DHCP servers bound to a single network interface.
+type dhcpServers struct {
+	server4 *server4.Server
+	server6 *server6.Server
+	duid    dhcpv6.DUID
+}
+
+// leaseKey identifies a lease by the network interface it is served on and
+// the client hardware address. An agent serving several IPPools may see the
+// same hardware address on more than one network.
+type leaseKey struct {
+	nic    string
+	hwAddr string
+}
+
+func (k leaseKey) String() string {
+	return k.nic + "/" + k.hwAddr
+}
+
+// clientIDKey identifies a lease by the network interface it is served on and
+// the client identifier.
+type clientIDKey struct {
+	nic      string
+	clientID string
+}
+
 type DHCPAllocator struct {
-	leases  map[string]DHCPLease
-	servers map[string]*server4.Server
-	mutex   sync.RWMutex
+	leases  map[leaseKey]DHCPLease
+	leases6 map[lease6Key]DHCPv6Lease
+	// Hardware addresses of the leases with a client identifier
+	clientIDs map[clientIDKey]string
+	// Dynamic ranges keyed by network interface
+	dynamicRanges map[string]*DynamicRange
+	// Client classes keyed by network interface
+	classes map[string][]ClientClass
+	// Leases of the health probe keyed by network interface
+	healthLeases map[string]DHCPLease
+	servers      map[string]*dhcpServers
+	stateChs     map[string]chan struct{}
+	renewChs     map[string]chan struct{}
+	prober       Prober
+	hooks        MessageHooks
+	mutex        sync.RWMutex
+
+	// Not guarded by mutex, they have locks of their own
+	limiter        *rateLimiter
+	unknownClients *unknownClients
 }
 
 func New() *DHCPAllocator {
@@ -46,16 +240,30 @@ func New() *DHCPAllocator {
 }
 
 func NewDHCPAllocator() *DHCPAllocator {
-	leases := make(map[string]DHCPLease)
-	servers := make(map[string]*server4.Server)
+	leases := make(map[leaseKey]DHCPLease)
+	leases6 := make(map[lease6Key]DHCPv6Lease)
+	servers := make(map[string]*dhcpServers)
 
 	return &DHCPAllocator{
 		leases:  leases,
+		leases6: leases6,
 		servers: servers,
+
+		clientIDs:     make(map[clientIDKey]string),
+		dynamicRanges: make(map[string]*DynamicRange),
+		classes:       make(map[string][]ClientClass),
+		healthLeases:  make(map[string]DHCPLease),
+		stateChs:      make(map[string]chan struct{}),
+		renewChs:      make(map[string]chan struct{}),
+		prober:        NewARPProber(),
+
+		limiter:        newRateLimiter(RateLimit{}),
+		unknownClients: newUnknownClients(),
 	}
 }
 
 func (a *DHCPAllocator) AddLease(
+	nic string,
 	hwAddr string,
 	serverIP string,
 	clientIP string,
@@ -66,6 +274,7 @@ func (a *DHCPAllocator) AddLease(
 	domainSearch []string,
 	ntpServers []string,
 	leaseTime *int,
+	opts ...LeaseOption,
 ) (err error) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
@@ -78,17 +287,122 @@ func (a *DHCPAllocator) AddLease(
 		return fmt.Errorf("hwaddr %s is not valid", hwAddr)
 	}
 
-	if a.checkLease(hwAddr) {
+	key := leaseKey{nic: nic, hwAddr: hwAddr}
+	if a.checkLease(key) && !a.leases[key].Dynamic {
 		return fmt.Errorf("lease for hwaddr %s already exists", hwAddr)
 	}
 
+	lease, err := newLease(serverIP, clientIP, cidr, routerIP, dnsServers, domainName, domainSearch, ntpServers, leaseTime, opts...)
+	if err != nil {
+		return err
+	}
+
+	idKey := clientIDKey{nic: nic, clientID: lease.ClientID}
+	if other, exists := a.clientIDs[idKey]; lease.ClientID != "" && exists {
+		return fmt.Errorf("client id %s is already used by hwaddr %s", lease.ClientID, other)
+	}
+
+	// Static leases take precedence over the dynamic ones of the same client
+	// or address
+	a.evictDynamicLeases(key, lease.ClientIP)
+
+	if lease.ClientID != "" {
+		a.clientIDs[idKey] = hwAddr
+	}
+
+	a.leases[key] = lease
+
+	logrus.Infof("(dhcp.AddLease) lease added for hardware address: %s on nic %s", hwAddr, nic)
+
+	return
+}
+
+// UpdateLease rebuilds the existing lease of hwAddr out of the configuration
+// parameters, e.g. after the IPPool configuration changed. What the client did
+// with the lease is kept unless the address changed, in which case the lease
+// starts over as if it was new.
+func (a *DHCPAllocator) UpdateLease(
+	nic string,
+	hwAddr string,
+	serverIP string,
+	clientIP string,
+	cidr string,
+	routerIP string,
+	dnsServers []string,
+	domainName *string,
+	domainSearch []string,
+	ntpServers []string,
+	leaseTime *int,
+	opts ...LeaseOption,
+) (err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	key := leaseKey{nic: nic, hwAddr: hwAddr}
+	old, exists := a.leases[key]
+	if !exists || old.Dynamic {
+		return fmt.Errorf("lease for hwaddr %s does not exists", hwAddr)
+	}
+
+	lease, err := newLease(serverIP, clientIP, cidr, routerIP, dnsServers, domainName, domainSearch, ntpServers, leaseTime, opts...)
+	if err != nil {
+		return err
+	}
+
+	idKey := clientIDKey{nic: nic, clientID: lease.ClientID}
+	if other, exists := a.clientIDs[idKey]; lease.ClientID != "" && exists && other != hwAddr {
+		return fmt.Errorf("client id %s is already used by hwaddr %s", lease.ClientID, other)
+	}
+
+	if lease.ClientIP.Equal(old.ClientIP) {
+		lease.Probed = old.Probed
+		lease.State = old.State
+		lease.StateTime = old.StateTime
+		lease.ClientHostname = old.ClientHostname
+		lease.BoundTime = old.BoundTime
+		lease.RenewTime = old.RenewTime
+		lease.ExpiryTime = old.ExpiryTime
+	} else {
+		a.evictDynamicLeases(key, lease.ClientIP)
+		if old.State != "" {
+			a.notifyLeaseState(nic)
+		}
+	}
+
+	if old.ClientID != "" && old.ClientID != lease.ClientID {
+		delete(a.clientIDs, clientIDKey{nic: nic, clientID: old.ClientID})
+	}
+	if lease.ClientID != "" {
+		a.clientIDs[idKey] = hwAddr
+	}
+
+	a.leases[key] = lease
+
+	logrus.Infof("(dhcp.UpdateLease) lease updated for hardware address: %s on nic %s", hwAddr, nic)
+
+	return
+}
+
+// newLease builds a lease out of the configuration parameters.
+func newLease(
+	serverIP string,
+	clientIP string,
+	cidr string,
+	routerIP string,
+	dnsServers []string,
+	domainName *string,
+	domainSearch []string,
+	ntpServers []string,
+	leaseTime *int,
+	opts ...LeaseOption,
+) (DHCPLease, error) {
 	lease := DHCPLease{}
 	lease.ServerIP = net.ParseIP(serverIP)
 	lease.ClientIP = net.ParseIP(clientIP)
 
 	_, ipNet, err := net.ParseCIDR(cidr)
 	if err != nil {
-		return err
+		return DHCPLease{}, err
 	}
 	lease.SubnetMask = ipNet.Mask
 
@@ -127,48 +441,73 @@ func (a *DHCPAllocator) AddLease(
 		lease.LeaseTime = *leaseTime
 	}
 
-	a.leases[hwAddr] = lease
-
-	logrus.Infof("(dhcp.AddLease) lease added for hardware address: %s", hwAddr)
+	for _, opt := range opts {
+		if err := opt(&lease); err != nil {
+			return DHCPLease{}, err
+		}
+	}
 
-	return
+	return lease, nil
 }
 
-func (a *DHCPAllocator) checkLease(hwAddr string) bool {
-	_, exists := a.leases[hwAddr]
+func (a *DHCPAllocator) checkLease(key leaseKey) bool {
+	_, exists := a.leases[key]
 
 	return exists
 }
 
-func (a *DHCPAllocator) GetLease(hwAddr string) (lease DHCPLease) {
+func (a *DHCPAllocator) GetLease(nic, hwAddr string) (lease DHCPLease) {
 	a.mutex.RLock()
 	defer a.mutex.RUnlock()
 
-	return a.leases[hwAddr]
+	return a.leases[leaseKey{nic: nic, hwAddr: hwAddr}]
 }
 
-func (a *DHCPAllocator) DeleteLease(hwAddr string) (err error) {
+func (a *DHCPAllocator) DeleteLease(nic, hwAddr string) (err error) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	if !a.checkLease(hwAddr) {
+	key := leaseKey{nic: nic, hwAddr: hwAddr}
+	if !a.checkLease(key) {
 		return fmt.Errorf("lease for hwaddr %s does not exists", hwAddr)
 	}
 
-	delete(a.leases, hwAddr)
+	if clientID := a.leases[key].ClientID; clientID != "" {
+		delete(a.clientIDs, clientIDKey{nic: nic, clientID: clientID})
+	}
+	delete(a.leases, key)
 
-	logrus.Infof("(dhcp.DeleteLease) lease deleted for hardware address: %s", hwAddr)
+	logrus.Infof("(dhcp.DeleteLease) lease deleted for hardware address: %s on nic %s", hwAddr, nic)
 
 	return
 }
 
+// ReleaseClientID drops the client identifier from the lease of hwAddr, if
+// any, so it can be handed over to another lease. Client identifiers swapped
+// between leases have to be released before the leases get updated.
+func (a *DHCPAllocator) ReleaseClientID(nic, hwAddr string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	key := leaseKey{nic: nic, hwAddr: hwAddr}
+	lease, exists := a.leases[key]
+	if !exists || lease.ClientID == "" {
+		return
+	}
+
+	delete(a.clientIDs, clientIDKey{nic: nic, clientID: lease.ClientID})
+	lease.ClientID = ""
+	a.leases[key] = lease
+}
+
 func (a *DHCPAllocator) Usage() {
 	a.mutex.RLock()
 	defer a.mutex.RUnlock()
 
-	for hwaddr, lease := range a.leases {
-		logrus.Infof("(dhcp.Usage) lease: hwaddr=%s, clientip=%s, netmask=%s, router=%s, dns=%+v, domain=%s, domainsearch=%+v, ntp=%+v, leasetime=%d",
-			hwaddr,
+	for key, lease := range a.leases {
+		logrus.Infof("(dhcp.Usage) lease: nic=%s, hwaddr=%s, clientip=%s, netmask=%s, router=%s, dns=%+v, domain=%s, domainsearch=%+v, ntp=%+v, leasetime=%d",
+			key.nic,
+			key.hwAddr,
 			lease.ClientIP.String(),
 			lease.SubnetMask.String(),
 			lease.Router.String(),
@@ -181,14 +520,38 @@ func (a *DHCPAllocator) Usage() {
 	}
 }
 
-func (a *DHCPAllocator) dhcpHandler(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
-	a.mutex.RLock()
-	defer a.mutex.RUnlock()
-
+// dhcpHandler serves the DHCPv4 messages received on nic with the leases of
+// that interface.
+func (a *DHCPAllocator) dhcpHandler(nic string, conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
 	if m == nil {
 		logrus.Errorf("(dhcp.dhcpHandler) packet is nil!")
 		return
 	}
+	received := time.Now()
+
+	// Turn flooding clients away before contending for the lease store
+	if !a.limiter.allow(leaseKey{nic: nic, hwAddr: m.ClientHWAddr.String()}, time.Now()) {
+		logrus.Tracef("(dhcp.dhcpHandler) rate limited hwaddr %s", m.ClientHWAddr.String())
+		return
+	}
+
+	if m.MessageType() == dhcpv4.MessageTypeDiscover {
+		// Probe the address before taking the lock, as it takes a while
+		if !a.probeLease(nic, m) {
+			return
+		}
+		a.offerDynamicLease(nic, m)
+	}
+
+	// The reply is built with the read lock held only, the change to the
+	// lease it implies is applied afterwards
+	var update *leaseUpdate
+	defer func() {
+		a.applyLeaseUpdate(update)
+	}()
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
 
 	logrus.Tracef("(dhcp.dhcpHandler) INCOMING PACKET=%s", m.Summary())
 
@@ -197,18 +560,197 @@ func (a *DHCPAllocator) dhcpHandler(conn net.PacketConn, peer net.Addr, m *dhcpv
 		return
 	}
 
-	reply, err := dhcpv4.NewReplyFromRequest(m)
+	// The health probe is not part of the traffic served
+	hooks := a.hooks
+	if isHealthProbe(m) {
+		hooks = MessageHooks{}
+	}
+	hooks.received(nic, m.MessageType())
+
+	var reply *dhcpv4.DHCPv4
+
+	switch messageType := m.MessageType(); messageType {
+	case dhcpv4.MessageTypeDiscover:
+		logrus.Debugf("(dhcp.dhcpHandler) DHCPDISCOVER: %+v", m)
+		reply, update = a.handleDiscover(nic, m)
+		logrus.Debugf("(dhcp.dhcpHandler) %s: %+v", replyType(reply), reply)
+	case dhcpv4.MessageTypeRequest:
+		logrus.Debugf("(dhcp.dhcpHandler) DHCPREQUEST: %+v", m)
+		reply, update = a.handleRequest(nic, m)
+		logrus.Debugf("(dhcp.dhcpHandler) %s: %+v", replyType(reply), reply)
+	case dhcpv4.MessageTypeRelease:
+		logrus.Debugf("(dhcp.dhcpHandler) DHCPRELEASE: %+v", m)
+		update = a.handleRelease(nic, m)
+	case dhcpv4.MessageTypeDecline:
+		logrus.Debugf("(dhcp.dhcpHandler) DHCPDECLINE: %+v", m)
+		update = a.handleDecline(nic, m)
+	case dhcpv4.MessageTypeInform:
+		logrus.Debugf("(dhcp.dhcpHandler) DHCPINFORM: %+v", m)
+		reply, update = a.buildInformReply(nic, m)
+		logrus.Debugf("(dhcp.dhcpHandler) DHCPACK: %+v", reply)
+	default:
+		logrus.Warnf("(dhcp.dhcpHandler) Unhandled message type for hwaddr [%s]: %v", m.ClientHWAddr.String(), messageType)
+		return
+	}
+
+	if reply == nil {
+		return
+	}
+
+	if _, err := conn.WriteTo(reply.ToBytes(), replyAddr(peer, reply)); err != nil {
+		logrus.Errorf("(dhcp.dhcpHandler) Cannot reply to client: %v", err)
+		return
+	}
+	hooks.replied(nic, reply, received)
+}
+
+// handleDiscover answers a DHCPDISCOVER with a DHCPOFFER. If both the client
+// and the lease have rapid commit (option 80, RFC 4039) enabled, the lease is
+// bound right away with a DHCPACK instead, saving the DHCPREQUEST round trip.
+// That is safe as the binding is decided by the controller beforehand and no
+// other server can offer the same address.
+//
+// A client without a lease has been given one from the dynamic range, if any,
+// beforehand.
+func (a *DHCPAllocator) handleDiscover(nic string, m *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, *leaseUpdate) {
+	lease, ok := a.lookupLease(nic, m)
+	if !ok || !lease.RapidCommit || !m.Options.Has(dhcpv4.OptionRapidCommit) {
+		return a.buildReply(nic, m, dhcpv4.MessageTypeOffer), nil
+	}
+
+	reply := a.buildReply(nic, m, dhcpv4.MessageTypeAck)
+	if reply == nil {
+		return nil, nil
+	}
+	reply.UpdateOption(dhcpv4.OptGeneric(dhcpv4.OptionRapidCommit, nil))
+
+	return reply, a.bindUpdate(nic, m, lease, reply)
+}
+
+// handleRequest answers a DHCPREQUEST according to the state the client is
+// in, as described in RFC 2131 section 4.3.2:
+//   - SELECTING: the server identifier is set, and the requested address must
+//     match the lease. If the client selected another server, stay silent.
+//   - INIT-REBOOT: no server identifier but a requested address, which must
+//     match the lease. Stay silent for unknown clients.
+//   - RENEWING/REBINDING: neither server identifier nor requested address,
+//     and ciaddr must match the lease.
+//
+// A mismatch is answered with a DHCPNAK so that the client restarts in INIT
+// state and picks up its current address, e.g. after re-numbering.
+func (a *DHCPAllocator) handleRequest(nic string, m *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, *leaseUpdate) {
+	hwAddr := m.ClientHWAddr.String()
+	lease, ok := a.lookupLease(nic, m)
+
+	serverID := m.ServerIdentifier()
+	requestedIP := m.RequestedIPAddress()
+
+	switch {
+	case serverID != nil && !serverID.IsUnspecified():
+		if !ok || !serverID.Equal(lease.ServerIP) {
+			logrus.Debugf("(dhcp.dhcpHandler) hwaddr %s selected server %s", hwAddr, serverID)
+			return nil, nil
+		}
+		if !requestedIP.Equal(lease.ClientIP) {
+			return buildNak(m, lease, fmt.Sprintf("requested address %s is not offered", requestedIP)), nil
+		}
+	case requestedIP != nil && !requestedIP.IsUnspecified():
+		if !ok {
+			logrus.Debugf("(dhcp.dhcpHandler) no lease for hwaddr %s in INIT-REBOOT state", hwAddr)
+			return nil, nil
+		}
+		if !requestedIP.Equal(lease.ClientIP) {
+			return buildNak(m, lease, fmt.Sprintf("requested address %s is not leased", requestedIP)), nil
+		}
+	case m.ClientIPAddr != nil && !m.ClientIPAddr.IsUnspecified():
+		if !ok {
+			logrus.Debugf("(dhcp.dhcpHandler) no lease for hwaddr %s in RENEWING/REBINDING state", hwAddr)
+			return nil, nil
+		}
+		if !m.ClientIPAddr.Equal(lease.ClientIP) {
+			return buildNak(m, lease, fmt.Sprintf("address %s is not leased", m.ClientIPAddr)), nil
+		}
+	default:
+		logrus.Warnf("(dhcp.dhcpHandler) ignoring malformed DHCPREQUEST from hwaddr %s", hwAddr)
+		return nil, nil
+	}
+
+	reply := a.buildReply(nic, m, dhcpv4.MessageTypeAck)
+	if reply == nil {
+		return nil, nil
+	}
+
+	return reply, a.bindUpdate(nic, m, lease, reply)
+}
+
+// bindUpdate returns the binding of the lease the DHCPACK is sent for.
+func (a *DHCPAllocator) bindUpdate(nic string, m *dhcpv4.DHCPv4, lease DHCPLease, reply *dhcpv4.DHCPv4) *leaseUpdate {
+	return &leaseUpdate{
+		key:       a.leaseKeyOf(nic, m),
+		clientIP:  lease.ClientIP,
+		bind:      true,
+		hostname:  m.HostName(),
+		leaseTime: reply.IPAddressLeaseTime(0),
+	}
+}
+
+func buildNak(m *dhcpv4.DHCPv4, lease DHCPLease, message string) *dhcpv4.DHCPv4 {
+	logrus.Infof("(dhcp.dhcpHandler) DHCPNAK to hwaddr %s: %s", m.ClientHWAddr.String(), message)
+
+	reply, err := dhcpv4.NewReplyFromRequest(m,
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeNak),
+		dhcpv4.WithServerIP(net.IPv4zero),
+		dhcpv4.WithYourIP(net.IPv4zero),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(lease.ServerIP)),
+		dhcpv4.WithOption(dhcpv4.OptMessage(message)),
+	)
 	if err != nil {
 		logrus.Errorf("(dhcp.dhcpHandler) NewReplyFromRequest failed: %v", err)
-		return
+		return nil
 	}
+	reply.ClientIPAddr = net.IPv4zero
 
-	lease := a.leases[m.ClientHWAddr.String()]
+	// A DHCPNAK is broadcast unless it goes through a relay agent
+	if m.GatewayIPAddr == nil || m.GatewayIPAddr.IsUnspecified() {
+		reply.SetBroadcast()
+	}
 
-	if lease.ClientIP == nil {
-		logrus.Warnf("(dhcp.dhcpHandler) NO LEASE FOUND: hwaddr=%s", m.ClientHWAddr.String())
+	return reply
+}
 
-		return
+// replyAddr returns where the reply should be sent to. Replies to relayed
+// messages go to the server port of the relay agent. Apart from DHCPNAKs
+// that must be broadcast, other replies go back to where the request came
+// from.
+func replyAddr(peer net.Addr, reply *dhcpv4.DHCPv4) net.Addr {
+	if isRelayed(reply) {
+		return &net.UDPAddr{IP: reply.GatewayIPAddr, Port: dhcpv4.ServerPort}
+	}
+	if reply.MessageType() == dhcpv4.MessageTypeNak && reply.IsBroadcast() {
+		return &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ClientPort}
+	}
+	return peer
+}
+
+func replyType(reply *dhcpv4.DHCPv4) string {
+	if reply == nil {
+		return "NO REPLY"
+	}
+	return "DHCP" + reply.MessageType().String()
+}
+
+// buildReply answers a DHCPDISCOVER or DHCPREQUEST with the lease bound to
+// the client hardware address. It returns nil if there is no such lease.
+func (a *DHCPAllocator) buildReply(nic string, m *dhcpv4.DHCPv4, messageType dhcpv4.MessageType) *dhcpv4.DHCPv4 {
+	lease, ok := a.lookupLease(nic, m)
+	if !ok || lease.ClientIP == nil {
+		logrus.Debugf("(dhcp.dhcpHandler) NO LEASE FOUND: hwaddr=%s", m.ClientHWAddr.String())
+		// The health probe is not part of the traffic served
+		if !isHealthProbe(m) {
+			a.unknownClients.record(nic, m.ClientHWAddr.String())
+			a.hooks.unknownClient(nic)
+		}
+		return nil
 	}
 
 	logrus.Debugf("(dhcp.dhcpHandler) LEASE FOUND: hwaddr=%s, serverip=%s, clientip=%s, mask=%s, router=%s, dns=%+v, domainname=%s, domainsearch=%+v, ntp=%+v, leasetime=%d",
@@ -224,6 +766,12 @@ func (a *DHCPAllocator) dhcpHandler(conn net.PacketConn, peer net.Addr, m *dhcpv
 		lease.LeaseTime,
 	)
 
+	reply, err := dhcpv4.NewReplyFromRequest(m)
+	if err != nil {
+		logrus.Errorf("(dhcp.dhcpHandler) NewReplyFromRequest failed: %v", err)
+		return nil
+	}
+
 	reply.ClientIPAddr = lease.ClientIP
 	reply.ServerIPAddr = lease.ServerIP
 	reply.YourIPAddr = lease.ClientIP
@@ -232,6 +780,224 @@ func (a *DHCPAllocator) dhcpHandler(conn net.PacketConn, peer net.Addr, m *dhcpv
 	reply.Flags = m.Flags
 	reply.GatewayIPAddr = m.GatewayIPAddr
 
+	setHostnameOptions(reply, m, lease)
+	setConfigOptions(reply, lease)
+	setBootOptions(reply, m, lease)
+
+	if class := a.classOf(nic, m); class != nil {
+		setClassConfigOptions(reply, class)
+		setClassBootOptions(reply, class)
+	}
+
+	setLeaseTimeOptions(reply, lease)
+
+	reply.UpdateOption(dhcpv4.OptMessageType(messageType))
+
+	return reply
+}
+
+// buildInformReply answers a DHCPINFORM with the configuration parameters
+// only. As per RFC 2131 section 4.3.5, the reply carries neither an address
+// in yiaddr nor a lease time. A client without a lease of its own is still
+// answered if its address belongs to the subnet served by this agent, with
+// the configuration of the IPPool but none of the parameters of the lease it
+// is looked up with.
+func (a *DHCPAllocator) buildInformReply(nic string, m *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, *leaseUpdate) {
+	hwAddr := m.ClientHWAddr.String()
+
+	var update *leaseUpdate
+	lease, ok := a.lookupLease(nic, m)
+	if ok && lease.ClientIP.Equal(m.ClientIPAddr) {
+		update = a.stateUpdate(nic, m, lease, LeaseStateInformed)
+	} else if lease, ok = a.findLeaseBySubnet(nic, m.ClientIPAddr); ok {
+		lease = poolConfigOf(lease)
+	} else {
+		logrus.Debugf("(dhcp.dhcpHandler) NO LEASE FOUND: hwaddr=%s, clientip=%s", hwAddr, m.ClientIPAddr)
+		a.unknownClients.record(nic, hwAddr)
+		return nil, nil
+	}
+
+	reply, err := dhcpv4.NewReplyFromRequest(m)
+	if err != nil {
+		logrus.Errorf("(dhcp.dhcpHandler) NewReplyFromRequest failed: %v", err)
+		return nil, nil
+	}
+
+	reply.ClientIPAddr = m.ClientIPAddr
+	reply.YourIPAddr = net.IPv4zero
+	reply.ServerIPAddr = lease.ServerIP
+	reply.GatewayIPAddr = m.GatewayIPAddr
+
+	setConfigOptions(reply, lease)
+
+	if class := a.classOf(nic, m); class != nil {
+		setClassConfigOptions(reply, class)
+	}
+
+	reply.UpdateOption(dhcpv4.OptMessageType(dhcpv4.MessageTypeAck))
+
+	return reply, update
+}
+
+// handleRelease records a DHCPRELEASE against the lease of the client. The
+// binding itself is kept as it is owned by the VirtualMachineNetworkConfig.
+func (a *DHCPAllocator) handleRelease(nic string, m *dhcpv4.DHCPv4) *leaseUpdate {
+	hwAddr := m.ClientHWAddr.String()
+
+	lease, ok := a.lookupLease(nic, m)
+	if !ok || !lease.ClientIP.Equal(m.ClientIPAddr) {
+		logrus.Warnf("(dhcp.dhcpHandler) ignoring DHCPRELEASE of %s from hwaddr %s without matching lease", m.ClientIPAddr, hwAddr)
+		return nil
+	}
+
+	logrus.Infof("(dhcp.dhcpHandler) hwaddr %s released %s", hwAddr, lease.ClientIP)
+	return a.stateUpdate(nic, m, lease, LeaseStateReleased)
+}
+
+// handleDecline marks the lease of the client as declined, i.e. the client
+// found the address already in use on the link.
+func (a *DHCPAllocator) handleDecline(nic string, m *dhcpv4.DHCPv4) *leaseUpdate {
+	hwAddr := m.ClientHWAddr.String()
+
+	lease, ok := a.lookupLease(nic, m)
+	if !ok || !lease.ClientIP.Equal(m.RequestedIPAddress()) {
+		logrus.Warnf("(dhcp.dhcpHandler) ignoring DHCPDECLINE of %s from hwaddr %s without matching lease", m.RequestedIPAddress(), hwAddr)
+		return nil
+	}
+
+	logrus.Warnf("(dhcp.dhcpHandler) hwaddr %s declined %s: address conflict", hwAddr, lease.ClientIP)
+	return a.stateUpdate(nic, m, lease, LeaseStateDeclined)
+}
+
+// leaseUpdate is a change to a lease implied by a message of the client. It
+// is decided with the read lock held, and applied with the write lock held.
+type leaseUpdate struct {
+	key leaseKey
+	// The address of the lease the change was decided for
+	clientIP net.IP
+
+	// Either bind the lease, or set its state
+	bind      bool
+	hostname  string
+	leaseTime time.Duration
+	state     LeaseState
+}
+
+// stateUpdate returns the change of the state of the lease to state.
+func (a *DHCPAllocator) stateUpdate(nic string, m *dhcpv4.DHCPv4, lease DHCPLease, state LeaseState) *leaseUpdate {
+	return &leaseUpdate{
+		key:      a.leaseKeyOf(nic, m),
+		clientIP: lease.ClientIP,
+		state:    state,
+	}
+}
+
+// applyLeaseUpdate applies the change to the lease, unless the lease has been
+// replaced in the meantime.
+func (a *DHCPAllocator) applyLeaseUpdate(update *leaseUpdate) {
+	if update == nil {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if lease, ok := a.leases[update.key]; !ok || !lease.ClientIP.Equal(update.clientIP) {
+		return
+	}
+	if update.bind {
+		a.bindLease(update.key, update.hostname, update.leaseTime)
+		return
+	}
+	a.setLeaseState(update.key, update.state)
+}
+
+// leaseKeyOf returns the key of the lease of the client on nic. The client
+// identifier (option 61) is looked up first, so that a client presenting an
+// identifier unrelated to its hardware address still gets its lease.
+func (a *DHCPAllocator) leaseKeyOf(nic string, m *dhcpv4.DHCPv4) leaseKey {
+	if clientID := m.Options.Get(dhcpv4.OptionClientIdentifier); len(clientID) > 0 {
+		if hwAddr, ok := a.clientIDs[clientIDKey{nic: nic, clientID: formatClientID(clientID)}]; ok {
+			return leaseKey{nic: nic, hwAddr: hwAddr}
+		}
+	}
+	return leaseKey{nic: nic, hwAddr: m.ClientHWAddr.String()}
+}
+
+// lookupLease returns the lease of the client on nic. A relayed message only
+// gets the lease if it belongs to the network the relay agent serves.
+func (a *DHCPAllocator) lookupLease(nic string, m *dhcpv4.DHCPv4) (DHCPLease, bool) {
+	if isHealthProbe(m) {
+		lease, ok := a.healthLeases[nic]
+		return lease, ok
+	}
+
+	lease, ok := a.leases[a.leaseKeyOf(nic, m)]
+	if !ok || !isRelayed(m) {
+		return lease, ok
+	}
+	if !lease.servesRelay(m) {
+		logrus.Debugf("(dhcp.dhcpHandler) lease of hwaddr %s is not served through relay agent %s", m.ClientHWAddr.String(), m.GatewayIPAddr)
+		return DHCPLease{}, false
+	}
+	return lease, true
+}
+
+func (a *DHCPAllocator) findLeaseBySubnet(nic string, ip net.IP) (DHCPLease, bool) {
+	if ip == nil || ip.IsUnspecified() {
+		return DHCPLease{}, false
+	}
+	for key, lease := range a.leases {
+		if key.nic != nic {
+			continue
+		}
+		ipNet := net.IPNet{IP: lease.ClientIP.Mask(lease.SubnetMask), Mask: lease.SubnetMask}
+		if ipNet.Contains(ip) {
+			return lease, true
+		}
+	}
+	return DHCPLease{}, false
+}
+
+// poolConfigOf returns the configuration parameters of the lease shared by
+// every lease of its IPPool, i.e. without the custom options which may have
+// been set for the NIC the lease belongs to.
+func poolConfigOf(lease DHCPLease) DHCPLease {
+	return DHCPLease{
+		ServerIP:     lease.ServerIP,
+		SubnetMask:   lease.SubnetMask,
+		Router:       lease.Router,
+		DNS:          lease.DNS,
+		DomainName:   lease.DomainName,
+		DomainSearch: lease.DomainSearch,
+		NTP:          lease.NTP,
+		Routes:       lease.Routes,
+	}
+}
+
+// setLeaseTimeOptions sets the lease time along with the renewal (T1) and
+// rebinding (T2) times, if any.
+func setLeaseTimeOptions(reply *dhcpv4.DHCPv4, lease DHCPLease) {
+	if lease.InfiniteLease {
+		reply.UpdateOption(dhcpv4.OptIPAddressLeaseTime(infiniteLeaseTime * time.Second))
+		return
+	}
+
+	if lease.LeaseTime > 0 {
+		reply.UpdateOption(dhcpv4.OptIPAddressLeaseTime(time.Duration(lease.LeaseTime) * time.Second))
+	} else {
+		reply.UpdateOption(dhcpv4.OptIPAddressLeaseTime(util.DefaultLeaseTime * time.Second))
+	}
+
+	if lease.RenewalTime > 0 {
+		reply.UpdateOption(dhcpv4.OptRenewTimeValue(time.Duration(lease.RenewalTime) * time.Second))
+	}
+	if lease.RebindingTime > 0 {
+		reply.UpdateOption(dhcpv4.OptRebindingTimeValue(time.Duration(lease.RebindingTime) * time.Second))
+	}
+}
+
+func setConfigOptions(reply *dhcpv4.DHCPv4, lease DHCPLease) {
 	reply.UpdateOption(dhcpv4.OptServerIdentifier(lease.ServerIP))
 	reply.UpdateOption(dhcpv4.OptSubnetMask(lease.SubnetMask))
 	reply.UpdateOption(dhcpv4.OptRouter(lease.Router))
@@ -255,30 +1021,152 @@ func (a *DHCPAllocator) dhcpHandler(conn net.PacketConn, peer net.Addr, m *dhcpv
 		reply.UpdateOption(dhcpv4.OptNTPServers(lease.NTP...))
 	}
 
-	if lease.LeaseTime > 0 {
-		reply.UpdateOption(dhcpv4.OptIPAddressLeaseTime(time.Duration(lease.LeaseTime) * time.Second))
+	if len(lease.Routes) > 0 {
+		routes := classlessRoutes(lease)
+		reply.UpdateOption(dhcpv4.OptClasslessStaticRoute(routes...))
+		reply.UpdateOption(dhcpv4.Option{Code: optionMSClasslessStaticRoute, Value: routes})
+	}
+
+	for code, value := range lease.CustomOptions {
+		reply.UpdateOption(dhcpv4.OptGeneric(dhcpv4.GenericOptionCode(code), value))
+	}
+}
+
+// classlessRoutes returns the static routes of the lease. Clients ignore the
+// router option once classless static routes are given (RFC 3442), so the
+// default route via the router is appended unless there is one already.
+func classlessRoutes(lease DHCPLease) dhcpv4.Routes {
+	if lease.Router == nil || lease.Router.IsUnspecified() {
+		return lease.Routes
+	}
+
+	for _, route := range lease.Routes {
+		if ones, _ := route.Dest.Mask.Size(); ones == 0 {
+			return lease.Routes
+		}
+	}
+
+	routes := append(dhcpv4.Routes{}, lease.Routes...)
+	return append(routes, &dhcpv4.Route{
+		Dest:   &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)},
+		Router: lease.Router.To4(),
+	})
+}
+
+// setLeaseState must be called with the write lock held.
+func (a *DHCPAllocator) setLeaseState(key leaseKey, state LeaseState) {
+	lease, ok := a.leases[key]
+	if !ok || lease.State == state {
+		return
+	}
+
+	lease.State = state
+	lease.StateTime = time.Now()
+	a.leases[key] = lease
+
+	a.notifyLeaseState(key.nic)
+}
+
+// bindLease records the DHCPACK of a lease. The bound time is kept across
+// renewals until the client stops using the lease, e.g. by releasing it. Only
+// a newly bound lease or a changed host name is signaled as a state change,
+// plain renewals are signaled apart. Must be called with the write lock held.
+func (a *DHCPAllocator) bindLease(key leaseKey, hostname string, leaseTime time.Duration) {
+	lease, ok := a.leases[key]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	stateChanged := lease.State != LeaseStateBound || (hostname != "" && hostname != lease.ClientHostname)
+	if lease.State != LeaseStateBound {
+		lease.State = LeaseStateBound
+		lease.StateTime = now
+		lease.BoundTime = now
+	}
+	lease.RenewTime = now
+	lease.ExpiryTime = now.Add(leaseTime)
+	if lease.InfiniteLease {
+		lease.ExpiryTime = time.Time{}
+	}
+	if hostname != "" {
+		lease.ClientHostname = hostname
+	}
+	a.leases[key] = lease
+
+	logrus.Debugf("(dhcp.dhcpHandler) hwaddr %s bound %s until %s", key.hwAddr, lease.ClientIP, lease.ExpiryTime.Format(time.RFC3339))
+
+	if stateChanged {
+		a.notifyLeaseState(key.nic)
 	} else {
-		// default lease time: 1 year
-		reply.UpdateOption(dhcpv4.OptIPAddressLeaseTime(31536000 * time.Second))
+		a.notifyLeaseRenewal(key.nic)
 	}
+}
 
-	switch messageType := m.MessageType(); messageType {
-	case dhcpv4.MessageTypeDiscover:
-		logrus.Debugf("(dhcp.dhcpHandler) DHCPDISCOVER: %+v", m)
-		reply.UpdateOption(dhcpv4.OptMessageType(dhcpv4.MessageTypeOffer))
-		logrus.Debugf("(dhcp.dhcpHandler) DHCPOFFER: %+v", reply)
-	case dhcpv4.MessageTypeRequest:
-		logrus.Debugf("(dhcp.dhcpHandler) DHCPREQUEST: %+v", m)
-		reply.UpdateOption(dhcpv4.OptMessageType(dhcpv4.MessageTypeAck))
-		logrus.Debugf("(dhcp.dhcpHandler) DHCPACK: %+v", reply)
+// stateChOf must be called with the write lock held.
+func (a *DHCPAllocator) stateChOf(nic string) chan struct{} {
+	if a.stateChs[nic] == nil {
+		a.stateChs[nic] = make(chan struct{}, 1)
+	}
+	return a.stateChs[nic]
+}
+
+func (a *DHCPAllocator) notifyLeaseState(nic string) {
+	select {
+	case a.stateChOf(nic) <- struct{}{}:
 	default:
-		logrus.Warnf("(dhcp.dhcpHandler) Unhandled message type for hwaddr [%s]: %v", m.ClientHWAddr.String(), messageType)
-		return
 	}
+}
 
-	if _, err := conn.WriteTo(reply.ToBytes(), peer); err != nil {
-		logrus.Errorf("(dhcp.dhcpHandler) Cannot reply to client: %v", err)
+// LeaseStateChanged returns a channel which is signaled whenever the state of
+// a lease on nic has changed, renewals aside. Multiple changes might be
+// coalesced into one signal.
+func (a *DHCPAllocator) LeaseStateChanged(nic string) <-chan struct{} {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.stateChOf(nic)
+}
+
+// renewChOf must be called with the write lock held.
+func (a *DHCPAllocator) renewChOf(nic string) chan struct{} {
+	if a.renewChs[nic] == nil {
+		a.renewChs[nic] = make(chan struct{}, 1)
+	}
+	return a.renewChs[nic]
+}
+
+func (a *DHCPAllocator) notifyLeaseRenewal(nic string) {
+	select {
+	case a.renewChOf(nic) <- struct{}{}:
+	default:
+	}
+}
+
+// LeaseRenewed returns a channel which is signaled whenever a bound lease on
+// nic has been renewed. Renewals are coalesced into one signal until it is
+// received, so that they can be reported in batches.
+func (a *DHCPAllocator) LeaseRenewed(nic string) <-chan struct{} {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.renewChOf(nic)
+}
+
+// ListLeaseStates returns the static leases on nic, keyed by hardware
+// address, that have seen any client activity.
+func (a *DHCPAllocator) ListLeaseStates(nic string) map[string]DHCPLease {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	leases := make(map[string]DHCPLease)
+	for key, lease := range a.leases {
+		if key.nic == nic && lease.State != "" && !lease.Dynamic {
+			leases[key.hwAddr] = lease
+		}
 	}
+
+	return leases
 }
 
 func (a *DHCPAllocator) Run(ctx context.Context, nic string) (err error) {
@@ -292,7 +1180,9 @@ func (a *DHCPAllocator) Run(ctx context.Context, nic string) (err error) {
 		Port: 67,
 	}
 
-	server, err = server4.NewServer(nic, &laddr, a.dhcpHandler)
+	server, err = server4.NewServer(nic, &laddr, func(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+		a.dhcpHandler(nic, conn, peer, m)
+	})
 	if err != nil {
 		return
 	}
@@ -303,7 +1193,12 @@ func (a *DHCPAllocator) Run(ctx context.Context, nic string) (err error) {
 		}
 	}()
 
-	a.servers[nic] = server
+	a.mutex.Lock()
+	a.serversOf(nic).server4 = server
+	a.mutex.Unlock()
+
+	go a.reportUnknownClients(ctx, nic)
+	go a.reclaimDynamicLeases(ctx, nic)
 
 	return nil
 }
@@ -311,35 +1206,76 @@ func (a *DHCPAllocator) Run(ctx context.Context, nic string) (err error) {
 func (a *DHCPAllocator) DryRun(ctx context.Context, nic string) (err error) {
 	logrus.Infof("(dhcp.DryRun) starting DHCP service on nic %s", nic)
 
-	var server *server4.Server
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
 
-	a.servers[nic] = server
+	a.servers[nic] = &dhcpServers{}
 
 	return nil
 }
 
+// serversOf must be called with the write lock held.
+func (a *DHCPAllocator) serversOf(nic string) *dhcpServers {
+	if a.servers[nic] == nil {
+		a.servers[nic] = &dhcpServers{}
+	}
+	return a.servers[nic]
+}
+
 func (a *DHCPAllocator) stop(nic string) (err error) {
 	logrus.Infof("(dhcp.Stop) stopping DHCP service on nic %s", nic)
 
-	if a.servers[nic] == nil {
+	a.mutex.RLock()
+	servers := a.servers[nic]
+	a.mutex.RUnlock()
+	if servers == nil {
+		return nil
+	}
+
+	if servers.server6 != nil {
+		if err := servers.server6.Close(); err != nil {
+			logrus.Errorf("(dhcp.Stop) cannot stop DHCPv6 service on nic %s: %v", nic, err)
+		}
+	}
+
+	if servers.server4 == nil {
 		return nil
 	}
 
-	return a.servers[nic].Close()
+	return servers.server4.Close()
 }
 
+// ListAll returns all the leases keyed by network interface and hardware
+// address, or network interface and IPv6 address, e.g. "eth1/<mac>".
 func (a *DHCPAllocator) ListAll(name string) (map[string]string, error) {
 	a.mutex.RLock()
 	defer a.mutex.RUnlock()
 
-	leases := make(map[string]string, len(a.leases))
-	for mac, lease := range a.leases {
-		leases[mac] = lease.String()
+	leases := make(map[string]string, len(a.leases)+len(a.leases6))
+	for key, lease := range a.leases {
+		leases[key.String()] = lease.String()
+	}
+	for key, lease := range a.leases6 {
+		leases[key.String()] = lease.String()
 	}
 
 	return leases, nil
 }
 
+// LeaseCount returns the number of leases on nic, static and dynamic ones.
+func (a *DHCPAllocator) LeaseCount(nic string) int {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	count := 0
+	for key := range a.leases {
+		if key.nic == nic {
+			count++
+		}
+	}
+	return count
+}
+
 func Cleanup(ctx context.Context, a *DHCPAllocator, nic string) <-chan error {
 	errCh := make(chan error)
 
@@ -4,8 +4,30 @@ import (
 	"fmt"
 	"net"
 	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"github.com/harvester/vm-dhcp-controller/pkg/util"
 )
 
+const testNIC = "eth1"
+
+// testPacketConn records the packets written by a handler so that tests can
+// inspect the replies without opening a socket.
+type testPacketConn struct {
+	net.PacketConn
+
+	written [][]byte
+	addrs   []net.Addr
+}
+
+func (c *testPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.written = append(c.written, append([]byte(nil), b...))
+	c.addrs = append(c.addrs, addr)
+	return len(b), nil
+}
+
 func TestDHCP(t *testing.T) {
 	td := New()
 
@@ -125,6 +147,7 @@ func TestDHCP(t *testing.T) {
 	// AddLease function tests
 	for i := 0; i < len(testLeases); i++ {
 		if got := td.AddLease(
+			testNIC,
 			testLeases[i].hwAddr,
 			testLeases[i].serverIP,
 			testLeases[i].clientIP,
@@ -145,37 +168,984 @@ func TestDHCP(t *testing.T) {
 	}
 
 	// GetLease function tests
-	lease1 := td.GetLease("aa:bb:cc:dd:ee:ff")
+	lease1 := td.GetLease(testNIC, "aa:bb:cc:dd:ee:ff")
 	if !lease1.ClientIP.Equal(net.ParseIP(testLeases[0].clientIP)) {
 		t.Errorf("got %q, wanted %q", lease1.ClientIP.String(), testLeases[1].clientIP)
 	}
-	lease2 := td.GetLease("ff:ee:dd:cc:bb:aa")
+	lease2 := td.GetLease(testNIC, "ff:ee:dd:cc:bb:aa")
 	if len(lease2.ClientIP) > 0 {
 		t.Errorf("got %q, wanted nil", lease2.ClientIP.String())
 	}
 
 	// checkLease function tests
-	if !td.checkLease("aa:bb:cc:dd:ee:ff") {
+	if !td.checkLease(leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:ff"}) {
 		t.Errorf("got false, wanted true for hwAddr aa:bb:cc:dd:ee:ff")
 	}
-	if td.checkLease("00:11:22:33:44:55") {
+	if td.checkLease(leaseKey{nic: testNIC, hwAddr: "00:11:22:33:44:55"}) {
 		t.Errorf("got true, wanted false for hwAddr 00:11:22:33:44:55")
 	}
-	if td.checkLease("ff:ee:dd:cc:bb:aa") {
+	if td.checkLease(leaseKey{nic: testNIC, hwAddr: "ff:ee:dd:cc:bb:aa"}) {
 		t.Errorf("got true, wanted false for hwAddr ff:ee:dd:cc:bb:aa")
 	}
-	if !td.checkLease("00:01:02:03:04:05") {
+	if !td.checkLease(leaseKey{nic: testNIC, hwAddr: "00:01:02:03:04:05"}) {
 		t.Errorf("got false, wanted true for hwAddr 00:01:02:03:04:05")
 	}
 
 	// DeleteLease function tests
-	if got := td.DeleteLease("aa:bb:cc:dd:ee:ff"); got != nil {
+	if got := td.DeleteLease(testNIC, "aa:bb:cc:dd:ee:ff"); got != nil {
 		t.Errorf("got %q, wanted nil", got)
 	}
-	if got := td.DeleteLease("aa:bb:cc:dd:ee:ff"); got != nil {
+	if got := td.DeleteLease(testNIC, "aa:bb:cc:dd:ee:ff"); got != nil {
 		wanted := "lease for hwaddr aa:bb:cc:dd:ee:ff does not exists"
 		if got.Error() != wanted {
 			t.Errorf("got %q, wanted %q", got, wanted)
 		}
 	}
 }
+
+func TestDHCPHandler(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	unknownHWAddr, _ := net.ParseMAC("00:11:22:33:44:55")
+	clientIP := net.ParseIP("192.168.0.10")
+	otherIP := net.ParseIP("192.168.0.20")
+	peer := &net.UDPAddr{IP: clientIP, Port: dhcpv4.ClientPort}
+	nicOption := dhcpv4.GenericOptionCode(224)
+
+	newTestAllocator := func() *DHCPAllocator {
+		td := New()
+		if err := td.AddLease(
+			testNIC,
+			hwAddr.String(),
+			"192.168.0.2",
+			clientIP.String(),
+			"192.168.0.0/24",
+			"192.168.0.254",
+			[]string{"8.8.8.8"},
+			func(s string) *string { return &s }("example.com"),
+			nil,
+			nil,
+			nil,
+			WithCustomOption(uint8(nicOption), []byte("nic")),
+		); err != nil {
+			t.Fatal(err)
+		}
+		return td
+	}
+
+	newMessage := func(messageType dhcpv4.MessageType, chaddr net.HardwareAddr, modifiers ...dhcpv4.Modifier) *dhcpv4.DHCPv4 {
+		m, err := dhcpv4.New(append([]dhcpv4.Modifier{
+			dhcpv4.WithMessageType(messageType),
+			dhcpv4.WithHwAddr(chaddr),
+		}, modifiers...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	t.Run("inform from known client", func(t *testing.T) {
+		td := newTestAllocator()
+		conn := &testPacketConn{}
+
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeInform, hwAddr, dhcpv4.WithClientIP(clientIP)))
+
+		if len(conn.written) != 1 {
+			t.Fatalf("got %d replies, wanted 1", len(conn.written))
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.MessageType() != dhcpv4.MessageTypeAck {
+			t.Errorf("got %s, wanted %s", reply.MessageType(), dhcpv4.MessageTypeAck)
+		}
+		if !reply.YourIPAddr.IsUnspecified() {
+			t.Errorf("got yiaddr %s, wanted 0.0.0.0", reply.YourIPAddr)
+		}
+		if reply.Options.Has(dhcpv4.OptionIPAddressLeaseTime) {
+			t.Errorf("got lease time option, wanted none")
+		}
+		if got := reply.DomainName(); got != "example.com" {
+			t.Errorf("got domain name %q, wanted %q", got, "example.com")
+		}
+		if got := string(reply.Options.Get(nicOption)); got != "nic" {
+			t.Errorf("got option %s %q, wanted %q", nicOption, got, "nic")
+		}
+		if got := td.GetLease(testNIC, hwAddr.String()).State; got != LeaseStateInformed {
+			t.Errorf("got state %q, wanted %q", got, LeaseStateInformed)
+		}
+	})
+
+	t.Run("inform from unknown client within subnet", func(t *testing.T) {
+		td := newTestAllocator()
+		conn := &testPacketConn{}
+
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeInform, unknownHWAddr, dhcpv4.WithClientIP(otherIP)))
+
+		if len(conn.written) != 1 {
+			t.Fatalf("got %d replies, wanted 1", len(conn.written))
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := reply.DomainName(); got != "example.com" {
+			t.Errorf("got domain name %q, wanted %q", got, "example.com")
+		}
+		if reply.Options.Has(nicOption) {
+			t.Errorf("got option %s of another client, wanted none", nicOption)
+		}
+		if got := td.GetLease(testNIC, hwAddr.String()).State; got != "" {
+			t.Errorf("got state %q, wanted none", got)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		td := newTestAllocator()
+		conn := &testPacketConn{}
+
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeRelease, hwAddr, dhcpv4.WithClientIP(clientIP)))
+
+		if len(conn.written) != 0 {
+			t.Errorf("got %d replies, wanted none", len(conn.written))
+		}
+		if got := td.GetLease(testNIC, hwAddr.String()).State; got != LeaseStateReleased {
+			t.Errorf("got state %q, wanted %q", got, LeaseStateReleased)
+		}
+		select {
+		case <-td.LeaseStateChanged(testNIC):
+		default:
+			t.Errorf("lease state change was not signaled")
+		}
+		if got := len(td.ListLeaseStates(testNIC)); got != 1 {
+			t.Errorf("got %d lease states, wanted 1", got)
+		}
+	})
+
+	t.Run("release of another address", func(t *testing.T) {
+		td := newTestAllocator()
+		conn := &testPacketConn{}
+
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeRelease, hwAddr, dhcpv4.WithClientIP(otherIP)))
+
+		if got := td.GetLease(testNIC, hwAddr.String()).State; got != "" {
+			t.Errorf("got state %q, wanted none", got)
+		}
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		td := newTestAllocator()
+		conn := &testPacketConn{}
+
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeDecline, hwAddr, dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(clientIP))))
+
+		if len(conn.written) != 0 {
+			t.Errorf("got %d replies, wanted none", len(conn.written))
+		}
+		if got := td.GetLease(testNIC, hwAddr.String()).State; got != LeaseStateDeclined {
+			t.Errorf("got state %q, wanted %q", got, LeaseStateDeclined)
+		}
+	})
+
+	t.Run("request after decline", func(t *testing.T) {
+		td := newTestAllocator()
+		conn := &testPacketConn{}
+
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeDecline, hwAddr, dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(clientIP))))
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeRequest, hwAddr, dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(clientIP))))
+
+		if len(conn.written) != 1 {
+			t.Fatalf("got %d replies, wanted 1", len(conn.written))
+		}
+		if got := td.GetLease(testNIC, hwAddr.String()).State; got != LeaseStateBound {
+			t.Errorf("got state %q, wanted %q", got, LeaseStateBound)
+		}
+	})
+
+	t.Run("bind and renew", func(t *testing.T) {
+		td := newTestAllocator()
+		conn := &testPacketConn{}
+
+		before := time.Now()
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeRequest, hwAddr,
+			dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(clientIP)),
+			dhcpv4.WithOption(dhcpv4.OptHostName("vm-1")),
+		))
+
+		lease := td.GetLease(testNIC, hwAddr.String())
+		if lease.State != LeaseStateBound {
+			t.Errorf("got state %q, wanted %q", lease.State, LeaseStateBound)
+		}
+		if lease.ClientHostname != "vm-1" {
+			t.Errorf("got hostname %q, wanted %q", lease.ClientHostname, "vm-1")
+		}
+		if lease.BoundTime.Before(before) || !lease.RenewTime.Equal(lease.BoundTime) {
+			t.Errorf("got bound time %s and renew time %s, wanted both at bind", lease.BoundTime, lease.RenewTime)
+		}
+		if got := lease.ExpiryTime.Sub(lease.RenewTime); got != util.DefaultLeaseTime*time.Second {
+			t.Errorf("got lease duration %s, wanted %s", got, util.DefaultLeaseTime*time.Second)
+		}
+
+		select {
+		case <-td.LeaseStateChanged(testNIC):
+		default:
+			t.Errorf("got no lease state change, wanted one")
+		}
+
+		td.dhcpHandler(testNIC, conn, peer, newMessage(dhcpv4.MessageTypeRequest, hwAddr, dhcpv4.WithClientIP(clientIP)))
+
+		renewed := td.GetLease(testNIC, hwAddr.String())
+		if !renewed.BoundTime.Equal(lease.BoundTime) {
+			t.Errorf("got bound time %s, wanted %s", renewed.BoundTime, lease.BoundTime)
+		}
+		if renewed.RenewTime.Before(lease.RenewTime) {
+			t.Errorf("got renew time %s, wanted after %s", renewed.RenewTime, lease.RenewTime)
+		}
+		if renewed.ClientHostname != "vm-1" {
+			t.Errorf("got hostname %q, wanted %q", renewed.ClientHostname, "vm-1")
+		}
+
+		select {
+		case <-td.LeaseStateChanged(testNIC):
+			t.Errorf("got lease state change on renewal, wanted none")
+		default:
+		}
+		select {
+		case <-td.LeaseRenewed(testNIC):
+		default:
+			t.Errorf("got no lease renewal, wanted one")
+		}
+	})
+}
+
+func TestDHCPHandlerRequest(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	unknownHWAddr, _ := net.ParseMAC("00:11:22:33:44:55")
+	serverIP := net.ParseIP("192.168.0.2")
+	otherServerIP := net.ParseIP("192.168.0.3")
+	clientIP := net.ParseIP("192.168.0.10")
+	staleIP := net.ParseIP("192.168.0.20")
+	relayIP := net.ParseIP("192.168.0.1")
+
+	td := New()
+	if err := td.AddLease(testNIC, hwAddr.String(), serverIP.String(), clientIP.String(), "192.168.0.0/24", "192.168.0.254", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		chaddr        net.HardwareAddr
+		serverID      net.IP
+		requestedIP   net.IP
+		ciaddr        net.IP
+		giaddr        net.IP
+		broadcast     bool
+		wantType      dhcpv4.MessageType
+		wantBroadcast bool
+	}{
+		{
+			name:        "selecting",
+			chaddr:      hwAddr,
+			serverID:    serverIP,
+			requestedIP: clientIP,
+			wantType:    dhcpv4.MessageTypeAck,
+		},
+		{
+			name:          "selecting with wrong address",
+			chaddr:        hwAddr,
+			serverID:      serverIP,
+			requestedIP:   staleIP,
+			wantType:      dhcpv4.MessageTypeNak,
+			wantBroadcast: true,
+		},
+		{
+			name:        "selecting another server",
+			chaddr:      hwAddr,
+			serverID:    otherServerIP,
+			requestedIP: clientIP,
+		},
+		{
+			name:        "init-reboot",
+			chaddr:      hwAddr,
+			requestedIP: clientIP,
+			wantType:    dhcpv4.MessageTypeAck,
+		},
+		{
+			name:          "init-reboot with stale address",
+			chaddr:        hwAddr,
+			requestedIP:   staleIP,
+			wantType:      dhcpv4.MessageTypeNak,
+			wantBroadcast: true,
+		},
+		{
+			name:        "init-reboot with stale address through relay",
+			chaddr:      hwAddr,
+			requestedIP: staleIP,
+			giaddr:      relayIP,
+			wantType:    dhcpv4.MessageTypeNak,
+		},
+		{
+			name:        "init-reboot from unknown client",
+			chaddr:      unknownHWAddr,
+			requestedIP: clientIP,
+		},
+		{
+			name:     "renewing",
+			chaddr:   hwAddr,
+			ciaddr:   clientIP,
+			wantType: dhcpv4.MessageTypeAck,
+		},
+		{
+			name:          "renewing with stale address",
+			chaddr:        hwAddr,
+			ciaddr:        staleIP,
+			wantType:      dhcpv4.MessageTypeNak,
+			wantBroadcast: true,
+		},
+		{
+			name:      "rebinding",
+			chaddr:    hwAddr,
+			ciaddr:    clientIP,
+			broadcast: true,
+			wantType:  dhcpv4.MessageTypeAck,
+		},
+		{
+			name:          "rebinding with stale address",
+			chaddr:        hwAddr,
+			ciaddr:        staleIP,
+			broadcast:     true,
+			wantType:      dhcpv4.MessageTypeNak,
+			wantBroadcast: true,
+		},
+		{
+			name:   "rebinding from unknown client",
+			chaddr: unknownHWAddr,
+			ciaddr: staleIP,
+		},
+		{
+			name:   "malformed",
+			chaddr: hwAddr,
+		},
+	}
+
+	for _, tc := range testCases {
+		modifiers := []dhcpv4.Modifier{
+			dhcpv4.WithMessageType(dhcpv4.MessageTypeRequest),
+			dhcpv4.WithHwAddr(tc.chaddr),
+		}
+		if tc.serverID != nil {
+			modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptServerIdentifier(tc.serverID)))
+		}
+		if tc.requestedIP != nil {
+			modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(tc.requestedIP)))
+		}
+		if tc.ciaddr != nil {
+			modifiers = append(modifiers, dhcpv4.WithClientIP(tc.ciaddr))
+		}
+		if tc.giaddr != nil {
+			modifiers = append(modifiers, dhcpv4.WithGatewayIP(tc.giaddr))
+		}
+		if tc.broadcast {
+			modifiers = append(modifiers, dhcpv4.WithBroadcast(true))
+		}
+		m, err := dhcpv4.New(modifiers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		peer := &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}
+		if tc.giaddr != nil {
+			peer = &net.UDPAddr{IP: tc.giaddr, Port: dhcpv4.ServerPort}
+		}
+
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, peer, m)
+
+		if tc.wantType == dhcpv4.MessageTypeNone {
+			if len(conn.written) != 0 {
+				t.Errorf("%s: got %d replies, wanted none", tc.name, len(conn.written))
+			}
+			continue
+		}
+		if len(conn.written) != 1 {
+			t.Errorf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+			continue
+		}
+
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.MessageType() != tc.wantType {
+			t.Errorf("%s: got %s, wanted %s", tc.name, reply.MessageType(), tc.wantType)
+		}
+		if tc.wantType == dhcpv4.MessageTypeAck && !reply.YourIPAddr.Equal(clientIP) {
+			t.Errorf("%s: got yiaddr %s, wanted %s", tc.name, reply.YourIPAddr, clientIP)
+		}
+		if tc.wantType == dhcpv4.MessageTypeNak {
+			if !reply.YourIPAddr.IsUnspecified() {
+				t.Errorf("%s: got yiaddr %s, wanted 0.0.0.0", tc.name, reply.YourIPAddr)
+			}
+			if !reply.ServerIdentifier().Equal(serverIP) {
+				t.Errorf("%s: got server identifier %s, wanted %s", tc.name, reply.ServerIdentifier(), serverIP)
+			}
+			if reply.IsBroadcast() != tc.wantBroadcast {
+				t.Errorf("%s: got broadcast %t, wanted %t", tc.name, reply.IsBroadcast(), tc.wantBroadcast)
+			}
+		}
+
+		wantAddr := net.Addr(peer)
+		if tc.wantBroadcast {
+			wantAddr = &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ClientPort}
+		}
+		if conn.addrs[0].String() != wantAddr.String() {
+			t.Errorf("%s: got destination %s, wanted %s", tc.name, conn.addrs[0], wantAddr)
+		}
+	}
+}
+
+func TestDHCPHandlerRoutes(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	clientIP := net.ParseIP("192.168.0.10")
+
+	td := New()
+	if err := td.AddLease(
+		testNIC,
+		hwAddr.String(),
+		"192.168.0.2",
+		clientIP.String(),
+		"192.168.0.0/24",
+		"192.168.0.1",
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		WithRoute("10.10.0.0/16", "192.168.0.254"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "route to 10.10.0.0/16 via 192.168.0.254; route to 0.0.0.0/0 via 192.168.0.1"
+
+	m, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &testPacketConn{}
+	td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+	if len(conn.written) != 1 {
+		t.Fatalf("got %d replies, wanted 1", len(conn.written))
+	}
+	reply, err := dhcpv4.FromBytes(conn.written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range []dhcpv4.OptionCode{dhcpv4.OptionClasslessStaticRoute, optionMSClasslessStaticRoute} {
+		var routes dhcpv4.Routes
+		if err := routes.FromBytes(reply.Options.Get(code)); err != nil {
+			t.Errorf("option %s: %v", code, err)
+			continue
+		}
+		if routes.String() != want {
+			t.Errorf("option %s: got %s, wanted %s", code, routes, want)
+		}
+	}
+
+	testRoutes := []struct {
+		destination string
+		gateway     string
+		want        error
+	}{
+		{
+			destination: "10.10.0.0/16",
+			gateway:     "fd00::1",
+			want:        fmt.Errorf("route gateway fd00::1 is not a valid ipv4 address"),
+		},
+		{
+			destination: "fd00::/64",
+			gateway:     "192.168.0.254",
+			want:        fmt.Errorf("route destination fd00::/64 is not a valid ipv4 cidr"),
+		},
+	}
+	for _, tc := range testRoutes {
+		got := td.AddLease(testNIC, "00:11:22:33:44:55", "192.168.0.2", "192.168.0.11", "192.168.0.0/24", "", nil, nil, nil, nil, nil, WithRoute(tc.destination, tc.gateway))
+		if got == nil || got.Error() != tc.want.Error() {
+			t.Errorf("got %q, wanted %q", got, tc.want)
+		}
+	}
+}
+
+func TestDHCPHandlerCustomOptions(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+	td := New()
+	if err := td.AddLease(
+		testNIC,
+		hwAddr.String(),
+		"192.168.0.2",
+		"192.168.0.10",
+		"192.168.0.0/24",
+		"192.168.0.1",
+		[]string{"8.8.8.8"},
+		nil,
+		nil,
+		nil,
+		nil,
+		WithCustomOption(26, []byte{0x23, 0x28}),
+		WithCustomOption(6, []byte{1, 1, 1, 1}),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &testPacketConn{}
+	td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+	if len(conn.written) != 1 {
+		t.Fatalf("got %d replies, wanted 1", len(conn.written))
+	}
+	reply, err := dhcpv4.FromBytes(conn.written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := reply.Options.Get(dhcpv4.OptionInterfaceMTU); string(got) != string([]byte{0x23, 0x28}) {
+		t.Errorf("got mtu %v, wanted [35 40]", got)
+	}
+	if got := reply.DNS(); len(got) != 1 || !got[0].Equal(net.ParseIP("1.1.1.1")) {
+		t.Errorf("got dns %v, wanted [1.1.1.1]", got)
+	}
+}
+
+func TestDHCPHandlerInterfaces(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	leases := []struct {
+		nic      string
+		serverIP string
+		clientIP string
+		cidr     string
+	}{
+		{
+			nic:      "eth1",
+			serverIP: "192.168.0.2",
+			clientIP: "192.168.0.10",
+			cidr:     "192.168.0.0/24",
+		},
+		{
+			nic:      "eth2",
+			serverIP: "10.0.0.2",
+			clientIP: "10.0.0.10",
+			cidr:     "10.0.0.0/24",
+		},
+	}
+
+	td := New()
+	for _, lease := range leases {
+		if err := td.AddLease(lease.nic, hwAddr.String(), lease.serverIP, lease.clientIP, lease.cidr, "", nil, nil, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, lease := range leases {
+		m, err := dhcpv4.NewDiscovery(hwAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := &testPacketConn{}
+		td.dhcpHandler(lease.nic, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		if len(conn.written) != 1 {
+			t.Fatalf("%s: got %d replies, wanted 1", lease.nic, len(conn.written))
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := reply.YourIPAddr.String(); got != lease.clientIP {
+			t.Errorf("%s: got yiaddr %s, wanted %s", lease.nic, got, lease.clientIP)
+		}
+		if got := reply.ServerIdentifier().String(); got != lease.serverIP {
+			t.Errorf("%s: got server identifier %s, wanted %s", lease.nic, got, lease.serverIP)
+		}
+	}
+
+	// A client on an interface without any lease gets no answer
+	m, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &testPacketConn{}
+	td.dhcpHandler("eth3", conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+	if len(conn.written) != 0 {
+		t.Errorf("eth3: got %d replies, wanted none", len(conn.written))
+	}
+
+	// Lease states are tracked per interface
+	m, err = dhcpv4.New(
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeRelease),
+		dhcpv4.WithHwAddr(hwAddr),
+		dhcpv4.WithClientIP(net.ParseIP("10.0.0.10")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	td.dhcpHandler("eth2", &testPacketConn{}, &net.UDPAddr{IP: net.ParseIP("10.0.0.10"), Port: dhcpv4.ClientPort}, m)
+	if got := len(td.ListLeaseStates("eth1")); got != 0 {
+		t.Errorf("eth1: got %d lease states, wanted none", got)
+	}
+	if got := td.ListLeaseStates("eth2")[hwAddr.String()].State; got != LeaseStateReleased {
+		t.Errorf("eth2: got lease state %q, wanted %q", got, LeaseStateReleased)
+	}
+}
+
+func TestDHCPHandlerLeaseTimes(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	leaseTime := 3600
+
+	testCases := []struct {
+		name          string
+		opts          []LeaseOption
+		leaseTime     time.Duration
+		renewalTime   time.Duration
+		rebindingTime time.Duration
+	}{
+		{
+			name:      "lease time only",
+			leaseTime: time.Hour,
+		},
+		{
+			name:          "renewal and rebinding times",
+			opts:          []LeaseOption{WithRenewalTime(1200), WithRebindingTime(2400)},
+			leaseTime:     time.Hour,
+			renewalTime:   20 * time.Minute,
+			rebindingTime: 40 * time.Minute,
+		},
+		{
+			name:      "infinite lease",
+			opts:      []LeaseOption{WithRenewalTime(1200), WithRebindingTime(2400), WithInfiniteLease()},
+			leaseTime: infiniteLeaseTime * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		td := New()
+		if err := td.AddLease(testNIC, hwAddr.String(), "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, &leaseTime, tc.opts...); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := dhcpv4.NewDiscovery(hwAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		if len(conn.written) != 1 {
+			t.Fatalf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := reply.IPAddressLeaseTime(0); got != tc.leaseTime {
+			t.Errorf("%s: got lease time %s, wanted %s", tc.name, got, tc.leaseTime)
+		}
+		if got := reply.IPAddressRenewalTime(0); got != tc.renewalTime {
+			t.Errorf("%s: got renewal time %s, wanted %s", tc.name, got, tc.renewalTime)
+		}
+		if got := reply.IPAddressRebindingTime(0); got != tc.rebindingTime {
+			t.Errorf("%s: got rebinding time %s, wanted %s", tc.name, got, tc.rebindingTime)
+		}
+	}
+}
+
+func TestDHCPHandlerRapidCommit(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+	testCases := []struct {
+		name            string
+		opts            []LeaseOption
+		clientOpts      []dhcpv4.Modifier
+		wantMessageType dhcpv4.MessageType
+		wantState       LeaseState
+	}{
+		{
+			name:            "rapid commit disabled",
+			clientOpts:      []dhcpv4.Modifier{dhcpv4.WithOption(dhcpv4.OptGeneric(dhcpv4.OptionRapidCommit, nil))},
+			wantMessageType: dhcpv4.MessageTypeOffer,
+		},
+		{
+			name:            "rapid commit not asked for",
+			opts:            []LeaseOption{WithRapidCommit()},
+			wantMessageType: dhcpv4.MessageTypeOffer,
+		},
+		{
+			name:            "rapid commit",
+			opts:            []LeaseOption{WithRapidCommit()},
+			clientOpts:      []dhcpv4.Modifier{dhcpv4.WithOption(dhcpv4.OptGeneric(dhcpv4.OptionRapidCommit, nil))},
+			wantMessageType: dhcpv4.MessageTypeAck,
+			wantState:       LeaseStateBound,
+		},
+	}
+
+	for _, tc := range testCases {
+		td := New()
+		if err := td.AddLease(testNIC, hwAddr.String(), "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, tc.opts...); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := dhcpv4.NewDiscovery(hwAddr, tc.clientOpts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		if len(conn.written) != 1 {
+			t.Fatalf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reply.MessageType() != tc.wantMessageType {
+			t.Errorf("%s: got message type %s, wanted %s", tc.name, reply.MessageType(), tc.wantMessageType)
+		}
+		if got, want := reply.Options.Has(dhcpv4.OptionRapidCommit), tc.wantMessageType == dhcpv4.MessageTypeAck; got != want {
+			t.Errorf("%s: got rapid commit option %t, wanted %t", tc.name, got, want)
+		}
+		if got := td.GetLease(testNIC, hwAddr.String()).State; got != tc.wantState {
+			t.Errorf("%s: got lease state %q, wanted %q", tc.name, got, tc.wantState)
+		}
+	}
+}
+
+func TestDHCPHandlerClientID(t *testing.T) {
+	leaseHWAddr := "aa:bb:cc:dd:ee:ff"
+	clientID := []byte{0xff, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01}
+
+	td := New()
+	if err := td.AddLease(testNIC, leaseHWAddr, "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("FF:00:00:00:01:00:01")); err != nil {
+		t.Fatal(err)
+	}
+	if err := td.AddLease(testNIC, "00:11:22:33:44:55", "192.168.0.2", "192.168.0.11", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("ff:00:00:00:01:00:01")); err == nil {
+		t.Errorf("got no error adding a lease with a duplicate client id")
+	}
+
+	testCases := []struct {
+		name     string
+		hwAddr   string
+		clientID []byte
+		wantIP   net.IP
+	}{
+		{
+			name:     "client id of another hwaddr",
+			hwAddr:   "52:54:00:00:00:01",
+			clientID: clientID,
+			wantIP:   net.ParseIP("192.168.0.10"),
+		},
+		{
+			name:     "unknown client id falls back to hwaddr",
+			hwAddr:   leaseHWAddr,
+			clientID: []byte{0x01, 0x52, 0x54, 0x00, 0x00, 0x00, 0x02},
+			wantIP:   net.ParseIP("192.168.0.10"),
+		},
+		{
+			name:   "unknown hwaddr without client id",
+			hwAddr: "52:54:00:00:00:01",
+		},
+	}
+
+	for _, tc := range testCases {
+		hwAddr, _ := net.ParseMAC(tc.hwAddr)
+		var modifiers []dhcpv4.Modifier
+		if tc.clientID != nil {
+			modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptClientIdentifier(tc.clientID)))
+		}
+		m, err := dhcpv4.NewDiscovery(hwAddr, modifiers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+
+		if tc.wantIP == nil {
+			if len(conn.written) != 0 {
+				t.Errorf("%s: got %d replies, wanted none", tc.name, len(conn.written))
+			}
+			continue
+		}
+		if len(conn.written) != 1 {
+			t.Fatalf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reply.YourIPAddr.Equal(tc.wantIP) {
+			t.Errorf("%s: got yiaddr %s, wanted %s", tc.name, reply.YourIPAddr, tc.wantIP)
+		}
+		if reply.ClientHWAddr.String() != tc.hwAddr {
+			t.Errorf("%s: got chaddr %s, wanted %s", tc.name, reply.ClientHWAddr, tc.hwAddr)
+		}
+	}
+
+	if err := td.DeleteLease(testNIC, leaseHWAddr); err != nil {
+		t.Fatal(err)
+	}
+	if len(td.clientIDs) != 0 {
+		t.Errorf("got %d client ids after deleting the lease, wanted none", len(td.clientIDs))
+	}
+}
+
+func TestDHCPHandlerReadLock(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+	td := New()
+	if err := td.AddLease(testNIC, hwAddr.String(), "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Offers are built while others read the lease store too
+	td.mutex.RLock()
+	conn := &testPacketConn{}
+	done := make(chan struct{})
+	go func() {
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("got the handler blocked by a reader of the lease store")
+	}
+	td.mutex.RUnlock()
+
+	if len(conn.written) != 1 {
+		t.Fatalf("got %d replies, wanted 1", len(conn.written))
+	}
+	offer, err := dhcpv4.FromBytes(conn.written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The binding is recorded once the DHCPACK is sent
+	request, err := dhcpv4.NewRequestFromOffer(offer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	td.dhcpHandler(testNIC, &testPacketConn{}, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, request)
+	if lease := td.GetLease(testNIC, hwAddr.String()); lease.State != LeaseStateBound {
+		t.Errorf("got lease state %q, wanted %q", lease.State, LeaseStateBound)
+	}
+}
+
+func TestUpdateLease(t *testing.T) {
+	hwAddr := "aa:bb:cc:dd:ee:ff"
+
+	td := New()
+	if err := td.AddLease(testNIC, hwAddr, "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", []string{"192.168.0.53"}, nil, nil, nil, nil, WithClientID("ff:00:00:00:01"), WithConflictDetection()); err != nil {
+		t.Fatal(err)
+	}
+	if err := td.AddLease(testNIC, "00:11:22:33:44:55", "192.168.0.2", "192.168.0.11", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("ff:00:00:00:02")); err != nil {
+		t.Fatal(err)
+	}
+
+	key := leaseKey{nic: testNIC, hwAddr: hwAddr}
+	td.bindLease(key, "vm-1", time.Hour)
+	lease := td.leases[key]
+	lease.Probed = true
+	td.leases[key] = lease
+	bound := td.GetLease(testNIC, hwAddr)
+
+	// Same address, new configuration
+	leaseTime := 7200
+	if err := td.UpdateLease(testNIC, hwAddr, "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", []string{"192.168.0.54"}, nil, nil, nil, &leaseTime, WithClientID("ff:00:00:00:03"), WithConflictDetection()); err != nil {
+		t.Fatal(err)
+	}
+	lease = td.GetLease(testNIC, hwAddr)
+	if len(lease.DNS) != 1 || !lease.DNS[0].Equal(net.ParseIP("192.168.0.54")) {
+		t.Errorf("got dns %v, wanted [192.168.0.54]", lease.DNS)
+	}
+	if lease.LeaseTime != leaseTime {
+		t.Errorf("got lease time %d, wanted %d", lease.LeaseTime, leaseTime)
+	}
+	if lease.State != LeaseStateBound || !lease.BoundTime.Equal(bound.BoundTime) || !lease.ExpiryTime.Equal(bound.ExpiryTime) || lease.ClientHostname != "vm-1" {
+		t.Errorf("got state %q bound at %s, wanted the bound lease kept", lease.State, lease.BoundTime)
+	}
+	if !lease.Probed {
+		t.Errorf("got lease not probed, wanted the probe kept")
+	}
+	if _, ok := td.clientIDs[clientIDKey{nic: testNIC, clientID: "ff:00:00:00:01"}]; ok {
+		t.Errorf("got old client id still indexed")
+	}
+	if got := td.clientIDs[clientIDKey{nic: testNIC, clientID: "ff:00:00:00:03"}]; got != hwAddr {
+		t.Errorf("got new client id indexed for %q, wanted %s", got, hwAddr)
+	}
+
+	// Client id of another lease
+	if err := td.UpdateLease(testNIC, hwAddr, "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("ff:00:00:00:02")); err == nil {
+		t.Errorf("got no error updating a lease with a duplicate client id")
+	}
+
+	// New address
+	if err := td.UpdateLease(testNIC, hwAddr, "192.168.0.2", "192.168.0.12", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithConflictDetection()); err != nil {
+		t.Fatal(err)
+	}
+	lease = td.GetLease(testNIC, hwAddr)
+	if !lease.ClientIP.Equal(net.ParseIP("192.168.0.12")) {
+		t.Errorf("got client ip %s, wanted 192.168.0.12", lease.ClientIP)
+	}
+	if lease.State != "" || !lease.BoundTime.IsZero() || lease.Probed {
+		t.Errorf("got state %q, probed %t, wanted a new lease", lease.State, lease.Probed)
+	}
+	if len(td.clientIDs) != 1 {
+		t.Errorf("got %d client ids, wanted 1", len(td.clientIDs))
+	}
+
+	if err := td.UpdateLease(testNIC, "52:54:00:00:00:01", "192.168.0.2", "192.168.0.13", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err == nil {
+		t.Errorf("got no error updating a lease which does not exist")
+	}
+}
+
+func TestReleaseClientID(t *testing.T) {
+	hwAddr1, hwAddr2 := "aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"
+
+	td := New()
+	if err := td.AddLease(testNIC, hwAddr1, "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("ff:00:00:00:01")); err != nil {
+		t.Fatal(err)
+	}
+	if err := td.AddLease(testNIC, hwAddr2, "192.168.0.2", "192.168.0.11", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("ff:00:00:00:02")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap the client ids
+	td.ReleaseClientID(testNIC, hwAddr1)
+	td.ReleaseClientID(testNIC, hwAddr2)
+	if err := td.UpdateLease(testNIC, hwAddr1, "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("ff:00:00:00:02")); err != nil {
+		t.Fatal(err)
+	}
+	if err := td.UpdateLease(testNIC, hwAddr2, "192.168.0.2", "192.168.0.11", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithClientID("ff:00:00:00:01")); err != nil {
+		t.Fatal(err)
+	}
+	if got := td.clientIDs[clientIDKey{nic: testNIC, clientID: "ff:00:00:00:02"}]; got != hwAddr1 {
+		t.Errorf("got client id ff:00:00:00:02 indexed for %q, wanted %s", got, hwAddr1)
+	}
+	if got := td.clientIDs[clientIDKey{nic: testNIC, clientID: "ff:00:00:00:01"}]; got != hwAddr2 {
+		t.Errorf("got client id ff:00:00:00:01 indexed for %q, wanted %s", got, hwAddr2)
+	}
+
+	// Releasing a lease without client id, or no lease at all, is a no-op
+	td.ReleaseClientID(testNIC, "52:54:00:00:00:01")
+	if len(td.clientIDs) != 2 {
+		t.Errorf("got %d client ids, wanted 2", len(td.clientIDs))
+	}
+}
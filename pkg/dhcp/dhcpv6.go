@@ -0,0 +1,397 @@
+package dhcp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/dhcpv6/server6"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/sirupsen/logrus"
+)
+
+// default valid lifetime: 1 year
+const defaultValidLifetime = 31536000
+
+type DHCPv6Lease struct {
+	DUID              string
+	IAID              *uint32
+	HWAddr            string
+	ClientIP          net.IP
+	DNS               []net.IP
+	DomainSearch      []string
+	PreferredLifetime int
+	ValidLifetime     int
+}
+
+// lease6Key identifies a DHCPv6 lease by the network interface it is served
+// on and the leased address.
+type lease6Key struct {
+	nic      string
+	clientIP string
+}
+
+func (k lease6Key) String() string {
+	return k.nic + "/" + k.clientIP
+}
+
+func (l *DHCPv6Lease) String() string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (a *DHCPAllocator) AddLease6(
+	nic string,
+	duid string,
+	iaid *uint32,
+	hwAddr string,
+	clientIP string,
+	cidr string,
+	dnsServers []string,
+	domainSearch []string,
+	preferredLifetime *int,
+	validLifetime *int,
+) (err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if _, err := net.ParseMAC(hwAddr); err != nil {
+		return fmt.Errorf("hwaddr %s is not valid", hwAddr)
+	}
+
+	ip := net.ParseIP(clientIP)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Errorf("clientip %s is not a valid ipv6 address", clientIP)
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	if !ipNet.Contains(ip) {
+		return fmt.Errorf("clientip %s is not within subnet %s", clientIP, cidr)
+	}
+
+	key := lease6Key{nic: nic, clientIP: clientIP}
+	if a.checkLease6(key) {
+		return fmt.Errorf("lease for clientip %s already exists", clientIP)
+	}
+
+	lease := DHCPv6Lease{
+		IAID:         iaid,
+		HWAddr:       hwAddr,
+		ClientIP:     ip,
+		DomainSearch: domainSearch,
+	}
+
+	if duid != "" {
+		lease.DUID, err = normalizeDUID(duid)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dnsServer := range dnsServers {
+		dnsServerIP := net.ParseIP(dnsServer)
+		if dnsServerIP == nil || dnsServerIP.To4() != nil {
+			logrus.Errorf("(dhcp.AddLease6) ignoring invalid ipv6 dns server entry %s", dnsServer)
+			continue
+		}
+		lease.DNS = append(lease.DNS, dnsServerIP)
+	}
+
+	lease.ValidLifetime = defaultValidLifetime
+	if validLifetime != nil && *validLifetime > 0 {
+		lease.ValidLifetime = *validLifetime
+	}
+	lease.PreferredLifetime = lease.ValidLifetime
+	if preferredLifetime != nil && *preferredLifetime > 0 {
+		if *preferredLifetime > lease.ValidLifetime {
+			return fmt.Errorf("preferred lifetime %d exceeds valid lifetime %d", *preferredLifetime, lease.ValidLifetime)
+		}
+		lease.PreferredLifetime = *preferredLifetime
+	}
+
+	a.leases6[key] = lease
+
+	logrus.Infof("(dhcp.AddLease6) lease added for ipv6 address: %s on nic %s", clientIP, nic)
+
+	return
+}
+
+func (a *DHCPAllocator) checkLease6(key lease6Key) bool {
+	_, exists := a.leases6[key]
+
+	return exists
+}
+
+func (a *DHCPAllocator) GetLease6(nic, clientIP string) (lease DHCPv6Lease) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.leases6[lease6Key{nic: nic, clientIP: clientIP}]
+}
+
+func (a *DHCPAllocator) DeleteLease6(nic, clientIP string) (err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	key := lease6Key{nic: nic, clientIP: clientIP}
+	if !a.checkLease6(key) {
+		return fmt.Errorf("lease for clientip %s does not exists", clientIP)
+	}
+
+	delete(a.leases6, key)
+
+	logrus.Infof("(dhcp.DeleteLease6) lease deleted for ipv6 address: %s on nic %s", clientIP, nic)
+
+	return
+}
+
+// findLease6 looks up the binding of a DHCPv6 client. A lease with a DUID
+// must match the client identifier exactly, while a lease without one falls
+// back to the link-layer address carried in a DUID-LL or DUID-LLT. A lease
+// without an IAID matches any identity association of the client.
+func (a *DHCPAllocator) findLease6(nic string, clientID dhcpv6.DUID, iaid [4]byte) (DHCPv6Lease, bool) {
+	duid := net.HardwareAddr(clientID.ToBytes()).String()
+	hwAddr := duidHWAddr(clientID)
+	id := binary.BigEndian.Uint32(iaid[:])
+
+	for key, lease := range a.leases6 {
+		if key.nic != nic {
+			continue
+		}
+		if lease.IAID != nil && *lease.IAID != id {
+			continue
+		}
+		if lease.DUID != "" {
+			if lease.DUID == duid {
+				return lease, true
+			}
+			continue
+		}
+		if hwAddr != "" && lease.HWAddr == hwAddr {
+			return lease, true
+		}
+	}
+
+	return DHCPv6Lease{}, false
+}
+
+// dhcpv6Handler serves the DHCPv6 messages received on nic with the leases
+// of that interface.
+func (a *DHCPAllocator) dhcpv6Handler(nic string, conn net.PacketConn, peer net.Addr, m dhcpv6.DHCPv6) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if m == nil {
+		logrus.Errorf("(dhcp.dhcpv6Handler) packet is nil!")
+		return
+	}
+
+	logrus.Tracef("(dhcp.dhcpv6Handler) INCOMING PACKET=%s", m.Summary())
+
+	msg, ok := m.(*dhcpv6.Message)
+	if !ok {
+		logrus.Errorf("(dhcp.dhcpv6Handler) relayed messages are not supported!")
+		return
+	}
+
+	clientID := msg.Options.ClientID()
+	if clientID == nil {
+		logrus.Errorf("(dhcp.dhcpv6Handler) no client identifier!")
+		return
+	}
+
+	var serverDUID dhcpv6.DUID
+	if servers := a.servers[nic]; servers != nil {
+		serverDUID = servers.duid
+	}
+	if serverDUID == nil {
+		logrus.Errorf("(dhcp.dhcpv6Handler) no server identifier for nic %s!", nic)
+		return
+	}
+
+	messageType := msg.Type()
+	switch messageType {
+	case dhcpv6.MessageTypeSolicit, dhcpv6.MessageTypeRebind:
+		if msg.Options.ServerID() != nil {
+			logrus.Debugf("(dhcp.dhcpv6Handler) discarding %s with server identifier from client %s", messageType, clientID)
+			return
+		}
+	case dhcpv6.MessageTypeRequest, dhcpv6.MessageTypeRenew:
+		if serverID := msg.Options.ServerID(); serverID == nil || !serverID.Equal(serverDUID) {
+			logrus.Debugf("(dhcp.dhcpv6Handler) %s from client %s is not addressed to us", messageType, clientID)
+			return
+		}
+	default:
+		logrus.Warnf("(dhcp.dhcpv6Handler) Unhandled message type for client [%s]: %v", clientID, messageType)
+		return
+	}
+
+	iaNA := msg.Options.OneIANA()
+	if iaNA == nil {
+		logrus.Warnf("(dhcp.dhcpv6Handler) no IA_NA in %s from client %s", messageType, clientID)
+		return
+	}
+
+	lease, found := a.findLease6(nic, clientID, iaNA.IaId)
+
+	var (
+		reply *dhcpv6.Message
+		err   error
+	)
+	if messageType == dhcpv6.MessageTypeSolicit {
+		if !found {
+			logrus.Warnf("(dhcp.dhcpv6Handler) NO LEASE FOUND: duid=%s, iaid=%x", clientID, iaNA.IaId)
+			return
+		}
+		reply, err = dhcpv6.NewAdvertiseFromSolicit(msg)
+	} else {
+		if !found && messageType == dhcpv6.MessageTypeRebind {
+			logrus.Warnf("(dhcp.dhcpv6Handler) NO LEASE FOUND: duid=%s, iaid=%x", clientID, iaNA.IaId)
+			return
+		}
+		reply, err = dhcpv6.NewReplyFromMessage(msg)
+	}
+	if err != nil {
+		logrus.Errorf("(dhcp.dhcpv6Handler) cannot build reply: %v", err)
+		return
+	}
+
+	reply.AddOption(dhcpv6.OptServerID(serverDUID))
+
+	if !found {
+		statusCode := iana.StatusNoAddrsAvail
+		if messageType == dhcpv6.MessageTypeRenew {
+			statusCode = iana.StatusNoBinding
+		}
+		logrus.Warnf("(dhcp.dhcpv6Handler) NO LEASE FOUND: duid=%s, iaid=%x, replying %s", clientID, iaNA.IaId, statusCode)
+		status := &dhcpv6.OptIANA{IaId: iaNA.IaId}
+		status.Options.Add(&dhcpv6.OptStatusCode{StatusCode: statusCode})
+		reply.AddOption(status)
+	} else {
+		logrus.Debugf("(dhcp.dhcpv6Handler) LEASE FOUND: duid=%s, iaid=%x, clientip=%s, dns=%+v, domainsearch=%+v, preferred=%d, valid=%d",
+			clientID,
+			iaNA.IaId,
+			lease.ClientIP.String(),
+			lease.DNS,
+			lease.DomainSearch,
+			lease.PreferredLifetime,
+			lease.ValidLifetime,
+		)
+
+		reply.AddOption(buildIANA(lease, iaNA))
+
+		if len(lease.DNS) > 0 {
+			reply.UpdateOption(dhcpv6.OptDNS(lease.DNS...))
+		}
+
+		if len(lease.DomainSearch) > 0 {
+			dhcpv6.WithDomainSearchList(lease.DomainSearch...)(reply)
+		}
+	}
+
+	logrus.Debugf("(dhcp.dhcpv6Handler) %s: %s", reply.Type(), reply.Summary())
+
+	if _, err := conn.WriteTo(reply.ToBytes(), peer); err != nil {
+		logrus.Errorf("(dhcp.dhcpv6Handler) Cannot reply to client: %v", err)
+	}
+}
+
+// buildIANA assigns the leased address to the requested identity association.
+// Any other address the client still holds is returned with zero lifetimes so
+// that it is dropped, e.g. after the VM has been re-numbered.
+func buildIANA(lease DHCPv6Lease, requested *dhcpv6.OptIANA) *dhcpv6.OptIANA {
+	preferred := time.Duration(lease.PreferredLifetime) * time.Second
+	valid := time.Duration(lease.ValidLifetime) * time.Second
+
+	ia := &dhcpv6.OptIANA{
+		IaId: requested.IaId,
+		T1:   preferred / 2,
+		T2:   preferred * 4 / 5,
+	}
+	ia.Options.Add(&dhcpv6.OptIAAddress{
+		IPv6Addr:          lease.ClientIP,
+		PreferredLifetime: preferred,
+		ValidLifetime:     valid,
+	})
+
+	for _, addr := range requested.Options.Addresses() {
+		if addr.IPv6Addr.Equal(lease.ClientIP) {
+			continue
+		}
+		ia.Options.Add(&dhcpv6.OptIAAddress{
+			IPv6Addr: addr.IPv6Addr,
+		})
+	}
+
+	return ia
+}
+
+func (a *DHCPAllocator) Run6(ctx context.Context, nic string) (err error) {
+	logrus.Infof("(dhcp.Run6) starting DHCPv6 service on nic %s", nic)
+
+	iface, err := net.InterfaceByName(nic)
+	if err != nil {
+		return
+	}
+
+	duid := &dhcpv6.DUIDLL{
+		HWType:        iana.HWTypeEthernet,
+		LinkLayerAddr: iface.HardwareAddr,
+	}
+
+	// listening on [::]:547 joins the All_DHCP_Relay_Agents_and_Servers group
+	server, err := server6.NewServer(nic, nil, func(conn net.PacketConn, peer net.Addr, m dhcpv6.DHCPv6) {
+		a.dhcpv6Handler(nic, conn, peer, m)
+	})
+	if err != nil {
+		return
+	}
+
+	a.serve6(nic, server, duid)
+
+	return nil
+}
+
+func (a *DHCPAllocator) serve6(nic string, server *server6.Server, duid dhcpv6.DUID) {
+	a.mutex.Lock()
+	servers := a.serversOf(nic)
+	servers.server6 = server
+	servers.duid = duid
+	a.mutex.Unlock()
+
+	go func() {
+		if err := server.Serve(); err != nil {
+			logrus.Errorf("(dhcp.Run6) DHCPv6 server on nic %s exited with error: %v", nic, err)
+		}
+	}()
+}
+
+func normalizeDUID(duid string) (string, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(duid, ":", ""))
+	if err != nil || len(b) == 0 {
+		return "", fmt.Errorf("duid %s is not valid", duid)
+	}
+	return net.HardwareAddr(b).String(), nil
+}
+
+func duidHWAddr(duid dhcpv6.DUID) string {
+	switch d := duid.(type) {
+	case *dhcpv6.DUIDLL:
+		return d.LinkLayerAddr.String()
+	case *dhcpv6.DUIDLLT:
+		return d.LinkLayerAddr.String()
+	}
+	return ""
+}
@@ -0,0 +1,278 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/iana"
+)
+
+func TestDHCPv6Lease(t *testing.T) {
+	td := New()
+
+	testLeases := []struct {
+		duid              string
+		hwAddr            string
+		clientIP          string
+		cidr              string
+		dnsServers        []string
+		preferredLifetime *int
+		validLifetime     *int
+		want              error
+	}{
+		{
+			duid:              "00:03:00:01:aa:bb:cc:dd:ee:ff",
+			hwAddr:            "aa:bb:cc:dd:ee:ff",
+			clientIP:          "fd00::10",
+			cidr:              "fd00::/64",
+			dnsServers:        []string{"fd00::53", "8.8.8.8"},
+			preferredLifetime: func(i int) *int { return &i }(1800),
+			validLifetime:     func(i int) *int { return &i }(3600),
+			want:              nil,
+		},
+		{
+			hwAddr:   "00:01:02:03:04:05",
+			clientIP: "fd00::10",
+			cidr:     "fd00::/64",
+			want:     fmt.Errorf("lease for clientip fd00::10 already exists"),
+		},
+		{
+			hwAddr:   "00:01:02:03:04:05",
+			clientIP: "fd00::11",
+			cidr:     "fd00::/64",
+			want:     nil,
+		},
+		{
+			hwAddr:   "00:01:02:03:04:05",
+			clientIP: "192.168.0.10",
+			cidr:     "fd00::/64",
+			want:     fmt.Errorf("clientip 192.168.0.10 is not a valid ipv6 address"),
+		},
+		{
+			hwAddr:   "00:01:02:03:04:05",
+			clientIP: "fd01::10",
+			cidr:     "fd00::/64",
+			want:     fmt.Errorf("clientip fd01::10 is not within subnet fd00::/64"),
+		},
+		{
+			hwAddr:   "11:22:33:44:55",
+			clientIP: "fd00::12",
+			cidr:     "fd00::/64",
+			want:     fmt.Errorf("hwaddr 11:22:33:44:55 is not valid"),
+		},
+		{
+			duid:     "xyz",
+			hwAddr:   "11:22:33:44:55:66",
+			clientIP: "fd00::12",
+			cidr:     "fd00::/64",
+			want:     fmt.Errorf("duid xyz is not valid"),
+		},
+		{
+			hwAddr:            "11:22:33:44:55:66",
+			clientIP:          "fd00::13",
+			cidr:              "fd00::/64",
+			preferredLifetime: func(i int) *int { return &i }(7200),
+			validLifetime:     func(i int) *int { return &i }(3600),
+			want:              fmt.Errorf("preferred lifetime 7200 exceeds valid lifetime 3600"),
+		},
+	}
+
+	// AddLease6 function tests
+	for i := 0; i < len(testLeases); i++ {
+		if got := td.AddLease6(
+			testNIC,
+			testLeases[i].duid,
+			nil,
+			testLeases[i].hwAddr,
+			testLeases[i].clientIP,
+			testLeases[i].cidr,
+			testLeases[i].dnsServers,
+			nil,
+			testLeases[i].preferredLifetime,
+			testLeases[i].validLifetime,
+		); got != testLeases[i].want {
+			if got == nil || testLeases[i].want == nil {
+				t.Errorf("got %q, wanted %q", got, testLeases[i].want)
+			} else if got.Error() != testLeases[i].want.Error() {
+				t.Errorf("got %q, wanted %q", got, testLeases[i].want)
+			}
+		}
+	}
+
+	// GetLease6 function tests
+	lease1 := td.GetLease6(testNIC, "fd00::10")
+	if lease1.HWAddr != "aa:bb:cc:dd:ee:ff" {
+		t.Errorf("got %q, wanted %q", lease1.HWAddr, "aa:bb:cc:dd:ee:ff")
+	}
+	if len(lease1.DNS) != 1 || !lease1.DNS[0].Equal(net.ParseIP("fd00::53")) {
+		t.Errorf("got %v, wanted [fd00::53]", lease1.DNS)
+	}
+	if lease1.PreferredLifetime != 1800 || lease1.ValidLifetime != 3600 {
+		t.Errorf("got lifetimes %d/%d, wanted 1800/3600", lease1.PreferredLifetime, lease1.ValidLifetime)
+	}
+	lease2 := td.GetLease6(testNIC, "fd00::11")
+	if lease2.PreferredLifetime != defaultValidLifetime || lease2.ValidLifetime != defaultValidLifetime {
+		t.Errorf("got lifetimes %d/%d, wanted defaults", lease2.PreferredLifetime, lease2.ValidLifetime)
+	}
+
+	// DeleteLease6 function tests
+	if got := td.DeleteLease6(testNIC, "fd00::10"); got != nil {
+		t.Errorf("got %q, wanted nil", got)
+	}
+	if got := td.DeleteLease6(testNIC, "fd00::10"); got != nil {
+		wanted := "lease for clientip fd00::10 does not exists"
+		if got.Error() != wanted {
+			t.Errorf("got %q, wanted %q", got, wanted)
+		}
+	}
+}
+
+func TestDHCPv6Handler(t *testing.T) {
+	serverDUID := &dhcpv6.DUIDLL{
+		HWType:        iana.HWTypeEthernet,
+		LinkLayerAddr: net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+	}
+	knownClient := &dhcpv6.DUIDLL{
+		HWType:        iana.HWTypeEthernet,
+		LinkLayerAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+	}
+	unknownClient := &dhcpv6.DUIDLL{
+		HWType:        iana.HWTypeEthernet,
+		LinkLayerAddr: net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+	}
+	otherServer := &dhcpv6.DUIDLL{
+		HWType:        iana.HWTypeEthernet,
+		LinkLayerAddr: net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02},
+	}
+	iaid := [4]byte{0, 0, 0, 1}
+
+	td := New()
+	td.serversOf(testNIC).duid = serverDUID
+	if err := td.AddLease6(testNIC, "", nil, "aa:bb:cc:dd:ee:ff", "fd00::10", "fd00::/64", []string{"fd00::53"}, []string{"example.com"}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	newMessage := func(messageType dhcpv6.MessageType, clientID, serverID dhcpv6.DUID) *dhcpv6.Message {
+		msg, err := dhcpv6.NewMessage(dhcpv6.WithIAID(iaid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.MessageType = messageType
+		msg.AddOption(dhcpv6.OptClientID(clientID))
+		if serverID != nil {
+			msg.AddOption(dhcpv6.OptServerID(serverID))
+		}
+		return msg
+	}
+
+	tests := []struct {
+		name       string
+		msg        *dhcpv6.Message
+		wantType   dhcpv6.MessageType
+		wantAddr   net.IP
+		wantStatus *iana.StatusCode
+	}{
+		{
+			name:     "solicit from known client",
+			msg:      newMessage(dhcpv6.MessageTypeSolicit, knownClient, nil),
+			wantType: dhcpv6.MessageTypeAdvertise,
+			wantAddr: net.ParseIP("fd00::10"),
+		},
+		{
+			name: "solicit from unknown client",
+			msg:  newMessage(dhcpv6.MessageTypeSolicit, unknownClient, nil),
+		},
+		{
+			name:     "request from known client",
+			msg:      newMessage(dhcpv6.MessageTypeRequest, knownClient, serverDUID),
+			wantType: dhcpv6.MessageTypeReply,
+			wantAddr: net.ParseIP("fd00::10"),
+		},
+		{
+			name: "request addressed to another server",
+			msg:  newMessage(dhcpv6.MessageTypeRequest, knownClient, otherServer),
+		},
+		{
+			name:       "request from unknown client",
+			msg:        newMessage(dhcpv6.MessageTypeRequest, unknownClient, serverDUID),
+			wantType:   dhcpv6.MessageTypeReply,
+			wantStatus: func(s iana.StatusCode) *iana.StatusCode { return &s }(iana.StatusNoAddrsAvail),
+		},
+		{
+			name:     "renew from known client",
+			msg:      newMessage(dhcpv6.MessageTypeRenew, knownClient, serverDUID),
+			wantType: dhcpv6.MessageTypeReply,
+			wantAddr: net.ParseIP("fd00::10"),
+		},
+		{
+			name:       "renew from unknown client",
+			msg:        newMessage(dhcpv6.MessageTypeRenew, unknownClient, serverDUID),
+			wantType:   dhcpv6.MessageTypeReply,
+			wantStatus: func(s iana.StatusCode) *iana.StatusCode { return &s }(iana.StatusNoBinding),
+		},
+		{
+			name:     "rebind from known client",
+			msg:      newMessage(dhcpv6.MessageTypeRebind, knownClient, nil),
+			wantType: dhcpv6.MessageTypeReply,
+			wantAddr: net.ParseIP("fd00::10"),
+		},
+		{
+			name: "rebind from unknown client",
+			msg:  newMessage(dhcpv6.MessageTypeRebind, unknownClient, nil),
+		},
+	}
+
+	for _, tc := range tests {
+		conn := &testPacketConn{}
+		td.dhcpv6Handler(testNIC, conn, &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: dhcpv6.DefaultClientPort}, tc.msg)
+
+		if tc.wantType == 0 {
+			if len(conn.written) != 0 {
+				t.Errorf("%s: got %d replies, wanted none", tc.name, len(conn.written))
+			}
+			continue
+		}
+		if len(conn.written) != 1 {
+			t.Errorf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+			continue
+		}
+
+		reply, err := dhcpv6.MessageFromBytes(conn.written[0])
+		if err != nil {
+			t.Errorf("%s: cannot parse reply: %v", tc.name, err)
+			continue
+		}
+		if reply.Type() != tc.wantType {
+			t.Errorf("%s: got %s, wanted %s", tc.name, reply.Type(), tc.wantType)
+		}
+		if reply.TransactionID != tc.msg.TransactionID {
+			t.Errorf("%s: got xid %s, wanted %s", tc.name, reply.TransactionID, tc.msg.TransactionID)
+		}
+		if serverID := reply.Options.ServerID(); serverID == nil || !serverID.Equal(serverDUID) {
+			t.Errorf("%s: got server id %v, wanted %v", tc.name, serverID, serverDUID)
+		}
+
+		iaNA := reply.Options.OneIANA()
+		if iaNA == nil {
+			t.Errorf("%s: reply has no IA_NA", tc.name)
+			continue
+		}
+		if tc.wantAddr != nil {
+			addr := iaNA.Options.OneAddress()
+			if addr == nil || !addr.IPv6Addr.Equal(tc.wantAddr) {
+				t.Errorf("%s: got address %v, wanted %s", tc.name, addr, tc.wantAddr)
+			}
+			if dns := reply.Options.DNS(); len(dns) != 1 || !dns[0].Equal(net.ParseIP("fd00::53")) {
+				t.Errorf("%s: got dns %v, wanted [fd00::53]", tc.name, dns)
+			}
+		}
+		if tc.wantStatus != nil {
+			status := iaNA.Options.Status()
+			if status == nil || status.StatusCode != *tc.wantStatus {
+				t.Errorf("%s: got status %v, wanted %s", tc.name, status, *tc.wantStatus)
+			}
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package dhcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/sirupsen/logrus"
+)
+
+// offerHoldTime is how long an address offered from the dynamic range is
+// held for the client to request it.
+const offerHoldTime = time.Minute
+
+// dynamicLeaseReclaimInterval is how often the expired dynamic leases are
+// reclaimed.
+const dynamicLeaseReclaimInterval = 10 * time.Second
+
+// DynamicRange is a range of addresses handed out to clients without a lease
+// of their own, e.g. appliances which are not virtual machines.
+type DynamicRange struct {
+	start     netip.Addr
+	end       netip.Addr
+	leaseTime int
+	reserved  map[netip.Addr]bool
+
+	// Configuration parameters of the dynamic leases
+	template DHCPLease
+}
+
+// DynamicLease is a lease handed out from the dynamic range.
+type DynamicLease struct {
+	HWAddr     string
+	ExpiryTime time.Time
+}
+
+// NewDynamicRange returns a dynamic range from start to end, both included,
+// whose leases last leaseTime seconds and carry the given configuration
+// parameters.
+func NewDynamicRange(
+	start string,
+	end string,
+	leaseTime int,
+	serverIP string,
+	cidr string,
+	routerIP string,
+	dnsServers []string,
+	domainName *string,
+	domainSearch []string,
+	ntpServers []string,
+	opts ...LeaseOption,
+) (*DynamicRange, error) {
+	startAddr, err := netip.ParseAddr(start)
+	if err != nil || !startAddr.Is4() {
+		return nil, fmt.Errorf("dynamic range start %s is not a valid ipv4 address", start)
+	}
+	endAddr, err := netip.ParseAddr(end)
+	if err != nil || !endAddr.Is4() {
+		return nil, fmt.Errorf("dynamic range end %s is not a valid ipv4 address", end)
+	}
+	if startAddr.Compare(endAddr) > 0 {
+		return nil, fmt.Errorf("dynamic range end %s is less than start %s", end, start)
+	}
+	if leaseTime <= 0 {
+		return nil, fmt.Errorf("dynamic lease time %d is not positive", leaseTime)
+	}
+
+	template, err := newLease(serverIP, "", cidr, routerIP, dnsServers, domainName, domainSearch, ntpServers, &leaseTime, opts...)
+	if err != nil {
+		return nil, err
+	}
+	template.Dynamic = true
+	template.LeaseTime = leaseTime
+	template.RenewalTime = leaseTime / 2
+	template.RebindingTime = leaseTime * 7 / 8
+	template.InfiniteLease = false
+
+	return &DynamicRange{
+		start:     startAddr,
+		end:       endAddr,
+		leaseTime: leaseTime,
+		reserved:  make(map[netip.Addr]bool),
+		template:  template,
+	}, nil
+}
+
+// Reserve keeps the given addresses, e.g. the ones allocated to virtual
+// machines or excluded from the pool, from being handed out.
+func (r *DynamicRange) Reserve(ips ...string) {
+	for _, ip := range ips {
+		if addr, err := netip.ParseAddr(ip); err == nil {
+			r.reserved[addr] = true
+		}
+	}
+}
+
+func (r *DynamicRange) contains(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip.To4())
+	return ok && r.start.Compare(addr) <= 0 && addr.Compare(r.end) <= 0 && !r.reserved[addr]
+}
+
+// SetDynamicRange hands out leases from r to the clients on nic without a
+// lease of their own, or stops doing so if r is nil. Dynamic leases which
+// are no longer within the range are dropped.
+func (a *DHCPAllocator) SetDynamicRange(nic string, r *DynamicRange) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if r == nil {
+		delete(a.dynamicRanges, nic)
+	} else {
+		a.dynamicRanges[nic] = r
+	}
+
+	changed := false
+	for key, lease := range a.leases {
+		if key.nic != nic || !lease.Dynamic || (r != nil && r.contains(lease.ClientIP)) {
+			continue
+		}
+		logrus.Infof("(dhcp.SetDynamicRange) drop dynamic lease %s of hwaddr %s on nic %s", lease.ClientIP, key.hwAddr, nic)
+		delete(a.leases, key)
+		changed = true
+	}
+	if changed {
+		a.notifyLeaseState(nic)
+	}
+}
+
+// offerDynamicLease gives a client without a lease of its own one from the
+// dynamic range of nic, if any, before it is answered.
+func (a *DHCPAllocator) offerDynamicLease(nic string, m *dhcpv4.DHCPv4) {
+	a.mutex.RLock()
+	_, ok := a.lookupLease(nic, m)
+	hasRange := a.dynamicRanges[nic] != nil
+	a.mutex.RUnlock()
+
+	if ok || !hasRange {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// The client might have got a lease in the meantime
+	if _, ok := a.lookupLease(nic, m); !ok {
+		a.allocateDynamicLease(nic, m)
+	}
+}
+
+// allocateDynamicLease offers the first free address of the dynamic range of
+// nic to the client. The address is held for offerHoldTime until the client
+// requests it. Must be called with the write lock held.
+func (a *DHCPAllocator) allocateDynamicLease(nic string, m *dhcpv4.DHCPv4) {
+	r := a.dynamicRanges[nic]
+	if r == nil {
+		return
+	}
+
+	inUse := make(map[netip.Addr]bool)
+	for key, lease := range a.leases {
+		if key.nic != nic {
+			continue
+		}
+		if addr, ok := netip.AddrFromSlice(lease.ClientIP.To4()); ok {
+			inUse[addr] = true
+		}
+	}
+
+	for addr := r.start; addr.Compare(r.end) <= 0; addr = addr.Next() {
+		if r.reserved[addr] || inUse[addr] {
+			continue
+		}
+
+		lease := r.template
+		lease.ClientIP = net.IP(addr.AsSlice())
+		lease.ExpiryTime = time.Now().Add(offerHoldTime)
+
+		key := leaseKey{nic: nic, hwAddr: m.ClientHWAddr.String()}
+		a.leases[key] = lease
+
+		logrus.Infof("(dhcp.dhcpHandler) offer dynamic lease %s to hwaddr %s on nic %s", lease.ClientIP, key.hwAddr, nic)
+		a.notifyLeaseState(nic)
+
+		return
+	}
+
+	logrus.Warnf("(dhcp.dhcpHandler) dynamic range %s-%s on nic %s is exhausted", r.start, r.end, nic)
+}
+
+// evictDynamicLeases drops the dynamic leases of the client, or of the
+// address, making room for a static lease. Must be called with the write lock
+// held.
+func (a *DHCPAllocator) evictDynamicLeases(key leaseKey, ip net.IP) {
+	for k, lease := range a.leases {
+		if k.nic != key.nic || !lease.Dynamic || (k != key && !lease.ClientIP.Equal(ip)) {
+			continue
+		}
+		logrus.Infof("(dhcp.AddLease) evict dynamic lease %s of hwaddr %s on nic %s", lease.ClientIP, k.hwAddr, k.nic)
+		delete(a.leases, k)
+		a.notifyLeaseState(k.nic)
+	}
+}
+
+// reclaimDynamicLeases drops the dynamic leases on nic which have expired or
+// been given up by the client, every dynamicLeaseReclaimInterval until ctx is
+// done.
+func (a *DHCPAllocator) reclaimDynamicLeases(ctx context.Context, nic string) {
+	ticker := time.NewTicker(dynamicLeaseReclaimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.expireDynamicLeases(nic, time.Now())
+		}
+	}
+}
+
+func (a *DHCPAllocator) expireDynamicLeases(nic string, now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	changed := false
+	for key, lease := range a.leases {
+		if key.nic != nic || !lease.Dynamic {
+			continue
+		}
+		switch {
+		case lease.State == LeaseStateDeclined:
+			// Someone else on the link uses the address
+			if r := a.dynamicRanges[nic]; r != nil {
+				r.Reserve(lease.ClientIP.String())
+			}
+		case lease.State == LeaseStateReleased:
+		case lease.ExpiryTime.Before(now):
+		default:
+			continue
+		}
+		logrus.Infof("(dhcp.reclaimDynamicLeases) reclaim dynamic lease %s of hwaddr %s on nic %s", lease.ClientIP, key.hwAddr, nic)
+		delete(a.leases, key)
+		changed = true
+	}
+	if changed {
+		a.notifyLeaseState(nic)
+	}
+}
+
+// ListDynamicLeases returns the dynamic leases on nic keyed by address.
+func (a *DHCPAllocator) ListDynamicLeases(nic string) map[string]DynamicLease {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	leases := make(map[string]DynamicLease)
+	for key, lease := range a.leases {
+		if key.nic == nic && lease.Dynamic {
+			leases[lease.ClientIP.String()] = DynamicLease{
+				HWAddr:     key.hwAddr,
+				ExpiryTime: lease.ExpiryTime,
+			}
+		}
+	}
+
+	return leases
+}
@@ -0,0 +1,185 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+func newTestDynamicRange(t *testing.T, start, end string, reserved ...string) *DynamicRange {
+	r, err := NewDynamicRange(start, end, 600, "192.168.0.2", "192.168.0.0/24", "192.168.0.1", []string{"192.168.0.1"}, nil, nil, nil, WithRenewalTime(43200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Reserve(reserved...)
+	return r
+}
+
+func discover(t *testing.T, td *DHCPAllocator, mac string) *dhcpv4.DHCPv4 {
+	hwAddr, _ := net.ParseMAC(mac)
+	m, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &testPacketConn{}
+	td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+	if len(conn.written) == 0 {
+		return nil
+	}
+	reply, err := dhcpv4.FromBytes(conn.written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+func TestDHCPHandlerDynamicRange(t *testing.T) {
+	td := New()
+	if err := td.AddLease(testNIC, "aa:bb:cc:dd:ee:ff", "192.168.0.2", "192.168.0.100", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if reply := discover(t, td, "52:54:00:00:00:01"); reply != nil {
+		t.Errorf("got reply %s without dynamic range, wanted none", reply.YourIPAddr)
+	}
+
+	td.SetDynamicRange(testNIC, newTestDynamicRange(t, "192.168.0.100", "192.168.0.103", "192.168.0.101"))
+
+	testCases := []struct {
+		name   string
+		hwAddr string
+		wantIP net.IP
+	}{
+		{
+			name:   "static lease",
+			hwAddr: "aa:bb:cc:dd:ee:ff",
+			wantIP: net.ParseIP("192.168.0.100"),
+		},
+		{
+			name:   "first unknown client skips leased and reserved addresses",
+			hwAddr: "52:54:00:00:00:01",
+			wantIP: net.ParseIP("192.168.0.102"),
+		},
+		{
+			name:   "same unknown client gets the same address",
+			hwAddr: "52:54:00:00:00:01",
+			wantIP: net.ParseIP("192.168.0.102"),
+		},
+		{
+			name:   "second unknown client",
+			hwAddr: "52:54:00:00:00:02",
+			wantIP: net.ParseIP("192.168.0.103"),
+		},
+		{
+			name:   "dynamic range exhausted",
+			hwAddr: "52:54:00:00:00:03",
+		},
+	}
+
+	for _, tc := range testCases {
+		reply := discover(t, td, tc.hwAddr)
+		if tc.wantIP == nil {
+			if reply != nil {
+				t.Errorf("%s: got reply %s, wanted none", tc.name, reply.YourIPAddr)
+			}
+			continue
+		}
+		if reply == nil {
+			t.Errorf("%s: got no reply, wanted %s", tc.name, tc.wantIP)
+			continue
+		}
+		if !reply.YourIPAddr.Equal(tc.wantIP) {
+			t.Errorf("%s: got yiaddr %s, wanted %s", tc.name, reply.YourIPAddr, tc.wantIP)
+		}
+	}
+
+	reply := discover(t, td, "52:54:00:00:00:01")
+	if got, want := reply.IPAddressLeaseTime(0), 600*time.Second; got != want {
+		t.Errorf("got dynamic lease time %s, wanted %s", got, want)
+	}
+	if got, want := reply.IPAddressRenewalTime(0), 300*time.Second; got != want {
+		t.Errorf("got dynamic renewal time %s, wanted %s", got, want)
+	}
+
+	leases := td.ListDynamicLeases(testNIC)
+	if len(leases) != 2 || leases["192.168.0.102"].HWAddr != "52:54:00:00:00:01" || leases["192.168.0.103"].HWAddr != "52:54:00:00:00:02" {
+		t.Errorf("got dynamic leases %+v", leases)
+	}
+	if states := td.ListLeaseStates(testNIC); len(states) != 0 {
+		t.Errorf("got lease states %+v of dynamic leases, wanted none", states)
+	}
+
+	// A static lease takes the address over
+	if err := td.AddLease(testNIC, "aa:bb:cc:dd:ee:01", "192.168.0.2", "192.168.0.103", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	// And the client of a dynamic lease gets a static one
+	if err := td.AddLease(testNIC, "52:54:00:00:00:01", "192.168.0.2", "192.168.0.50", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if leases := td.ListDynamicLeases(testNIC); len(leases) != 0 {
+		t.Errorf("got dynamic leases %+v after adding static leases, wanted none", leases)
+	}
+	if lease := td.GetLease(testNIC, "52:54:00:00:00:01"); lease.Dynamic || !lease.ClientIP.Equal(net.ParseIP("192.168.0.50")) {
+		t.Errorf("got lease %s, wanted the static lease", lease.String())
+	}
+}
+
+func TestExpireDynamicLeases(t *testing.T) {
+	td := New()
+	td.SetDynamicRange(testNIC, newTestDynamicRange(t, "192.168.0.100", "192.168.0.110"))
+
+	hwAddr, _ := net.ParseMAC("52:54:00:00:00:01")
+	if reply := discover(t, td, hwAddr.String()); reply == nil {
+		t.Fatal("got no offer from the dynamic range")
+	}
+
+	// The offer is held for a while only
+	td.expireDynamicLeases(testNIC, time.Now())
+	if leases := td.ListDynamicLeases(testNIC); len(leases) != 1 {
+		t.Errorf("got %d dynamic leases within the offer hold time, wanted 1", len(leases))
+	}
+	td.expireDynamicLeases(testNIC, time.Now().Add(offerHoldTime+time.Second))
+	if leases := td.ListDynamicLeases(testNIC); len(leases) != 0 {
+		t.Errorf("got %d dynamic leases after the offer hold time, wanted none", len(leases))
+	}
+
+	// A bound lease lasts for the lease time
+	discover(t, td, hwAddr.String())
+	m, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := dhcpv4.NewRequestFromOffer(&dhcpv4.DHCPv4{
+		OpCode:        dhcpv4.OpcodeBootReply,
+		ClientHWAddr:  hwAddr,
+		TransactionID: m.TransactionID,
+		YourIPAddr:    net.ParseIP("192.168.0.100"),
+		Options: dhcpv4.OptionsFromList(
+			dhcpv4.OptMessageType(dhcpv4.MessageTypeOffer),
+			dhcpv4.OptServerIdentifier(net.ParseIP("192.168.0.2")),
+		),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	td.dhcpHandler(testNIC, &testPacketConn{}, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, request)
+
+	td.expireDynamicLeases(testNIC, time.Now().Add(offerHoldTime+time.Second))
+	if leases := td.ListDynamicLeases(testNIC); len(leases) != 1 {
+		t.Errorf("got %d dynamic leases within the lease time, wanted 1", len(leases))
+	}
+	td.expireDynamicLeases(testNIC, time.Now().Add(601*time.Second))
+	if leases := td.ListDynamicLeases(testNIC); len(leases) != 0 {
+		t.Errorf("got %d dynamic leases after the lease time, wanted none", len(leases))
+	}
+
+	// Dropping the dynamic range drops its leases
+	discover(t, td, hwAddr.String())
+	td.SetDynamicRange(testNIC, nil)
+	if leases := td.ListDynamicLeases(testNIC); len(leases) != 0 {
+		t.Errorf("got %d dynamic leases without dynamic range, wanted none", len(leases))
+	}
+}
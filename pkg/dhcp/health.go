@@ -0,0 +1,173 @@
+package dhcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+)
+
+// HealthProbeHWAddr is the hardware address of the synthetic health probe.
+// It is locally administered so that it never belongs to a virtual machine.
+const HealthProbeHWAddr = "02:00:00:00:00:01"
+
+// healthProbeTimeout is how long the health probe waits for the whole
+// DISCOVER/REQUEST exchange.
+const healthProbeTimeout = 3 * time.Second
+
+// healthProbeLeaseTime is the lease time offered to the health probe, in
+// seconds.
+const healthProbeLeaseTime = 60
+
+// exchangeFunc sends a message of the health probe and returns the reply of
+// the DHCP server.
+type exchangeFunc func(ctx context.Context, m *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error)
+
+// SetHealthProbeLease lets the health probe on nic get a lease of serverIP,
+// the only address of the network no virtual machine ever gets. The lease is
+// kept apart from the others, it is neither listed nor bound. An empty
+// serverIP removes the lease.
+func (a *DHCPAllocator) SetHealthProbeLease(nic, serverIP, cidr string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if serverIP == "" {
+		delete(a.healthLeases, nic)
+		return nil
+	}
+
+	leaseTime := healthProbeLeaseTime
+	lease, err := newLease(serverIP, serverIP, cidr, "", nil, nil, nil, nil, &leaseTime)
+	if err != nil {
+		return err
+	}
+	a.healthLeases[nic] = lease
+
+	return nil
+}
+
+// ProbeHealth runs a DISCOVER/REQUEST exchange for HealthProbeHWAddr against
+// the DHCP server on nic and returns how long it took. The messages are
+// broadcast over nic and answered by the server through its socket like any
+// other. In dry-run, where no server listens on nic, they are passed to the
+// handler directly.
+func (a *DHCPAllocator) ProbeHealth(ctx context.Context, nic string) (time.Duration, error) {
+	a.mutex.RLock()
+	lease, ok := a.healthLeases[nic]
+	servers := a.servers[nic]
+	dryRun := servers != nil && servers.server4 == nil
+	a.mutex.RUnlock()
+
+	if !ok {
+		return 0, fmt.Errorf("no health probe lease on nic %s", nic)
+	}
+	if servers == nil {
+		return 0, fmt.Errorf("dhcp service on nic %s is not running", nic)
+	}
+
+	exchange := a.loopbackExchange(nic)
+	if !dryRun {
+		conn, err := server4.NewIPv4UDPConn(nic, &net.UDPAddr{Port: dhcpv4.ClientPort})
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		exchange = udpExchange(conn, lease.ServerIP)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	hwAddr, _ := net.ParseMAC(HealthProbeHWAddr)
+	start := time.Now()
+
+	// Broadcast replies let the agent see rogue DHCP servers answering too
+	discover, err := dhcpv4.NewDiscovery(hwAddr, dhcpv4.WithBroadcast(true))
+	if err != nil {
+		return 0, err
+	}
+	offer, err := exchange(ctx, discover)
+	if err != nil {
+		return 0, fmt.Errorf("no DHCPOFFER: %w", err)
+	}
+	if offer.MessageType() != dhcpv4.MessageTypeOffer || !offer.YourIPAddr.Equal(lease.ClientIP) {
+		return 0, fmt.Errorf("got DHCP%s of %s, wanted DHCPOFFER of %s", offer.MessageType(), offer.YourIPAddr, lease.ClientIP)
+	}
+
+	request, err := dhcpv4.NewRequestFromOffer(offer)
+	if err != nil {
+		return 0, err
+	}
+	ack, err := exchange(ctx, request)
+	if err != nil {
+		return 0, fmt.Errorf("no DHCPACK: %w", err)
+	}
+	if ack.MessageType() != dhcpv4.MessageTypeAck {
+		return 0, fmt.Errorf("got DHCP%s, wanted DHCPACK", ack.MessageType())
+	}
+
+	return time.Since(start), nil
+}
+
+// isHealthProbe reports whether the message was sent by the health probe.
+func isHealthProbe(m *dhcpv4.DHCPv4) bool {
+	return m.ClientHWAddr.String() == HealthProbeHWAddr
+}
+
+// loopbackExchange passes the messages to the handler of nic directly.
+func (a *DHCPAllocator) loopbackExchange(nic string) exchangeFunc {
+	return func(ctx context.Context, m *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
+		conn := &recordingConn{}
+		a.dhcpHandler(nic, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		if conn.written == nil {
+			return nil, fmt.Errorf("no reply")
+		}
+		return dhcpv4.FromBytes(conn.written)
+	}
+}
+
+// udpExchange broadcasts the messages over conn and waits for the reply of
+// serverIP. Replies of other DHCP servers on the link are skipped.
+func udpExchange(conn net.PacketConn, serverIP net.IP) exchangeFunc {
+	return func(ctx context.Context, m *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
+		if _, err := conn.WriteTo(m.ToBytes(), &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ServerPort}); err != nil {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			if err := conn.SetReadDeadline(deadline); err != nil {
+				return nil, err
+			}
+		}
+
+		buf := make([]byte, 4096)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return nil, err
+			}
+			reply, err := dhcpv4.FromBytes(buf[:n])
+			if err != nil || reply.OpCode != dhcpv4.OpcodeBootReply || reply.TransactionID != m.TransactionID {
+				continue
+			}
+			if !reply.ServerIdentifier().Equal(serverIP) {
+				continue
+			}
+			return reply, nil
+		}
+	}
+}
+
+// recordingConn keeps the reply written by the handler.
+type recordingConn struct {
+	net.PacketConn
+
+	written []byte
+}
+
+func (c *recordingConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.written = append([]byte(nil), b...)
+	return len(b), nil
+}
@@ -0,0 +1,52 @@
+package dhcp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProbeHealth(t *testing.T) {
+	ctx := context.Background()
+	td := New()
+
+	if _, err := td.ProbeHealth(ctx, testNIC); err == nil {
+		t.Errorf("got no error without health probe lease, wanted one")
+	}
+
+	if err := td.SetHealthProbeLease(testNIC, "192.168.0.2", "192.168.0.0/24"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := td.ProbeHealth(ctx, testNIC); err == nil {
+		t.Errorf("got no error without dhcp service, wanted one")
+	}
+
+	if err := td.DryRun(ctx, testNIC); err != nil {
+		t.Fatal(err)
+	}
+	messages := 0
+	td.SetMessageHooks(MessageHooks{
+		Received: func(nic, messageType string) {
+			messages++
+		},
+	})
+	latency, err := td.ProbeHealth(ctx, testNIC)
+	if err != nil {
+		t.Fatalf("got error %v, wanted none", err)
+	}
+	if latency <= 0 || latency > healthProbeTimeout {
+		t.Errorf("got latency %s, wanted within %s", latency, healthProbeTimeout)
+	}
+	if messages != 0 {
+		t.Errorf("got %d messages of the health probe counted, wanted none", messages)
+	}
+	if got := td.LeaseCount(testNIC); got != 0 {
+		t.Errorf("got %d leases, wanted the health probe lease not to be listed", got)
+	}
+
+	if err := td.SetHealthProbeLease(testNIC, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := td.ProbeHealth(ctx, testNIC); err == nil {
+		t.Errorf("got no error after removing the health probe lease, wanted one")
+	}
+}
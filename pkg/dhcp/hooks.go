@@ -0,0 +1,46 @@
+package dhcp
+
+import (
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// MessageHooks are called as DHCPv4 messages are served, e.g. to export them
+// as metrics. Unset hooks are skipped. They are called with the lease store
+// locked, so they must not call back into the DHCPAllocator.
+type MessageHooks struct {
+	// Received is called for every message not dropped by the rate limits,
+	// with its type, e.g. "DISCOVER"
+	Received func(nic, messageType string)
+	// Replied is called for every reply sent, with its type, e.g. "OFFER",
+	// and the time taken since the message was received
+	Replied func(nic, messageType string, latency time.Duration)
+	// UnknownClient is called for every message of a client without a lease
+	UnknownClient func(nic string)
+}
+
+// SetMessageHooks replaces the hooks called as DHCPv4 messages are served.
+func (a *DHCPAllocator) SetMessageHooks(hooks MessageHooks) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.hooks = hooks
+}
+
+func (h MessageHooks) received(nic string, messageType dhcpv4.MessageType) {
+	if h.Received != nil {
+		h.Received(nic, messageType.String())
+	}
+}
+
+func (h MessageHooks) replied(nic string, reply *dhcpv4.DHCPv4, received time.Time) {
+	if h.Replied != nil {
+		h.Replied(nic, reply.MessageType().String(), time.Since(received))
+	}
+}
+
+func (h MessageHooks) unknownClient(nic string) {
+	if h.UnknownClient != nil {
+		h.UnknownClient(nic)
+	}
+}
@@ -0,0 +1,64 @@
+package dhcp
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+func TestMessageHooks(t *testing.T) {
+	td := New()
+	if err := td.AddLease(testNIC, "aa:bb:cc:dd:ee:ff", "192.168.0.2", "192.168.0.100", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var received, replied []string
+	unknown := 0
+	td.SetMessageHooks(MessageHooks{
+		Received: func(nic, messageType string) {
+			received = append(received, messageType)
+		},
+		Replied: func(nic, messageType string, latency time.Duration) {
+			if latency < 0 {
+				t.Errorf("%s: got latency %s, wanted non-negative", messageType, latency)
+			}
+			replied = append(replied, messageType)
+		},
+		UnknownClient: func(nic string) {
+			unknown++
+		},
+	})
+
+	offer := discover(t, td, "aa:bb:cc:dd:ee:ff")
+	if offer == nil {
+		t.Fatal("got no offer")
+	}
+	request, err := dhcpv4.NewRequestFromOffer(offer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &testPacketConn{}
+	td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, request)
+
+	if reply := discover(t, td, "52:54:00:00:00:01"); reply != nil {
+		t.Errorf("got reply %s to unknown client, wanted none", reply.YourIPAddr)
+	}
+
+	// The health probe is not counted, even without its lease
+	if reply := discover(t, td, HealthProbeHWAddr); reply != nil {
+		t.Errorf("got reply %s to health probe without lease, wanted none", reply.YourIPAddr)
+	}
+
+	if want := []string{"DISCOVER", "REQUEST", "DISCOVER"}; !reflect.DeepEqual(received, want) {
+		t.Errorf("got received %v, wanted %v", received, want)
+	}
+	if want := []string{"OFFER", "ACK"}; !reflect.DeepEqual(replied, want) {
+		t.Errorf("got replied %v, wanted %v", replied, want)
+	}
+	if unknown != 1 {
+		t.Errorf("got %d unknown client messages, wanted 1", unknown)
+	}
+}
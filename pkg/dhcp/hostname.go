@@ -0,0 +1,80 @@
+package dhcp
+
+import (
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/rfc1035label"
+
+	"github.com/harvester/vm-dhcp-controller/pkg/util"
+)
+
+// Flags of the Client FQDN option (RFC 4702)
+const (
+	fqdnFlagS byte = 1 << iota // the server performs the A RR updates
+	fqdnFlagO                  // the server overrides the S flag of the client
+	fqdnFlagE                  // the domain name is in canonical wire format
+	fqdnFlagN                  // the server performs no DNS updates
+)
+
+// WithHostname sets the host name handed out to the client in option 12.
+// The client's FQDN is the host name suffixed with the domain name of the
+// lease, if any.
+func WithHostname(hostname string) LeaseOption {
+	return func(l *DHCPLease) error {
+		l.Hostname = hostname
+		return nil
+	}
+}
+
+// WithDDNS tells the client that its DNS records are updated on its behalf, as
+// the controller registers them once the lease is bound.
+func WithDDNS() LeaseOption {
+	return func(l *DHCPLease) error {
+		l.DDNS = true
+		return nil
+	}
+}
+
+func (l *DHCPLease) fqdn() string {
+	return util.FQDN(l.Hostname, l.DomainName)
+}
+
+// setHostnameOptions sets the host name of the reply and, if the client sent
+// a Client FQDN option, answers it with the FQDN of the lease.
+func setHostnameOptions(reply, m *dhcpv4.DHCPv4, lease DHCPLease) {
+	if lease.Hostname == "" {
+		return
+	}
+
+	reply.UpdateOption(dhcpv4.OptHostName(lease.Hostname))
+
+	clientFQDN := m.GetOneOption(dhcpv4.OptionFQDN)
+	if len(clientFQDN) == 0 {
+		return
+	}
+
+	// With DDNS, the A RR is updated on behalf of the client, overriding its
+	// request to do so itself if need be. Otherwise, tell the client not to
+	// expect DNS updates, overriding its request for the server to do so.
+	flags := clientFQDN[0] & fqdnFlagE
+	if lease.DDNS {
+		flags |= fqdnFlagS
+		if clientFQDN[0]&fqdnFlagS == 0 {
+			flags |= fqdnFlagO
+		}
+	} else {
+		flags |= fqdnFlagN
+		if clientFQDN[0]&fqdnFlagS != 0 {
+			flags |= fqdnFlagO
+		}
+	}
+
+	// RCODE1 and RCODE2 are deprecated and must be 255 in server responses
+	value := []byte{flags, 255, 255}
+	if flags&fqdnFlagE != 0 {
+		value = append(value, (&rfc1035label.Labels{Labels: []string{lease.fqdn()}}).ToBytes()...)
+	} else {
+		value = append(value, lease.fqdn()...)
+	}
+
+	reply.UpdateOption(dhcpv4.OptGeneric(dhcpv4.OptionFQDN, value))
+}
@@ -0,0 +1,114 @@
+package dhcp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+func TestDHCPHandlerHostname(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	ddnsHWAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	domainName := "example.com"
+
+	td := New()
+	if err := td.AddLease(
+		testNIC,
+		hwAddr.String(),
+		"192.168.0.2",
+		"192.168.0.10",
+		"192.168.0.0/24",
+		"192.168.0.1",
+		nil,
+		&domainName,
+		nil,
+		nil,
+		nil,
+		WithHostname("web-01"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := td.AddLease(
+		testNIC,
+		ddnsHWAddr.String(),
+		"192.168.0.2",
+		"192.168.0.11",
+		"192.168.0.0/24",
+		"192.168.0.1",
+		nil,
+		&domainName,
+		nil,
+		nil,
+		nil,
+		WithHostname("web-01"),
+		WithDDNS(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name       string
+		ddns       bool
+		clientFQDN []byte
+		wantFQDN   []byte
+	}{
+		{
+			name: "no client fqdn",
+		},
+		{
+			name:       "client fqdn in wire format asking for server updates",
+			clientFQDN: append([]byte{fqdnFlagS | fqdnFlagE, 0, 0}, "\x06client\x00"...),
+			wantFQDN:   append([]byte{fqdnFlagO | fqdnFlagE | fqdnFlagN, 255, 255}, "\x06web-01\x07example\x03com\x00"...),
+		},
+		{
+			name:       "client fqdn in ascii",
+			clientFQDN: append([]byte{0, 0, 0}, "client"...),
+			wantFQDN:   append([]byte{fqdnFlagN, 255, 255}, "web-01.example.com"...),
+		},
+		{
+			name:       "client fqdn asking for server updates with ddns",
+			ddns:       true,
+			clientFQDN: append([]byte{fqdnFlagS, 0, 0}, "client"...),
+			wantFQDN:   append([]byte{fqdnFlagS, 255, 255}, "web-01.example.com"...),
+		},
+		{
+			name:       "client fqdn asking for no server updates with ddns",
+			ddns:       true,
+			clientFQDN: append([]byte{0, 0, 0}, "client"...),
+			wantFQDN:   append([]byte{fqdnFlagS | fqdnFlagO, 255, 255}, "web-01.example.com"...),
+		},
+	}
+
+	for _, tc := range testCases {
+		clientHWAddr := hwAddr
+		if tc.ddns {
+			clientHWAddr = ddnsHWAddr
+		}
+		m, err := dhcpv4.NewDiscovery(clientHWAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.clientFQDN != nil {
+			m.UpdateOption(dhcpv4.OptGeneric(dhcpv4.OptionFQDN, tc.clientFQDN))
+		}
+
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+		if len(conn.written) != 1 {
+			t.Fatalf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+		}
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := reply.HostName(); got != "web-01" {
+			t.Errorf("%s: got hostname %q, wanted %q", tc.name, got, "web-01")
+		}
+		if got := reply.GetOneOption(dhcpv4.OptionFQDN); !bytes.Equal(got, tc.wantFQDN) {
+			t.Errorf("%s: got fqdn %q, wanted %q", tc.name, got, tc.wantFQDN)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package dhcp
+
+import (
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/sirupsen/logrus"
+)
+
+// Prober checks whether an address is already in use on the link, e.g. by a
+// host with a statically configured address.
+type Prober interface {
+	// Probe reports whether ip is in use on the link of nic by a host other
+	// than the client with clientHWAddr, which might hold the address
+	// already, e.g. after the agent restarted.
+	Probe(nic string, ip net.IP, clientHWAddr net.HardwareAddr) (bool, error)
+}
+
+// WithConflictDetection probes the address of the lease before it is first
+// offered. An address found in use is not offered; the lease is marked as
+// conflicting instead, so that the controller allocates another address.
+func WithConflictDetection() LeaseOption {
+	return func(l *DHCPLease) error {
+		l.ConflictDetection = true
+		return nil
+	}
+}
+
+// SetProber replaces the prober of the leases with conflict detection.
+func (a *DHCPAllocator) SetProber(prober Prober) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.prober = prober
+}
+
+// probeLease probes the address of the lease of the client unless it has
+// been probed already, and reports whether the address may be offered. The
+// lock is not held while probing so that other clients are served meanwhile.
+// A failed probe lets the address be offered, as a client finding it in use
+// still declines it.
+func (a *DHCPAllocator) probeLease(nic string, m *dhcpv4.DHCPv4) bool {
+	a.mutex.RLock()
+	key := a.leaseKeyOf(nic, m)
+	lease, ok := a.leases[key]
+	prober := a.prober
+	a.mutex.RUnlock()
+
+	if !ok || lease.Dynamic || !lease.ConflictDetection || prober == nil {
+		return true
+	}
+	if lease.State == LeaseStateConflict {
+		return false
+	}
+	if lease.Probed {
+		return true
+	}
+
+	inUse, err := prober.Probe(nic, lease.ClientIP, m.ClientHWAddr)
+	if err != nil {
+		logrus.Warnf("(dhcp.dhcpHandler) cannot probe %s of hwaddr %s on nic %s: %v", lease.ClientIP, key.hwAddr, nic, err)
+		return true
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// The lease might have been replaced while probing
+	current, ok := a.leases[key]
+	if !ok || !current.ClientIP.Equal(lease.ClientIP) {
+		return true
+	}
+
+	if inUse {
+		logrus.Warnf("(dhcp.dhcpHandler) %s of hwaddr %s is already in use on nic %s: address conflict", lease.ClientIP, key.hwAddr, nic)
+		current.State = LeaseStateConflict
+		current.StateTime = time.Now()
+		a.leases[key] = current
+		a.notifyLeaseState(nic)
+		return false
+	}
+
+	current.Probed = true
+	a.leases[key] = current
+	return true
+}
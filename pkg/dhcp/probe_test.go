@@ -0,0 +1,122 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+type testProber struct {
+	inUse        map[string]bool
+	probes       int
+	clientHWAddr net.HardwareAddr
+}
+
+func (p *testProber) Probe(nic string, ip net.IP, clientHWAddr net.HardwareAddr) (bool, error) {
+	p.probes++
+	p.clientHWAddr = clientHWAddr
+	return p.inUse[ip.String()], nil
+}
+
+func TestDHCPHandlerConflictDetection(t *testing.T) {
+	prober := &testProber{inUse: map[string]bool{"192.168.0.11": true}}
+
+	td := New()
+	td.SetProber(prober)
+	for hwAddr, clientIP := range map[string]string{
+		"aa:bb:cc:dd:ee:01": "192.168.0.10",
+		"aa:bb:cc:dd:ee:02": "192.168.0.11",
+	} {
+		if err := td.AddLease(testNIC, hwAddr, "192.168.0.2", clientIP, "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil, WithConflictDetection()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		hwAddr      string
+		wantReplies int
+		wantProbes  int
+		wantState   LeaseState
+	}{
+		{
+			name:        "free address",
+			hwAddr:      "aa:bb:cc:dd:ee:01",
+			wantReplies: 1,
+			wantProbes:  1,
+		},
+		{
+			name:        "free address probed already",
+			hwAddr:      "aa:bb:cc:dd:ee:01",
+			wantReplies: 1,
+			wantProbes:  1,
+		},
+		{
+			name:        "address in use",
+			hwAddr:      "aa:bb:cc:dd:ee:02",
+			wantReplies: 0,
+			wantProbes:  2,
+			wantState:   LeaseStateConflict,
+		},
+		{
+			name:        "conflicting address not probed again",
+			hwAddr:      "aa:bb:cc:dd:ee:02",
+			wantReplies: 0,
+			wantProbes:  2,
+			wantState:   LeaseStateConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		hwAddr, _ := net.ParseMAC(tc.hwAddr)
+		m, err := dhcpv4.NewDiscovery(hwAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+
+		if len(conn.written) != tc.wantReplies {
+			t.Errorf("%s: got %d replies, wanted %d", tc.name, len(conn.written), tc.wantReplies)
+		}
+		if prober.probes != tc.wantProbes {
+			t.Errorf("%s: got %d probes, wanted %d", tc.name, prober.probes, tc.wantProbes)
+		}
+		if prober.clientHWAddr.String() != tc.hwAddr {
+			t.Errorf("%s: got probe for client %s, wanted %s", tc.name, prober.clientHWAddr, tc.hwAddr)
+		}
+		if got := td.GetLease(testNIC, tc.hwAddr).State; got != tc.wantState {
+			t.Errorf("%s: got state %q, wanted %q", tc.name, got, tc.wantState)
+		}
+	}
+
+	if _, ok := td.ListLeaseStates(testNIC)["aa:bb:cc:dd:ee:02"]; !ok {
+		t.Errorf("conflicting lease is not listed")
+	}
+}
+
+func TestDHCPHandlerNoConflictDetection(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	prober := &testProber{inUse: map[string]bool{"192.168.0.10": true}}
+
+	td := New()
+	td.SetProber(prober)
+	if err := td.AddLease(testNIC, hwAddr.String(), "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &testPacketConn{}
+	td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+
+	if len(conn.written) != 1 {
+		t.Errorf("got %d replies, wanted 1", len(conn.written))
+	}
+	if prober.probes != 0 {
+		t.Errorf("got %d probes, wanted none", prober.probes)
+	}
+}
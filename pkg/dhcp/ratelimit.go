@@ -0,0 +1,303 @@
+package dhcp
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// DropReason tells why a packet was dropped without being served.
+type DropReason string
+
+const (
+	DropReasonClientRateLimit DropReason = "client_rate_limit"
+	DropReasonGlobalRateLimit DropReason = "global_rate_limit"
+)
+
+// clientLimiterIdleTimeout is how long the limiter of a client that stopped
+// sending packets is kept around.
+const clientLimiterIdleTimeout = 5 * time.Minute
+
+// maxClientLimiters caps the clients with a limiter, so that a flood of
+// spoofed hardware addresses does not exhaust the memory of the agent.
+const maxClientLimiters = 4096
+
+// unknownClientsReportInterval is how often the packets of clients without a
+// lease are summarised in the log.
+const unknownClientsReportInterval = 10 * time.Second
+
+// maxReportedUnknownClients caps the hardware addresses listed in a summary.
+const maxReportedUnknownClients = 10
+
+// maxUnknownClients caps the hardware addresses counted per network interface
+// between two summaries. The packets of further ones are counted as a whole.
+const maxUnknownClients = 1024
+
+// RateLimit configures the token buckets applied to incoming DHCPv4 packets.
+// Each client hardware address on each network interface gets a bucket
+// refilled at ClientRate packets per second and holding up to ClientBurst
+// packets. All the packets of the agent share another bucket sized with
+// GlobalRate and GlobalBurst. A zero rate disables the respective bucket.
+type RateLimit struct {
+	ClientRate  float64
+	ClientBurst int
+	GlobalRate  float64
+	GlobalBurst int
+}
+
+var DefaultRateLimit = RateLimit{
+	ClientRate:  5,
+	ClientBurst: 10,
+	GlobalRate:  500,
+	GlobalBurst: 1000,
+}
+
+type clientLimiter struct {
+	key      leaseKey
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type dropKey struct {
+	nic    string
+	reason DropReason
+}
+
+// rateLimiter decides whether a packet is served, and counts the ones which
+// are not. It has its own lock so that flooding clients are turned away
+// without contending for the lease store.
+type rateLimiter struct {
+	config  RateLimit
+	global  *rate.Limiter
+	clients map[leaseKey]*list.Element
+	// Client limiters, the most recently seen first
+	recent     *list.List
+	maxClients int
+	dropped    map[dropKey]uint64
+	onDrop     func(nic string, reason DropReason)
+	mutex      sync.Mutex
+}
+
+func newRateLimiter(config RateLimit) *rateLimiter {
+	l := &rateLimiter{
+		maxClients: maxClientLimiters,
+		dropped:    make(map[dropKey]uint64),
+	}
+	l.configure(config)
+	return l
+}
+
+// configure must be called with the lock held, or before the limiter is in
+// use. The buckets of all clients start over full.
+func (l *rateLimiter) configure(config RateLimit) {
+	l.config = config
+	l.clients = make(map[leaseKey]*list.Element)
+	l.recent = list.New()
+	l.global = nil
+	if config.GlobalRate > 0 {
+		l.global = rate.NewLimiter(rate.Limit(config.GlobalRate), max(config.GlobalBurst, 1))
+	}
+}
+
+// allow takes a token from the bucket of the client, then from the global
+// one. The client bucket comes first so that a single flooding client does
+// not drain the tokens of everybody else.
+func (l *rateLimiter) allow(key leaseKey, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.config.ClientRate > 0 {
+		if !l.client(key, now).limiter.AllowN(now, 1) {
+			l.drop(key.nic, DropReasonClientRateLimit)
+			return false
+		}
+	}
+
+	if l.global != nil && !l.global.AllowN(now, 1) {
+		l.drop(key.nic, DropReasonGlobalRateLimit)
+		return false
+	}
+
+	return true
+}
+
+// drop must be called with the lock held.
+func (l *rateLimiter) drop(nic string, reason DropReason) {
+	l.dropped[dropKey{nic: nic, reason: reason}]++
+	if l.onDrop != nil {
+		l.onDrop(nic, reason)
+	}
+}
+
+// client returns the limiter of the client, creating it if needed. The
+// clients which have been idle for a while are forgotten, and so is the least
+// recently seen one if there are too many clients; it starts over with a full
+// bucket when it comes back. It must be called with the lock held.
+func (l *rateLimiter) client(key leaseKey, now time.Time) *clientLimiter {
+	if e, ok := l.clients[key]; ok {
+		client := e.Value.(*clientLimiter)
+		client.lastSeen = now
+		l.recent.MoveToFront(e)
+		return client
+	}
+
+	for e := l.recent.Back(); e != nil; e = l.recent.Back() {
+		client := e.Value.(*clientLimiter)
+		if now.Sub(client.lastSeen) < clientLimiterIdleTimeout && l.recent.Len() < l.maxClients {
+			break
+		}
+		l.recent.Remove(e)
+		delete(l.clients, client.key)
+	}
+
+	client := &clientLimiter{
+		key:      key,
+		limiter:  rate.NewLimiter(rate.Limit(l.config.ClientRate), max(l.config.ClientBurst, 1)),
+		lastSeen: now,
+	}
+	l.clients[key] = l.recent.PushFront(client)
+	return client
+}
+
+// SetRateLimit replaces the rate limits of incoming DHCPv4 packets. The
+// buckets of all clients start over full.
+func (a *DHCPAllocator) SetRateLimit(config RateLimit) {
+	a.limiter.mutex.Lock()
+	defer a.limiter.mutex.Unlock()
+	a.limiter.configure(config)
+}
+
+// OnPacketDropped registers fn to be called for every packet which is
+// dropped by the rate limits, e.g. to export the drops as metrics.
+func (a *DHCPAllocator) OnPacketDropped(fn func(nic string, reason DropReason)) {
+	a.limiter.mutex.Lock()
+	defer a.limiter.mutex.Unlock()
+	a.limiter.onDrop = fn
+}
+
+// DroppedPackets returns the number of packets dropped on nic so far, keyed
+// by the reason.
+func (a *DHCPAllocator) DroppedPackets(nic string) map[DropReason]uint64 {
+	a.limiter.mutex.Lock()
+	defer a.limiter.mutex.Unlock()
+
+	dropped := make(map[DropReason]uint64)
+	for key, count := range a.limiter.dropped {
+		if key.nic == nic {
+			dropped[key.reason] = count
+		}
+	}
+	return dropped
+}
+
+// unknownClients counts the packets of clients without a lease so that they
+// are logged as a periodic summary rather than one line per packet.
+type unknownClients struct {
+	packets    map[string]*unknownClientPackets
+	maxHWAddrs int
+	mutex      sync.Mutex
+}
+
+// unknownClientPackets holds the packet counts of the unknown clients on a
+// network interface.
+type unknownClientPackets struct {
+	hwAddrs map[string]int
+	// Packets of the hardware addresses beyond the cap
+	untracked int
+}
+
+func newUnknownClients() *unknownClients {
+	return &unknownClients{
+		packets:    make(map[string]*unknownClientPackets),
+		maxHWAddrs: maxUnknownClients,
+	}
+}
+
+func (u *unknownClients) record(nic, hwAddr string) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	packets := u.packets[nic]
+	if packets == nil {
+		packets = &unknownClientPackets{hwAddrs: make(map[string]int)}
+		u.packets[nic] = packets
+	}
+	if _, ok := packets.hwAddrs[hwAddr]; !ok && len(packets.hwAddrs) >= u.maxHWAddrs {
+		packets.untracked++
+		return
+	}
+	packets.hwAddrs[hwAddr]++
+}
+
+// flush returns the packet counts of the unknown clients on nic seen since
+// the last call, and starts over.
+func (u *unknownClients) flush(nic string) *unknownClientPackets {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	packets := u.packets[nic]
+	delete(u.packets, nic)
+	return packets
+}
+
+// reportUnknownClients logs the unknown clients on nic every
+// unknownClientsReportInterval until ctx is done.
+func (a *DHCPAllocator) reportUnknownClients(ctx context.Context, nic string) {
+	ticker := time.NewTicker(unknownClientsReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if summary := summarizeUnknownClients(a.unknownClients.flush(nic)); summary != "" {
+				logrus.Warnf("(dhcp.reportUnknownClients) NO LEASE FOUND on nic %s in the last %s: %s", nic, unknownClientsReportInterval, summary)
+			}
+		}
+	}
+}
+
+// summarizeUnknownClients lists the hardware addresses with the most packets
+// first, e.g. "3 packets from 2 hwaddrs (aa:bb:cc:dd:ee:ff x2, ...)".
+func summarizeUnknownClients(unknown *unknownClientPackets) string {
+	if unknown == nil || (len(unknown.hwAddrs) == 0 && unknown.untracked == 0) {
+		return ""
+	}
+
+	packets := unknown.hwAddrs
+	hwAddrs := make([]string, 0, len(packets))
+	total := unknown.untracked
+	for hwAddr, count := range packets {
+		hwAddrs = append(hwAddrs, hwAddr)
+		total += count
+	}
+	sort.Slice(hwAddrs, func(i, j int) bool {
+		if packets[hwAddrs[i]] != packets[hwAddrs[j]] {
+			return packets[hwAddrs[i]] > packets[hwAddrs[j]]
+		}
+		return hwAddrs[i] < hwAddrs[j]
+	})
+
+	listed := make([]string, 0, maxReportedUnknownClients+1)
+	for i, hwAddr := range hwAddrs {
+		if i == maxReportedUnknownClients {
+			listed = append(listed, "...")
+			break
+		}
+		listed = append(listed, hwAddr+" x"+strconv.Itoa(packets[hwAddr]))
+	}
+
+	if unknown.untracked > 0 {
+		return fmt.Sprintf("%d packets from more than %d hwaddrs (%s)", total, len(hwAddrs), strings.Join(listed, ", "))
+	}
+	return fmt.Sprintf("%d packets from %d hwaddrs (%s)", total, len(hwAddrs), strings.Join(listed, ", "))
+}
@@ -0,0 +1,175 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(RateLimit{
+		ClientRate:  1,
+		ClientBurst: 2,
+		GlobalRate:  1,
+		GlobalBurst: 3,
+	})
+
+	var drops []DropReason
+	l.onDrop = func(nic string, reason DropReason) {
+		drops = append(drops, reason)
+	}
+
+	now := time.Now()
+	client1 := leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:01"}
+	client2 := leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:02"}
+	client3 := leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:03"}
+
+	testCases := []struct {
+		name   string
+		key    leaseKey
+		offset time.Duration
+		want   bool
+	}{
+		{name: "first packet of client 1", key: client1, want: true},
+		{name: "burst of client 1", key: client1, want: true},
+		{name: "client 1 over its limit", key: client1, want: false},
+		{name: "first packet of client 2", key: client2, want: true},
+		{name: "global limit exceeded", key: client3, want: false},
+		{name: "client 1 after refill", key: client1, offset: time.Second, want: true},
+	}
+
+	for _, tc := range testCases {
+		if got := l.allow(tc.key, now.Add(tc.offset)); got != tc.want {
+			t.Errorf("%s: got %t, wanted %t", tc.name, got, tc.want)
+		}
+	}
+
+	wantDrops := []DropReason{DropReasonClientRateLimit, DropReasonGlobalRateLimit}
+	if len(drops) != len(wantDrops) {
+		t.Fatalf("got drops %v, wanted %v", drops, wantDrops)
+	}
+	for i := range drops {
+		if drops[i] != wantDrops[i] {
+			t.Errorf("got drops %v, wanted %v", drops, wantDrops)
+		}
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := newRateLimiter(RateLimit{})
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if !l.allow(leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:ff"}, now) {
+			t.Fatalf("packet %d dropped without rate limits", i)
+		}
+	}
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	l := newRateLimiter(RateLimit{ClientRate: 1, ClientBurst: 1})
+
+	now := time.Now()
+	l.allow(leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:01"}, now)
+	l.allow(leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:02"}, now.Add(clientLimiterIdleTimeout))
+
+	if len(l.clients) != 1 {
+		t.Errorf("got %d client limiters, wanted 1", len(l.clients))
+	}
+}
+
+func TestRateLimiterMaxClients(t *testing.T) {
+	l := newRateLimiter(RateLimit{ClientRate: 1, ClientBurst: 1})
+	l.maxClients = 2
+
+	now := time.Now()
+	client1 := leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:01"}
+	client2 := leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:02"}
+	client3 := leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:03"}
+
+	l.allow(client1, now)
+	l.allow(client2, now)
+	l.allow(client1, now)
+	l.allow(client3, now)
+
+	if len(l.clients) != 2 || l.recent.Len() != 2 {
+		t.Fatalf("got %d client limiters (%d recent), wanted 2", len(l.clients), l.recent.Len())
+	}
+	if _, ok := l.clients[client2]; ok {
+		t.Errorf("least recently seen client limiter is kept")
+	}
+	if l.allow(client1, now) {
+		t.Errorf("client 1 over its limit allowed")
+	}
+}
+
+func TestDHCPHandlerRateLimit(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+	td := New()
+	td.SetRateLimit(RateLimit{ClientRate: 0.001, ClientBurst: 1})
+	if err := td.AddLease(testNIC, hwAddr.String(), "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := &testPacketConn{}
+	for i := 0; i < 3; i++ {
+		m, err := dhcpv4.NewDiscovery(hwAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+	}
+
+	if len(conn.written) != 1 {
+		t.Errorf("got %d replies, wanted 1", len(conn.written))
+	}
+	if got := td.DroppedPackets(testNIC)[DropReasonClientRateLimit]; got != 2 {
+		t.Errorf("got %d dropped packets, wanted 2", got)
+	}
+	if got := td.DroppedPackets("eth2"); len(got) != 0 {
+		t.Errorf("got dropped packets %v on eth2, wanted none", got)
+	}
+}
+
+func TestDHCPHandlerUnknownClients(t *testing.T) {
+	td := New()
+
+	conn := &testPacketConn{}
+	for _, mac := range []string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02", "aa:bb:cc:dd:ee:02"} {
+		hwAddr, _ := net.ParseMAC(mac)
+		m, err := dhcpv4.NewDiscovery(hwAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: net.IPv4zero, Port: dhcpv4.ClientPort}, m)
+	}
+
+	if len(conn.written) != 0 {
+		t.Errorf("got %d replies, wanted none", len(conn.written))
+	}
+
+	want := "3 packets from 2 hwaddrs (aa:bb:cc:dd:ee:02 x2, aa:bb:cc:dd:ee:01 x1)"
+	if got := summarizeUnknownClients(td.unknownClients.flush(testNIC)); got != want {
+		t.Errorf("got summary %q, wanted %q", got, want)
+	}
+	if got := summarizeUnknownClients(td.unknownClients.flush(testNIC)); got != "" {
+		t.Errorf("got summary %q after flush, wanted none", got)
+	}
+}
+
+func TestUnknownClientsMaxHWAddrs(t *testing.T) {
+	u := newUnknownClients()
+	u.maxHWAddrs = 2
+
+	for _, mac := range []string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02", "aa:bb:cc:dd:ee:03", "aa:bb:cc:dd:ee:04", "aa:bb:cc:dd:ee:01"} {
+		u.record(testNIC, mac)
+	}
+
+	want := "5 packets from more than 2 hwaddrs (aa:bb:cc:dd:ee:01 x2, aa:bb:cc:dd:ee:02 x1)"
+	if got := summarizeUnknownClients(u.flush(testNIC)); got != want {
+		t.Errorf("got summary %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package dhcp
+
+import (
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LeaseRecord is what the clients did with a lease, as opposed to the
+// configuration parameters it was built of. It is kept across restarts of the
+// agent along with the configuration.
+type LeaseRecord struct {
+	IPAddress string     `json:"ipAddress"`
+	Dynamic   bool       `json:"dynamic,omitempty"`
+	Probed    bool       `json:"probed,omitempty"`
+	State     LeaseState `json:"state,omitempty"`
+	StateTime time.Time  `json:"stateTime,omitempty"`
+
+	ClientHostname string    `json:"clientHostname,omitempty"`
+	BoundTime      time.Time `json:"boundTime,omitempty"`
+	RenewTime      time.Time `json:"renewTime,omitempty"`
+	ExpiryTime     time.Time `json:"expiryTime,omitempty"`
+}
+
+// ListLeaseRecords returns the records of the leases on nic keyed by hardware
+// address, static and dynamic ones alike.
+func (a *DHCPAllocator) ListLeaseRecords(nic string) map[string]LeaseRecord {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	records := make(map[string]LeaseRecord)
+	for key, lease := range a.leases {
+		if key.nic != nic {
+			continue
+		}
+		records[key.hwAddr] = LeaseRecord{
+			IPAddress:      lease.ClientIP.String(),
+			Dynamic:        lease.Dynamic,
+			Probed:         lease.Probed,
+			State:          lease.State,
+			StateTime:      lease.StateTime,
+			ClientHostname: lease.ClientHostname,
+			BoundTime:      lease.BoundTime,
+			RenewTime:      lease.RenewTime,
+			ExpiryTime:     lease.ExpiryTime,
+		}
+	}
+
+	return records
+}
+
+// RestoreLeaseRecords applies the records of a former run to the leases on
+// nic. A record of a static lease is applied only if the lease still has the
+// same address. A dynamic lease is handed out again if it has not expired
+// yet, and its address is within the dynamic range and not in use, so the
+// dynamic range must be set beforehand.
+func (a *DHCPAllocator) RestoreLeaseRecords(nic string, records map[string]LeaseRecord) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	inUse := make(map[netip.Addr]bool)
+	for key, lease := range a.leases {
+		if key.nic != nic {
+			continue
+		}
+		if addr, ok := netip.AddrFromSlice(lease.ClientIP.To4()); ok {
+			inUse[addr] = true
+		}
+	}
+
+	now := time.Now()
+	for hwAddr, record := range records {
+		key := leaseKey{nic: nic, hwAddr: hwAddr}
+		ip := net.ParseIP(record.IPAddress)
+
+		if !record.Dynamic {
+			lease, ok := a.leases[key]
+			if !ok || lease.Dynamic || !lease.ClientIP.Equal(ip) {
+				continue
+			}
+			a.leases[key] = record.apply(lease)
+			continue
+		}
+
+		r := a.dynamicRanges[nic]
+		addr, ok := netip.AddrFromSlice(ip.To4())
+		if r == nil || !ok || !r.contains(ip) || inUse[addr] || a.checkLease(key) || record.ExpiryTime.Before(now) {
+			logrus.Debugf("(dhcp.RestoreLeaseRecords) skip dynamic lease %s of hwaddr %s on nic %s", record.IPAddress, hwAddr, nic)
+			continue
+		}
+		lease := r.template
+		lease.ClientIP = ip.To4()
+		a.leases[key] = record.apply(lease)
+		inUse[addr] = true
+
+		logrus.Infof("(dhcp.RestoreLeaseRecords) restore dynamic lease %s of hwaddr %s on nic %s", lease.ClientIP, hwAddr, nic)
+	}
+}
+
+func (r LeaseRecord) apply(lease DHCPLease) DHCPLease {
+	lease.Probed = r.Probed
+	lease.State = r.State
+	lease.StateTime = r.StateTime
+	lease.ClientHostname = r.ClientHostname
+	lease.BoundTime = r.BoundTime
+	lease.RenewTime = r.RenewTime
+	lease.ExpiryTime = r.ExpiryTime
+	return lease
+}
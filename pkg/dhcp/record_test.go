@@ -0,0 +1,61 @@
+package dhcp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestoreLeaseRecords(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	old := New()
+	if err := old.AddLease(testNIC, "aa:bb:cc:dd:ee:01", "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.AddLease(testNIC, "aa:bb:cc:dd:ee:02", "192.168.0.2", "192.168.0.11", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	old.SetDynamicRange(testNIC, newTestDynamicRange(t, "192.168.0.100", "192.168.0.110"))
+	old.mutex.Lock()
+	old.bindLease(leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:01"}, "vm-1", time.Hour)
+	old.bindLease(leaseKey{nic: testNIC, hwAddr: "aa:bb:cc:dd:ee:02"}, "vm-2", time.Hour)
+	old.mutex.Unlock()
+	if reply := discover(t, old, "52:54:00:00:00:01"); reply == nil {
+		t.Fatal("got no offer from the dynamic range")
+	}
+	records := old.ListLeaseRecords(testNIC)
+	expired := records["52:54:00:00:00:01"]
+	expired.IPAddress = "192.168.0.101"
+	expired.ExpiryTime = now.Add(-time.Minute)
+	records["52:54:00:00:00:02"] = expired
+
+	// The address of the second lease changed in the meantime
+	td := New()
+	if err := td.AddLease(testNIC, "aa:bb:cc:dd:ee:01", "192.168.0.2", "192.168.0.10", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := td.AddLease(testNIC, "aa:bb:cc:dd:ee:02", "192.168.0.2", "192.168.0.12", "192.168.0.0/24", "192.168.0.1", nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	td.SetDynamicRange(testNIC, newTestDynamicRange(t, "192.168.0.100", "192.168.0.110"))
+	td.RestoreLeaseRecords(testNIC, records)
+
+	lease := td.GetLease(testNIC, "aa:bb:cc:dd:ee:01")
+	if lease.State != LeaseStateBound || lease.ClientHostname != "vm-1" || lease.ExpiryTime.IsZero() {
+		t.Errorf("unchanged static lease: got state %q, hostname %q, expiry %s, wanted it restored", lease.State, lease.ClientHostname, lease.ExpiryTime)
+	}
+
+	lease = td.GetLease(testNIC, "aa:bb:cc:dd:ee:02")
+	if lease.State != "" || lease.ClientHostname != "" {
+		t.Errorf("changed static lease: got state %q, hostname %q, wanted none", lease.State, lease.ClientHostname)
+	}
+
+	lease = td.GetLease(testNIC, "52:54:00:00:00:01")
+	if !lease.Dynamic || lease.ClientIP.String() != "192.168.0.100" || lease.ServerIP.String() != "192.168.0.2" {
+		t.Errorf("dynamic lease: got %s, wanted 192.168.0.100 restored", lease.String())
+	}
+
+	if td.checkLease(leaseKey{nic: testNIC, hwAddr: "52:54:00:00:00:02"}) {
+		t.Errorf("expired dynamic lease: got it restored, wanted not")
+	}
+}
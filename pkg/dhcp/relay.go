@@ -0,0 +1,65 @@
+package dhcp
+
+import (
+	"net"
+	"slices"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// RelayAgentIDs holds the relay agent information (option 82) identifying the
+// network of a lease, for relay agents whose giaddr is not within the subnet.
+type RelayAgentIDs struct {
+	CircuitIDs []string
+	RemoteIDs  []string
+}
+
+// WithRelayCircuitID makes the lease available to messages relayed with the
+// given agent circuit ID.
+func WithRelayCircuitID(circuitID string) LeaseOption {
+	return func(l *DHCPLease) error {
+		relayAgentIDsOf(l).CircuitIDs = append(relayAgentIDsOf(l).CircuitIDs, circuitID)
+		return nil
+	}
+}
+
+// WithRelayRemoteID makes the lease available to messages relayed with the
+// given agent remote ID.
+func WithRelayRemoteID(remoteID string) LeaseOption {
+	return func(l *DHCPLease) error {
+		relayAgentIDsOf(l).RemoteIDs = append(relayAgentIDsOf(l).RemoteIDs, remoteID)
+		return nil
+	}
+}
+
+func relayAgentIDsOf(l *DHCPLease) *RelayAgentIDs {
+	if l.RelayAgentIDs == nil {
+		l.RelayAgentIDs = &RelayAgentIDs{}
+	}
+	return l.RelayAgentIDs
+}
+
+func isRelayed(m *dhcpv4.DHCPv4) bool {
+	return m.GatewayIPAddr != nil && !m.GatewayIPAddr.IsUnspecified()
+}
+
+// servesRelay reports whether the lease belongs to the network the relayed
+// message comes from, i.e. the circuit or remote ID of the relay agent
+// matches, or else giaddr is within the subnet of the lease.
+func (l *DHCPLease) servesRelay(m *dhcpv4.DHCPv4) bool {
+	if l.RelayAgentIDs != nil {
+		if info := m.RelayAgentInfo(); info != nil {
+			circuitID := info.Get(dhcpv4.AgentCircuitIDSubOption)
+			if circuitID != nil && slices.Contains(l.RelayAgentIDs.CircuitIDs, string(circuitID)) {
+				return true
+			}
+			remoteID := info.Get(dhcpv4.AgentRemoteIDSubOption)
+			if remoteID != nil && slices.Contains(l.RelayAgentIDs.RemoteIDs, string(remoteID)) {
+				return true
+			}
+		}
+	}
+
+	ipNet := net.IPNet{IP: l.ClientIP.Mask(l.SubnetMask), Mask: l.SubnetMask}
+	return ipNet.Contains(m.GatewayIPAddr)
+}
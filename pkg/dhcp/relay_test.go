@@ -0,0 +1,127 @@
+package dhcp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+func TestDHCPHandlerRelay(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	clientIP := net.ParseIP("192.168.0.10")
+
+	td := New()
+	if err := td.AddLease(
+		testNIC,
+		hwAddr.String(),
+		"192.168.0.2",
+		clientIP.String(),
+		"192.168.0.0/24",
+		"192.168.0.254",
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		WithRelayCircuitID("vlan100"),
+		WithRelayRemoteID("leaf-1"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		giaddr    net.IP
+		circuitID string
+		remoteID  string
+		wantReply bool
+	}{
+		{
+			name:      "giaddr within subnet",
+			giaddr:    net.ParseIP("192.168.0.1"),
+			wantReply: true,
+		},
+		{
+			name:      "giaddr within subnet with relay agent information",
+			giaddr:    net.ParseIP("192.168.0.1"),
+			circuitID: "vlan200",
+			remoteID:  "leaf-2",
+			wantReply: true,
+		},
+		{
+			name:   "giaddr outside subnet",
+			giaddr: net.ParseIP("10.0.0.1"),
+		},
+		{
+			name:      "giaddr outside subnet with matching circuit id",
+			giaddr:    net.ParseIP("10.0.0.1"),
+			circuitID: "vlan100",
+			wantReply: true,
+		},
+		{
+			name:      "giaddr outside subnet with matching remote id",
+			giaddr:    net.ParseIP("10.0.0.1"),
+			circuitID: "vlan200",
+			remoteID:  "leaf-1",
+			wantReply: true,
+		},
+		{
+			name:      "giaddr outside subnet with unknown relay agent information",
+			giaddr:    net.ParseIP("10.0.0.1"),
+			circuitID: "vlan200",
+			remoteID:  "leaf-2",
+		},
+	}
+
+	for _, tc := range testCases {
+		m, err := dhcpv4.NewDiscovery(hwAddr, dhcpv4.WithGatewayIP(tc.giaddr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var subOptions []dhcpv4.Option
+		if tc.circuitID != "" {
+			subOptions = append(subOptions, dhcpv4.OptGeneric(dhcpv4.AgentCircuitIDSubOption, []byte(tc.circuitID)))
+		}
+		if tc.remoteID != "" {
+			subOptions = append(subOptions, dhcpv4.OptGeneric(dhcpv4.AgentRemoteIDSubOption, []byte(tc.remoteID)))
+		}
+		if len(subOptions) > 0 {
+			m.UpdateOption(dhcpv4.OptRelayAgentInfo(subOptions...))
+		}
+
+		conn := &testPacketConn{}
+		td.dhcpHandler(testNIC, conn, &net.UDPAddr{IP: tc.giaddr, Port: dhcpv4.ServerPort}, m)
+
+		if !tc.wantReply {
+			if len(conn.written) != 0 {
+				t.Errorf("%s: got %d replies, wanted none", tc.name, len(conn.written))
+			}
+			continue
+		}
+		if len(conn.written) != 1 {
+			t.Errorf("%s: got %d replies, wanted 1", tc.name, len(conn.written))
+			continue
+		}
+
+		reply, err := dhcpv4.FromBytes(conn.written[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reply.YourIPAddr.Equal(clientIP) {
+			t.Errorf("%s: got yiaddr %s, wanted %s", tc.name, reply.YourIPAddr, clientIP)
+		}
+		if !reply.GatewayIPAddr.Equal(tc.giaddr) {
+			t.Errorf("%s: got giaddr %s, wanted %s", tc.name, reply.GatewayIPAddr, tc.giaddr)
+		}
+		wantAddr := &net.UDPAddr{IP: tc.giaddr, Port: dhcpv4.ServerPort}
+		if conn.addrs[0].String() != wantAddr.String() {
+			t.Errorf("%s: got destination %s, wanted %s", tc.name, conn.addrs[0], wantAddr)
+		}
+		got, want := reply.Options.Get(dhcpv4.OptionRelayAgentInformation), m.Options.Get(dhcpv4.OptionRelayAgentInformation)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got relay agent information %x, wanted %x", tc.name, got, want)
+		}
+	}
+}
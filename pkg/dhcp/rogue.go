@@ -0,0 +1,75 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+const (
+	ipProtocolUDP = 17
+	udpHeaderLen  = 8
+)
+
+// RogueServer is a DHCP server other than the agent answering clients on the
+// network of an IPPool.
+type RogueServer struct {
+	// Server identifier (option 54) of the reply, or its source address if
+	// there is none
+	ServerIP string
+	// Hardware address the reply was sent from
+	HWAddr string
+	// Type of the reply, i.e. "OFFER" or "ACK"
+	MessageType string
+}
+
+// serverIPOf returns the address of the DHCP server on nic, if known. Must be
+// called with the read lock held.
+func (a *DHCPAllocator) serverIPOf(nic string) net.IP {
+	return a.healthLeases[nic].ServerIP
+}
+
+// rogueReply parses an IPv4 packet sent from hwAddr and returns the DHCP
+// server which sent it, if it is a DHCPOFFER or DHCPACK of a server other
+// than serverIP. Fragmented packets are skipped.
+func rogueReply(packet []byte, hwAddr net.HardwareAddr, serverIP net.IP) (RogueServer, bool) {
+	if serverIP == nil || len(packet) < 20 || packet[0]>>4 != 4 || packet[9] != ipProtocolUDP {
+		return RogueServer{}, false
+	}
+	if binary.BigEndian.Uint16(packet[6:8])&0x3fff != 0 {
+		return RogueServer{}, false
+	}
+	ihl := int(packet[0]&0x0f) * 4
+	if ihl < 20 || len(packet) < ihl+udpHeaderLen {
+		return RogueServer{}, false
+	}
+	udp := packet[ihl:]
+	if binary.BigEndian.Uint16(udp[0:2]) != dhcpv4.ServerPort || binary.BigEndian.Uint16(udp[2:4]) != dhcpv4.ClientPort {
+		return RogueServer{}, false
+	}
+
+	m, err := dhcpv4.FromBytes(udp[udpHeaderLen:])
+	if err != nil || m.OpCode != dhcpv4.OpcodeBootReply {
+		return RogueServer{}, false
+	}
+	messageType := m.MessageType()
+	if messageType != dhcpv4.MessageTypeOffer && messageType != dhcpv4.MessageTypeAck {
+		return RogueServer{}, false
+	}
+
+	// Replies of the agent forwarded by relay agents carry its identifier
+	serverID := m.ServerIdentifier()
+	if serverID == nil {
+		serverID = net.IP(packet[12:16])
+	}
+	if serverID.Equal(serverIP) {
+		return RogueServer{}, false
+	}
+
+	return RogueServer{
+		ServerIP:    serverID.String(),
+		HWAddr:      hwAddr.String(),
+		MessageType: messageType.String(),
+	}, true
+}
@@ -0,0 +1,86 @@
+package dhcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// rogueServerPollInterval is how often the watch for rogue DHCP servers
+// checks whether it is done.
+const rogueServerPollInterval = time.Second
+
+// dhcpReplyFilter is a classic BPF program which accepts unfragmented UDP
+// packets from port 67 to port 68, starting at the IPv4 header.
+var dhcpReplyFilter = []unix.SockFilter{
+	{Code: unix.BPF_LD | unix.BPF_B | unix.BPF_ABS, K: 9},
+	{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 8, K: ipProtocolUDP},
+	{Code: unix.BPF_LD | unix.BPF_H | unix.BPF_ABS, K: 6},
+	{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, Jt: 6, K: 0x1fff},
+	{Code: unix.BPF_LDX | unix.BPF_B | unix.BPF_MSH, K: 0},
+	{Code: unix.BPF_LD | unix.BPF_H | unix.BPF_IND, K: 0},
+	{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 3, K: 67},
+	{Code: unix.BPF_LD | unix.BPF_H | unix.BPF_IND, K: 2},
+	{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 1, K: 68},
+	{Code: unix.BPF_RET | unix.BPF_K, K: 0xffff},
+	{Code: unix.BPF_RET | unix.BPF_K, K: 0},
+}
+
+// WatchRogueServers listens on nic for DHCPOFFERs and DHCPACKs of servers
+// other than the agent, and calls fn for each of them until ctx is done. Only
+// the replies reaching the interface are seen, i.e. the broadcast ones and
+// the ones sent to the agent. It needs the CAP_NET_RAW capability.
+func (a *DHCPAllocator) WatchRogueServers(ctx context.Context, nic string, fn func(RogueServer)) error {
+	ifi, err := net.InterfaceByName(nic)
+	if err != nil {
+		return err
+	}
+
+	proto := htons(unix.ETH_P_IP)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(proto))
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	prog := unix.SockFprog{Len: uint16(len(dhcpReplyFilter)), Filter: &dhcpReplyFilter[0]}
+	if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &prog); err != nil {
+		return err
+	}
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: proto, Ifindex: ifi.Index}); err != nil {
+		return err
+	}
+	tv := unix.NsecToTimeval(rogueServerPollInterval.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 65536)
+	for ctx.Err() == nil {
+		n, from, err := unix.Recvfrom(fd, buf, 0)
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		ll, ok := from.(*unix.SockaddrLinklayer)
+		if !ok || ll.Pkttype == unix.PACKET_OUTGOING {
+			continue
+		}
+
+		a.mutex.RLock()
+		serverIP := a.serverIPOf(nic)
+		a.mutex.RUnlock()
+
+		if server, ok := rogueReply(buf[:n], net.HardwareAddr(ll.Addr[:ll.Halen]), serverIP); ok {
+			fn(server)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package dhcp
+
+import (
+	"context"
+	"fmt"
+)
+
+// WatchRogueServers is only supported on Linux.
+func (a *DHCPAllocator) WatchRogueServers(ctx context.Context, nic string, fn func(RogueServer)) error {
+	return fmt.Errorf("rogue dhcp server detection is not supported on this platform")
+}
@@ -0,0 +1,109 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// udpPacket wraps payload into an IPv4 UDP packet.
+func udpPacket(src net.IP, srcPort, dstPort uint16, flags uint16, payload []byte) []byte {
+	b := make([]byte, 20+udpHeaderLen, 20+udpHeaderLen+len(payload))
+	b[0] = 0x45
+	binary.BigEndian.PutUint16(b[2:4], uint16(len(b)+len(payload)))
+	binary.BigEndian.PutUint16(b[6:8], flags)
+	b[8] = 64
+	b[9] = ipProtocolUDP
+	copy(b[12:16], src.To4())
+	copy(b[16:20], net.IPv4bcast.To4())
+	binary.BigEndian.PutUint16(b[20:22], srcPort)
+	binary.BigEndian.PutUint16(b[22:24], dstPort)
+	binary.BigEndian.PutUint16(b[24:26], uint16(udpHeaderLen+len(payload)))
+	return append(b, payload...)
+}
+
+func newTestReply(t *testing.T, messageType dhcpv4.MessageType, serverID net.IP) []byte {
+	hwAddr, _ := net.ParseMAC("52:54:00:00:00:01")
+	m, err := dhcpv4.New(
+		dhcpv4.WithHwAddr(hwAddr),
+		dhcpv4.WithReply(&dhcpv4.DHCPv4{OpCode: dhcpv4.OpcodeBootRequest}),
+		dhcpv4.WithMessageType(messageType),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverID != nil {
+		m.UpdateOption(dhcpv4.OptServerIdentifier(serverID))
+	}
+	return m.ToBytes()
+}
+
+func TestRogueReply(t *testing.T) {
+	serverIP := net.ParseIP("192.168.0.2")
+	rogueIP := net.ParseIP("192.168.0.254")
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+	discover, err := dhcpv4.NewDiscovery(hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		packet   []byte
+		serverIP net.IP
+		want     *RogueServer
+	}{
+		{
+			name:     "offer of rogue server",
+			packet:   udpPacket(rogueIP, 67, 68, 0, newTestReply(t, dhcpv4.MessageTypeOffer, rogueIP)),
+			serverIP: serverIP,
+			want:     &RogueServer{ServerIP: "192.168.0.254", HWAddr: "aa:bb:cc:dd:ee:ff", MessageType: "OFFER"},
+		},
+		{
+			name:     "ack of rogue server without server identifier",
+			packet:   udpPacket(rogueIP, 67, 68, 0, newTestReply(t, dhcpv4.MessageTypeAck, nil)),
+			serverIP: serverIP,
+			want:     &RogueServer{ServerIP: "192.168.0.254", HWAddr: "aa:bb:cc:dd:ee:ff", MessageType: "ACK"},
+		},
+		{
+			name:     "offer of the agent forwarded by relay agent",
+			packet:   udpPacket(net.ParseIP("192.168.0.1"), 67, 68, 0, newTestReply(t, dhcpv4.MessageTypeOffer, serverIP)),
+			serverIP: serverIP,
+		},
+		{
+			name:     "nak of rogue server",
+			packet:   udpPacket(rogueIP, 67, 68, 0, newTestReply(t, dhcpv4.MessageTypeNak, rogueIP)),
+			serverIP: serverIP,
+		},
+		{
+			name:     "request",
+			packet:   udpPacket(net.IPv4zero, 68, 67, 0, discover.ToBytes()),
+			serverIP: serverIP,
+		},
+		{
+			name:     "fragment",
+			packet:   udpPacket(rogueIP, 67, 68, 0x2000, newTestReply(t, dhcpv4.MessageTypeOffer, rogueIP)),
+			serverIP: serverIP,
+		},
+		{
+			name:   "unknown server address",
+			packet: udpPacket(rogueIP, 67, 68, 0, newTestReply(t, dhcpv4.MessageTypeOffer, rogueIP)),
+		},
+	}
+
+	for _, tc := range testCases {
+		got, ok := rogueReply(tc.packet, hwAddr, tc.serverIP)
+		if tc.want == nil {
+			if ok {
+				t.Errorf("%s: got rogue server %+v, wanted none", tc.name, got)
+			}
+			continue
+		}
+		if !ok || got != *tc.want {
+			t.Errorf("%s: got rogue server %+v (%t), wanted %+v", tc.name, got, ok, *tc.want)
+		}
+	}
+}
@@ -33,6 +33,7 @@ func init() {
 type Interface interface {
 	Node() NodeController
 	Pod() PodController
+	Secret() SecretController
 }
 
 func New(controllerFactory controller.SharedControllerFactory) Interface {
@@ -52,3 +53,7 @@ func (v *version) Node() NodeController {
 func (v *version) Pod() PodController {
 	return generic.NewController[*v1.Pod, *v1.PodList](schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}, "pods", true, v.controllerFactory)
 }
+
+func (v *version) Secret() SecretController {
+	return generic.NewController[*v1.Secret, *v1.SecretList](schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Secret"}, "secrets", true, v.controllerFactory)
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -17,12 +18,26 @@ var (
 	LabelMACAddress   = "mac"
 	LabelIPAddress    = "ip"
 	LabelState        = "state"
+	LabelResult       = "result"
+	LabelReason       = "reason"
+	LabelMessageType  = "type"
 )
 
 type MetricsAllocator struct {
 	ipPoolUsed      *prometheus.GaugeVec
 	ipPoolAvailable *prometheus.GaugeVec
 	vmNetCfgStatus  *prometheus.GaugeVec
+	tftpTransfers   *prometheus.CounterVec
+	tftpSentBytes   *prometheus.CounterVec
+	dhcpDropped     *prometheus.CounterVec
+	dhcpMessages    *prometheus.CounterVec
+	dhcpUnknown     *prometheus.CounterVec
+	dhcpReplyTime   *prometheus.HistogramVec
+	dhcpLeases      *prometheus.GaugeVec
+	ipPoolLastSync  *prometheus.GaugeVec
+	dhcpRogue       *prometheus.CounterVec
+	healthProbeTime *prometheus.GaugeVec
+	healthProbeLast *prometheus.GaugeVec
 	registry        *prometheus.Registry
 }
 
@@ -63,12 +78,129 @@ func NewMetricsAllocator() *MetricsAllocator {
 				LabelState,
 			},
 		),
+		tftpTransfers: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "vmdhcpagent_tftp_transfers_total",
+				Help: "Amount of TFTP read requests by result",
+			},
+			[]string{
+				LabelIPPoolName,
+				LabelResult,
+			},
+		),
+		tftpSentBytes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "vmdhcpagent_tftp_sent_bytes_total",
+				Help: "Amount of file content bytes sent over TFTP",
+			},
+			[]string{
+				LabelIPPoolName,
+			},
+		),
+		dhcpDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "vmdhcpagent_dhcp_dropped_packets_total",
+				Help: "Amount of DHCP packets dropped without being served by reason",
+			},
+			[]string{
+				LabelIPPoolName,
+				LabelReason,
+			},
+		),
+		dhcpMessages: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "vmdhcpagent_dhcp_messages_total",
+				Help: "Amount of DHCP messages received and replies sent by message type",
+			},
+			[]string{
+				LabelIPPoolName,
+				LabelMessageType,
+			},
+		),
+		dhcpUnknown: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "vmdhcpagent_dhcp_unknown_client_messages_total",
+				Help: "Amount of DHCP messages of clients without a lease",
+			},
+			[]string{
+				LabelIPPoolName,
+			},
+		),
+		dhcpReplyTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "vmdhcpagent_dhcp_reply_duration_seconds",
+				Help:    "Time taken to reply to DHCP messages by reply type",
+				Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+			},
+			[]string{
+				LabelIPPoolName,
+				LabelMessageType,
+			},
+		),
+		dhcpLeases: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "vmdhcpagent_dhcp_leases",
+				Help: "Amount of leases in the lease store, static and dynamic ones",
+			},
+			[]string{
+				LabelIPPoolName,
+			},
+		),
+		ipPoolLastSync: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "vmdhcpagent_ippool_last_sync_timestamp_seconds",
+				Help: "Time the leases were last synced with the IPPool",
+			},
+			[]string{
+				LabelIPPoolName,
+			},
+		),
+		dhcpRogue: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "vmdhcpagent_dhcp_rogue_server_replies_total",
+				Help: "Amount of DHCP replies seen from servers other than the agent",
+			},
+			[]string{
+				LabelIPPoolName,
+				LabelIPAddress,
+				LabelMACAddress,
+			},
+		),
+		healthProbeTime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "vmdhcpagent_dhcp_health_probe_duration_seconds",
+				Help: "Time taken by the last successful DHCP health probe",
+			},
+			[]string{
+				LabelIPPoolName,
+			},
+		),
+		healthProbeLast: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "vmdhcpagent_dhcp_health_probe_last_success_timestamp_seconds",
+				Help: "Time of the last successful DHCP health probe",
+			},
+			[]string{
+				LabelIPPoolName,
+			},
+		),
 	}
 
 	metricsAllocator.registry = prometheus.NewRegistry()
 	metricsAllocator.registry.MustRegister(metricsAllocator.ipPoolUsed)
 	metricsAllocator.registry.MustRegister(metricsAllocator.ipPoolAvailable)
 	metricsAllocator.registry.MustRegister(metricsAllocator.vmNetCfgStatus)
+	metricsAllocator.registry.MustRegister(metricsAllocator.tftpTransfers)
+	metricsAllocator.registry.MustRegister(metricsAllocator.tftpSentBytes)
+	metricsAllocator.registry.MustRegister(metricsAllocator.dhcpDropped)
+	metricsAllocator.registry.MustRegister(metricsAllocator.dhcpMessages)
+	metricsAllocator.registry.MustRegister(metricsAllocator.dhcpUnknown)
+	metricsAllocator.registry.MustRegister(metricsAllocator.dhcpReplyTime)
+	metricsAllocator.registry.MustRegister(metricsAllocator.dhcpLeases)
+	metricsAllocator.registry.MustRegister(metricsAllocator.ipPoolLastSync)
+	metricsAllocator.registry.MustRegister(metricsAllocator.dhcpRogue)
+	metricsAllocator.registry.MustRegister(metricsAllocator.healthProbeTime)
+	metricsAllocator.registry.MustRegister(metricsAllocator.healthProbeLast)
 
 	return metricsAllocator
 }
@@ -113,6 +245,76 @@ func (a *MetricsAllocator) UpdateVmNetCfgStatus(name, networkName, macAddress, i
 	}).Set(float64(1))
 }
 
+func (a *MetricsAllocator) ObserveTFTPTransfer(ipPoolName, result string, sentBytes int64) {
+	a.tftpTransfers.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+		LabelResult:     result,
+	}).Inc()
+
+	a.tftpSentBytes.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+	}).Add(float64(sentBytes))
+}
+
+func (a *MetricsAllocator) IncDHCPDroppedPackets(ipPoolName, reason string) {
+	a.dhcpDropped.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+		LabelReason:     reason,
+	}).Inc()
+}
+
+func (a *MetricsAllocator) IncDHCPMessages(ipPoolName, messageType string) {
+	a.dhcpMessages.With(prometheus.Labels{
+		LabelIPPoolName:  ipPoolName,
+		LabelMessageType: messageType,
+	}).Inc()
+}
+
+func (a *MetricsAllocator) ObserveDHCPReply(ipPoolName, messageType string, latency time.Duration) {
+	a.IncDHCPMessages(ipPoolName, messageType)
+
+	a.dhcpReplyTime.With(prometheus.Labels{
+		LabelIPPoolName:  ipPoolName,
+		LabelMessageType: messageType,
+	}).Observe(latency.Seconds())
+}
+
+func (a *MetricsAllocator) IncDHCPUnknownClientMessages(ipPoolName string) {
+	a.dhcpUnknown.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+	}).Inc()
+}
+
+func (a *MetricsAllocator) UpdateDHCPLeases(ipPoolName string, leases int) {
+	a.dhcpLeases.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+	}).Set(float64(leases))
+}
+
+func (a *MetricsAllocator) UpdateIPPoolLastSync(ipPoolName string, t time.Time) {
+	a.ipPoolLastSync.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+	}).Set(float64(t.Unix()))
+}
+
+func (a *MetricsAllocator) IncDHCPRogueServerReplies(ipPoolName, serverIP, macAddress string) {
+	a.dhcpRogue.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+		LabelIPAddress:  serverIP,
+		LabelMACAddress: macAddress,
+	}).Inc()
+}
+
+func (a *MetricsAllocator) ObserveDHCPHealthProbe(ipPoolName string, latency time.Duration, t time.Time) {
+	a.healthProbeTime.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+	}).Set(latency.Seconds())
+
+	a.healthProbeLast.With(prometheus.Labels{
+		LabelIPPoolName: ipPoolName,
+	}).Set(float64(t.Unix()))
+}
+
 func (a *MetricsAllocator) DeleteVmNetCfgStatus(name string) {
 	var vmNetCfgMetrics []prometheus.Labels
 
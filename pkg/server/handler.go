@@ -75,6 +75,30 @@ func listLeaseHandler(dhcpAllocator *dhcp.DHCPAllocator) http.Handler {
 	})
 }
 
+func getLeaseHandler(dhcpAllocator *dhcp.DHCPAllocator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		nic := params["nic"]
+		macAddress := params["macAddress"]
+		lease := dhcpAllocator.GetLease(nic, macAddress)
+		if lease.ClientIP == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(w, "lease of %s on %s not found", macAddress, nic)
+			return
+		}
+		payload, err := json.Marshal(lease)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprintf(w, "cannot marshal lease of %s on %s: %s", macAddress, nic, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(payload); err != nil {
+			logrus.Error(err)
+		}
+	})
+}
+
 func metricsHandler(metricsAllocator *metrics.MetricsAllocator) http.Handler {
 	return metricsAllocator.GetHTTPHandler()
 }
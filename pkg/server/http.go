@@ -35,7 +35,11 @@ func (s *HTTPServer) registerProbeHandlers() {
 		}
 	})
 	s.router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		if err := json.NewEncoder(w).Encode(map[string]bool{"ok": true}); err != nil {
+		ready := s.Ready == nil || s.Ready()
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(map[string]bool{"ok": ready}); err != nil {
 			logrus.Fatal(err)
 		}
 	})
@@ -55,9 +59,16 @@ func (s *HTTPServer) RegisterControllerHandlers() {
 func (s *HTTPServer) RegisterAgentHandlers() {
 	s.registerProbeHandlers()
 
+	// The lease of a single client is always served read-only, dumping all
+	// of them is for debugging only
+	s.router.Handle("/leases/{nic}/{macAddress}", getLeaseHandler(s.DHCPAllocator)).Methods(http.MethodGet)
 	if s.DebugMode {
 		s.router.Handle("/leases", listLeaseHandler(s.DHCPAllocator))
 	}
+
+	if s.MetricsAllocator != nil {
+		s.router.Handle("/metrics", metricsHandler(s.MetricsAllocator))
+	}
 }
 
 func (s *HTTPServer) Run() error {
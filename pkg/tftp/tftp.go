@@ -0,0 +1,446 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/insomniacslk/dhcp/interfaces"
+	"github.com/sirupsen/logrus"
+
+	"github.com/harvester/vm-dhcp-controller/pkg/metrics"
+)
+
+const (
+	opRRQ   uint16 = 1
+	opWRQ   uint16 = 2
+	opDATA  uint16 = 3
+	opACK   uint16 = 4
+	opERROR uint16 = 5
+	opOACK  uint16 = 6
+)
+
+const (
+	errNotDefined        uint16 = 0
+	errFileNotFound      uint16 = 1
+	errAccessViolation   uint16 = 2
+	errIllegalOperation  uint16 = 4
+	errUnknownTransferID uint16 = 5
+)
+
+const (
+	DefaultPort = 69
+
+	defaultBlockSize = 512
+	minBlockSize     = 8
+	maxBlockSize     = 65464
+	defaultTimeout   = 1 * time.Second
+	maxTimeout       = 255 * time.Second
+	maxRetries       = 5
+	// Requests beyond this many transfers in progress are refused, as each
+	// one holds a socket for up to maxRetries times its timeout
+	maxTransfers = 64
+)
+
+const (
+	ResultSuccess  = "success"
+	ResultNotFound = "not_found"
+	ResultRejected = "rejected"
+	ResultFailed   = "failed"
+)
+
+// Server is a read-only TFTP server (RFC 1350) supporting the blksize
+// (RFC 2348), timeout and tsize (RFC 2349) options. Files are served from
+// the root directory only, write requests are refused. At most maxTransfers
+// requests are handled at a time.
+type Server struct {
+	root   string
+	addr   string
+	ipPool string
+
+	metricsAllocator *metrics.MetricsAllocator
+
+	conn      net.PacketConn
+	transfers chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewServer(root, ipPool string, metricsAllocator *metrics.MetricsAllocator) *Server {
+	return &Server{
+		root:             root,
+		addr:             fmt.Sprintf(":%d", DefaultPort),
+		ipPool:           ipPool,
+		metricsAllocator: metricsAllocator,
+		transfers:        make(chan struct{}, maxTransfers),
+	}
+}
+
+// Run starts serving on the given network interface. An empty nic means all
+// interfaces.
+func (s *Server) Run(ctx context.Context, nic string) (err error) {
+	logrus.Infof("(tftp.Run) starting TFTP service on nic %s serving %s", nic, s.root)
+
+	s.conn, err = listen(ctx, nic, s.addr)
+	if err != nil {
+		return err
+	}
+
+	go s.serve(ctx, nic)
+
+	return nil
+}
+
+func listen(ctx context.Context, nic, addr string) (net.PacketConn, error) {
+	lc := net.ListenConfig{}
+	if nic != "" {
+		lc.Control = func(_, _ string, c syscall.RawConn) error {
+			var bindErr error
+			if err := c.Control(func(fd uintptr) {
+				bindErr = interfaces.BindToInterface(int(fd), nic)
+			}); err != nil {
+				return err
+			}
+			return bindErr
+		}
+	}
+	return lc.ListenPacket(ctx, "udp4", addr)
+}
+
+func (s *Server) serve(ctx context.Context, nic string) {
+	buf := make([]byte, 65536)
+	for {
+		n, peer, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logrus.Errorf("(tftp.serve) TFTP server on nic %s exited with error: %v", nic, err)
+			}
+			return
+		}
+
+		select {
+		case s.transfers <- struct{}{}:
+		default:
+			logrus.Warnf("(tftp.serve) too many transfers in progress on nic %s, refusing request of %s", nic, peer)
+			s.observe(ResultRejected, 0)
+			sendError(s.conn, peer, errNotDefined, "too many transfers")
+			continue
+		}
+
+		packet := append([]byte(nil), buf[:n]...)
+		s.wg.Add(1)
+		go func() {
+			defer func() {
+				<-s.transfers
+				s.wg.Done()
+			}()
+			s.handle(ctx, nic, peer, packet)
+		}()
+	}
+}
+
+func (s *Server) stop() error {
+	logrus.Info("(tftp.Stop) stopping TFTP service")
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+type readRequest struct {
+	filename string
+	mode     string
+	options  map[string]string
+}
+
+func parseRequest(packet []byte) (readRequest, error) {
+	fields := bytes.Split(packet[2:], []byte{0})
+	// The request ends with a NUL, hence the trailing empty field
+	if len(fields) < 3 || len(fields[len(fields)-1]) != 0 || len(fields)%2 != 1 {
+		return readRequest{}, fmt.Errorf("malformed request")
+	}
+
+	req := readRequest{
+		filename: string(fields[0]),
+		mode:     strings.ToLower(string(fields[1])),
+		options:  make(map[string]string),
+	}
+	for i := 2; i+1 < len(fields); i += 2 {
+		req.options[strings.ToLower(string(fields[i]))] = string(fields[i+1])
+	}
+
+	return req, nil
+}
+
+func (s *Server) handle(ctx context.Context, nic string, peer net.Addr, packet []byte) {
+	if len(packet) < 2 {
+		return
+	}
+
+	conn, err := listen(ctx, nic, ":0")
+	if err != nil {
+		logrus.Errorf("(tftp.handle) cannot open transfer socket for %s: %v", peer, err)
+		return
+	}
+	defer conn.Close()
+
+	switch opcode := binary.BigEndian.Uint16(packet); opcode {
+	case opRRQ:
+	case opWRQ:
+		s.observe(ResultRejected, 0)
+		sendError(conn, peer, errAccessViolation, "server is read-only")
+		return
+	default:
+		sendError(conn, peer, errIllegalOperation, fmt.Sprintf("unexpected opcode %d", opcode))
+		return
+	}
+
+	req, err := parseRequest(packet)
+	if err != nil {
+		s.observe(ResultRejected, 0)
+		sendError(conn, peer, errIllegalOperation, err.Error())
+		return
+	}
+	// netascii files are sent as is, since the boot assets are binaries anyway
+	if req.mode != "octet" && req.mode != "netascii" {
+		s.observe(ResultRejected, 0)
+		sendError(conn, peer, errIllegalOperation, fmt.Sprintf("unsupported mode %s", req.mode))
+		return
+	}
+
+	file, size, err := s.open(req.filename)
+	if err != nil {
+		logrus.Warnf("(tftp.handle) %s requested %s: %v", peer, req.filename, err)
+		if errors.Is(err, fs.ErrNotExist) {
+			s.observe(ResultNotFound, 0)
+			sendError(conn, peer, errFileNotFound, "file not found")
+		} else {
+			s.observe(ResultRejected, 0)
+			sendError(conn, peer, errAccessViolation, "access violation")
+		}
+		return
+	}
+	defer file.Close()
+
+	t := &transfer{
+		conn:      conn,
+		peer:      peer,
+		blockSize: defaultBlockSize,
+		timeout:   defaultTimeout,
+	}
+	oack := t.negotiate(req.options, size)
+
+	sent, err := t.send(file, oack)
+	if err != nil {
+		logrus.Warnf("(tftp.handle) transfer of %s to %s failed after %d bytes: %v", req.filename, peer, sent, err)
+		s.observe(ResultFailed, sent)
+		return
+	}
+
+	logrus.Infof("(tftp.handle) sent %s (%d bytes) to %s", req.filename, sent, peer)
+	s.observe(ResultSuccess, sent)
+}
+
+// open opens the file within the root directory. Any attempt to escape from
+// the root, e.g. by "../" or absolute symlinks, fails.
+func (s *Server) open(filename string) (*os.File, int64, error) {
+	root, err := os.OpenRoot(s.root)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer root.Close()
+
+	// Clients may send Windows-style or absolute paths
+	filename = strings.TrimLeft(strings.ReplaceAll(filename, "\\", "/"), "/")
+
+	file, err := root.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, 0, fs.ErrNotExist
+	}
+
+	return file, info.Size(), nil
+}
+
+func (s *Server) observe(result string, sent int64) {
+	if s.metricsAllocator == nil {
+		return
+	}
+	s.metricsAllocator.ObserveTFTPTransfer(s.ipPool, result, sent)
+}
+
+type transfer struct {
+	conn      net.PacketConn
+	peer      net.Addr
+	blockSize int
+	timeout   time.Duration
+}
+
+// negotiate applies the options the server supports and returns the OACK
+// packet acknowledging them, or nil if there is none.
+func (t *transfer) negotiate(options map[string]string, size int64) []byte {
+	var oack []byte
+
+	if v, ok := options["blksize"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= minBlockSize {
+			t.blockSize = min(n, maxBlockSize)
+			oack = appendOption(oack, "blksize", strconv.Itoa(t.blockSize))
+		}
+	}
+
+	if v, ok := options["timeout"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 && time.Duration(n)*time.Second <= maxTimeout {
+			t.timeout = time.Duration(n) * time.Second
+			oack = appendOption(oack, "timeout", v)
+		}
+	}
+
+	if _, ok := options["tsize"]; ok {
+		oack = appendOption(oack, "tsize", strconv.FormatInt(size, 10))
+	}
+
+	if oack == nil {
+		return nil
+	}
+
+	return append(binary.BigEndian.AppendUint16(nil, opOACK), oack...)
+}
+
+func appendOption(b []byte, name, value string) []byte {
+	b = append(b, name...)
+	b = append(b, 0)
+	b = append(b, value...)
+	return append(b, 0)
+}
+
+// send transfers the file in lock-step, i.e. the next block is sent once
+// the previous one is acknowledged. The block number wraps around after
+// 65535 blocks.
+func (t *transfer) send(file io.Reader, oack []byte) (int64, error) {
+	if oack != nil {
+		if err := t.sendAndWait(oack, 0); err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		sent  int64
+		block uint16
+		data  = make([]byte, t.blockSize)
+	)
+	for {
+		n, err := io.ReadFull(file, data)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			sendError(t.conn, t.peer, errNotDefined, "read error")
+			return sent, err
+		}
+
+		block++
+		packet := binary.BigEndian.AppendUint16(nil, opDATA)
+		packet = binary.BigEndian.AppendUint16(packet, block)
+		packet = append(packet, data[:n]...)
+
+		if err := t.sendAndWait(packet, block); err != nil {
+			return sent, err
+		}
+		sent += int64(n)
+
+		// A short block terminates the transfer
+		if n < t.blockSize {
+			return sent, nil
+		}
+	}
+}
+
+// sendAndWait sends the packet until the peer acknowledges the given block.
+// Duplicate acknowledgements of earlier blocks are ignored rather than
+// answered so as not to fall into the Sorcerer's Apprentice syndrome.
+func (t *transfer) sendAndWait(packet []byte, block uint16) error {
+	buf := make([]byte, 1500)
+
+	for retry := 0; retry < maxRetries; retry++ {
+		if _, err := t.conn.WriteTo(packet, t.peer); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(t.timeout)
+		for {
+			if err := t.conn.SetReadDeadline(deadline); err != nil {
+				return err
+			}
+			n, peer, err := t.conn.ReadFrom(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return err
+			}
+			if peer.String() != t.peer.String() {
+				sendError(t.conn, peer, errUnknownTransferID, "unknown transfer id")
+				continue
+			}
+			if n < 4 {
+				continue
+			}
+
+			switch binary.BigEndian.Uint16(buf) {
+			case opACK:
+				if binary.BigEndian.Uint16(buf[2:]) == block {
+					return nil
+				}
+			case opERROR:
+				return fmt.Errorf("aborted by peer: %s", strings.TrimRight(string(buf[4:n]), "\x00"))
+			}
+		}
+	}
+
+	return fmt.Errorf("timed out waiting for ack of block %d", block)
+}
+
+func sendError(conn net.PacketConn, peer net.Addr, code uint16, message string) {
+	packet := binary.BigEndian.AppendUint16(nil, opERROR)
+	packet = binary.BigEndian.AppendUint16(packet, code)
+	packet = append(packet, message...)
+	packet = append(packet, 0)
+
+	if _, err := conn.WriteTo(packet, peer); err != nil {
+		logrus.Errorf("(tftp.sendError) cannot send error to %s: %v", peer, err)
+	}
+}
+
+func Cleanup(ctx context.Context, s *Server) <-chan error {
+	errCh := make(chan error)
+
+	go func() {
+		<-ctx.Done()
+		defer close(errCh)
+
+		errCh <- s.stop()
+	}()
+
+	return errCh
+}
@@ -0,0 +1,206 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T, root string) *Server {
+	t.Helper()
+
+	s := NewServer(root, "default/test", nil)
+	s.addr = "127.0.0.1:0"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.Run(ctx, ""); err != nil {
+		t.Fatalf("cannot run server: %v", err)
+	}
+	errCh := Cleanup(ctx, s)
+	t.Cleanup(func() {
+		cancel()
+		<-errCh
+	})
+
+	return s
+}
+
+func newRequest(filename string, options ...string) []byte {
+	packet := binary.BigEndian.AppendUint16(nil, opRRQ)
+	for _, field := range append([]string{filename, "octet"}, options...) {
+		packet = append(packet, field...)
+		packet = append(packet, 0)
+	}
+	return packet
+}
+
+type response struct {
+	data    []byte
+	options string
+	errCode uint16
+}
+
+// get downloads the file the way a PXE ROM does and returns either the
+// content or the error code sent by the server.
+func get(t *testing.T, s *Server, request []byte) response {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteTo(request, s.conn.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		resp response
+		buf  = make([]byte, 65536)
+		last = -1
+	)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ack []byte
+		switch binary.BigEndian.Uint16(buf) {
+		case opERROR:
+			resp.errCode = binary.BigEndian.Uint16(buf[2:])
+			return resp
+		case opOACK:
+			resp.options = strings.ReplaceAll(string(buf[2:n]), "\x00", " ")
+			ack = binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, opACK), 0)
+		case opDATA:
+			block := binary.BigEndian.Uint16(buf[2:])
+			resp.data = append(resp.data, buf[4:n]...)
+			ack = binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, opACK), block)
+			if last < 0 {
+				last = n - 4
+			}
+			if n-4 < last {
+				_, _ = conn.WriteTo(ack, peer)
+				return resp
+			}
+		default:
+			t.Fatalf("unexpected opcode %d", binary.BigEndian.Uint16(buf))
+		}
+
+		if _, err := conn.WriteTo(ack, peer); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	content := bytes.Repeat([]byte("0123456789"), 300)
+	if err := os.MkdirAll(filepath.Join(root, "efi"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "efi", "ipxe.efi"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(root), "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := startTestServer(t, root)
+
+	tests := []struct {
+		name        string
+		request     []byte
+		wantData    []byte
+		wantOptions string
+		wantErr     uint16
+	}{
+		{
+			name:     "default block size",
+			request:  newRequest("efi/ipxe.efi"),
+			wantData: content,
+		},
+		{
+			name:        "negotiated block size and transfer size",
+			request:     newRequest("/efi/ipxe.efi", "blksize", "1428", "tsize", "0"),
+			wantData:    content,
+			wantOptions: "blksize 1428 tsize 3000 ",
+		},
+		{
+			name:     "windows-style path",
+			request:  newRequest("\\efi\\ipxe.efi"),
+			wantData: content,
+		},
+		{
+			name:    "file not found",
+			request: newRequest("undionly.kpxe"),
+			wantErr: errFileNotFound,
+		},
+		{
+			name:    "path traversal",
+			request: newRequest("../secret"),
+			wantErr: errAccessViolation,
+		},
+		{
+			name:    "directory",
+			request: newRequest("efi"),
+			wantErr: errFileNotFound,
+		},
+		{
+			name:    "write request",
+			request: append(binary.BigEndian.AppendUint16(nil, opWRQ), "efi/ipxe.efi\x00octet\x00"...),
+			wantErr: errAccessViolation,
+		},
+	}
+
+	for _, tc := range tests {
+		resp := get(t, s, tc.request)
+		if resp.errCode != tc.wantErr {
+			t.Errorf("%s: got error code %d, wanted %d", tc.name, resp.errCode, tc.wantErr)
+			continue
+		}
+		if !bytes.Equal(resp.data, tc.wantData) {
+			t.Errorf("%s: got %d bytes, wanted %d bytes", tc.name, len(resp.data), len(tc.wantData))
+		}
+		if resp.options != tc.wantOptions {
+			t.Errorf("%s: got options %q, wanted %q", tc.name, resp.options, tc.wantOptions)
+		}
+	}
+}
+
+func TestServerTooManyTransfers(t *testing.T) {
+	root := t.TempDir()
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	if err := os.WriteFile(filepath.Join(root, "ipxe.efi"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := startTestServer(t, root)
+
+	// Take up all the transfer slots
+	for i := 0; i < cap(s.transfers); i++ {
+		s.transfers <- struct{}{}
+	}
+
+	if resp := get(t, s, newRequest("ipxe.efi")); resp.errCode != errNotDefined || resp.data != nil {
+		t.Errorf("got error code %d and %d bytes, wanted error code %d", resp.errCode, len(resp.data), errNotDefined)
+	}
+
+	// Free one slot again
+	<-s.transfers
+
+	if resp := get(t, s, newRequest("ipxe.efi")); !bytes.Equal(resp.data, content) {
+		t.Errorf("got %d bytes, wanted %d bytes", len(resp.data), len(content))
+	}
+}
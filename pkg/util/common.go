@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"strings"
+	"time"
 
 	"github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io"
 )
@@ -11,6 +12,14 @@ import (
 const (
 	ExcludedMark = "EXCLUDED"
 	ReservedMark = "RESERVED"
+	// Leased out by the agent from the dynamic range
+	DynamicMark = "DYNAMIC"
+	// Declined by a client or found in use by another host, kept away from
+	// the VMs until the quarantine expires
+	QuarantinedMark = "QUARANTINED"
+
+	// How long a quarantined IP address is kept away from the VMs
+	QuarantineDuration = time.Hour
 
 	AgentSuffixName         = "agent"
 	NodeArgsAnnotationKey   = "rke2.io/node-args"
@@ -0,0 +1,175 @@
+package util
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
+)
+
+// reservedOptionCodes are the DHCP options that drive the protocol itself
+// and hence cannot be set as custom options.
+var reservedOptionCodes = map[int]string{
+	0:   "pad",
+	50:  "requested ip address",
+	51:  "ip address lease time",
+	52:  "option overload",
+	53:  "dhcp message type",
+	54:  "server identifier",
+	55:  "parameter request list",
+	57:  "maximum dhcp message size",
+	58:  "renewal time value",
+	59:  "rebinding time value",
+	61:  "client identifier",
+	82:  "relay agent information",
+	255: "end",
+}
+
+// EncodeCustomOption returns the wire format of the value of the custom DHCP
+// option, e.g. "10.0.0.1,10.0.0.2" of type ip-list becomes 8 bytes.
+func EncodeCustomOption(option networkv1.CustomOption) ([]byte, error) {
+	if option.Code < 1 || option.Code > 254 {
+		return nil, fmt.Errorf("option code %d is out of range", option.Code)
+	}
+	if name, ok := reservedOptionCodes[option.Code]; ok {
+		return nil, fmt.Errorf("option code %d (%s) is reserved", option.Code, name)
+	}
+
+	var value []byte
+
+	switch option.Type {
+	case networkv1.CustomOptionTypeIP:
+		ip := net.ParseIP(option.Value).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("option %d value %q is not an ipv4 address", option.Code, option.Value)
+		}
+		value = ip
+	case networkv1.CustomOptionTypeIPList:
+		for _, s := range strings.Split(option.Value, ",") {
+			ip := net.ParseIP(strings.TrimSpace(s)).To4()
+			if ip == nil {
+				return nil, fmt.Errorf("option %d value %q is not a list of ipv4 addresses", option.Code, option.Value)
+			}
+			value = append(value, ip...)
+		}
+	case networkv1.CustomOptionTypeString:
+		value = []byte(option.Value)
+	case networkv1.CustomOptionTypeUint8:
+		n, err := strconv.ParseUint(option.Value, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("option %d value %q is not an uint8", option.Code, option.Value)
+		}
+		value = []byte{uint8(n)}
+	case networkv1.CustomOptionTypeUint16:
+		n, err := strconv.ParseUint(option.Value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("option %d value %q is not an uint16", option.Code, option.Value)
+		}
+		value = binary.BigEndian.AppendUint16(nil, uint16(n))
+	case networkv1.CustomOptionTypeUint32:
+		n, err := strconv.ParseUint(option.Value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("option %d value %q is not an uint32", option.Code, option.Value)
+		}
+		value = binary.BigEndian.AppendUint32(nil, uint32(n))
+	case networkv1.CustomOptionTypeHex:
+		b, err := hex.DecodeString(strings.ReplaceAll(option.Value, ":", ""))
+		if err != nil {
+			return nil, fmt.Errorf("option %d value %q is not a hex string", option.Code, option.Value)
+		}
+		value = b
+	default:
+		return nil, fmt.Errorf("option %d type %q is not supported", option.Code, option.Type)
+	}
+
+	if len(value) == 0 || len(value) > 255 {
+		return nil, fmt.Errorf("option %d value length %d is out of range", option.Code, len(value))
+	}
+
+	return value, nil
+}
+
+// CheckCustomOptions checks whether the custom DHCP options are well-formed
+// and each option code appears at most once.
+func CheckCustomOptions(options []networkv1.CustomOption) error {
+	codes := make(map[int]struct{}, len(options))
+	for _, option := range options {
+		if _, ok := codes[option.Code]; ok {
+			return fmt.Errorf("option code %d is duplicated", option.Code)
+		}
+		codes[option.Code] = struct{}{}
+
+		if _, err := EncodeCustomOption(option); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MergeCustomOptions returns the custom options of the IPPool overridden by
+// the ones of the NIC with the same code.
+func MergeCustomOptions(poolOptions, nicOptions []networkv1.CustomOption) []networkv1.CustomOption {
+	if len(nicOptions) == 0 {
+		return poolOptions
+	}
+
+	overridden := make(map[int]struct{}, len(nicOptions))
+	for _, option := range nicOptions {
+		overridden[option.Code] = struct{}{}
+	}
+
+	options := make([]networkv1.CustomOption, 0, len(poolOptions)+len(nicOptions))
+	for _, option := range poolOptions {
+		if _, ok := overridden[option.Code]; !ok {
+			options = append(options, option)
+		}
+	}
+
+	return append(options, nicOptions...)
+}
+
+// DefaultLeaseTime is the lease time in seconds unless configured: 1 year
+const DefaultLeaseTime = 31536000
+
+// LeaseTimes returns the lease, renewal (T1) and rebinding (T2) times in
+// seconds of the IPv4 config. Unless configured, T1 and T2 default to 50% and
+// 87.5% of the lease time as suggested by RFC 2131 section 4.4.5.
+func LeaseTimes(ipv4Config networkv1.IPv4Config) (leaseTime, renewalTime, rebindingTime int) {
+	leaseTime = DefaultLeaseTime
+	if ipv4Config.LeaseTime != nil && *ipv4Config.LeaseTime > 0 {
+		leaseTime = *ipv4Config.LeaseTime
+	}
+
+	renewalTime = leaseTime / 2
+	if ipv4Config.RenewalTime != nil {
+		renewalTime = *ipv4Config.RenewalTime
+	}
+
+	rebindingTime = leaseTime * 7 / 8
+	if ipv4Config.RebindingTime != nil {
+		rebindingTime = *ipv4Config.RebindingTime
+	}
+
+	return
+}
+
+// DefaultDynamicLeaseTime is the lease time in seconds of the leases handed
+// out from the dynamic range unless configured: 1 hour
+const DefaultDynamicLeaseTime = 3600
+
+// DynamicLeaseTime returns the lease time of the dynamic range.
+func DynamicLeaseTime(dynamicRange *networkv1.DynamicRange) int {
+	if dynamicRange == nil || dynamicRange.LeaseTime == nil {
+		return DefaultDynamicLeaseTime
+	}
+	return *dynamicRange.LeaseTime
+}
+
+// IsInfiniteLease reports whether the leases of the IPv4 config never expire.
+func IsInfiniteLease(ipv4Config networkv1.IPv4Config) bool {
+	return ipv4Config.InfiniteLease != nil && *ipv4Config.InfiniteLease
+}
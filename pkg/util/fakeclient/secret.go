@@ -0,0 +1,44 @@
+package fakeclient
+
+import (
+	"context"
+
+	"github.com/rancher/wrangler/v3/pkg/generic"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	typecorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+type SecretClient func(string) typecorev1.SecretInterface
+
+func (c SecretClient) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	return c(secret.Namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+}
+func (c SecretClient) Get(namespace, name string, options metav1.GetOptions) (*corev1.Secret, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c SecretClient) Create(secret *corev1.Secret) (*corev1.Secret, error) {
+	return c(secret.Namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+}
+func (c SecretClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	return c(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+func (c SecretClient) List(namespace string, opts metav1.ListOptions) (*corev1.SecretList, error) {
+	panic("implement me")
+}
+func (c SecretClient) UpdateStatus(secret *corev1.Secret) (*corev1.Secret, error) {
+	panic("implement me")
+}
+func (c SecretClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	panic("implement me")
+}
+func (c SecretClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *corev1.Secret, err error) {
+	panic("implement me")
+}
+
+func (c SecretClient) WithImpersonation(config rest.ImpersonationConfig) (generic.ClientInterface[*corev1.Secret, *corev1.SecretList], error) {
+	panic("implement me")
+}
@@ -12,6 +12,7 @@ import (
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	typenetworkv1 "github.com/harvester/vm-dhcp-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1alpha1"
+	"github.com/harvester/vm-dhcp-controller/pkg/indexer"
 )
 
 type VirtualMachineNetworkConfigClient func(string) typenetworkv1.VirtualMachineNetworkConfigInterface
@@ -66,5 +67,22 @@ func (c VirtualMachineNetworkConfigCache) AddIndexer(indexName string, indexer g
 	panic("implement me")
 }
 func (c VirtualMachineNetworkConfigCache) GetByIndex(indexName, key string) ([]*networkv1.VirtualMachineNetworkConfig, error) {
-	panic("implement me")
+	if indexName != indexer.VmNetCfgByNetworkIndex {
+		panic("implement me")
+	}
+	vmNetCfgs, err := c.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var result []*networkv1.VirtualMachineNetworkConfig
+	for _, vmNetCfg := range vmNetCfgs {
+		networkNames, _ := indexer.VmNetCfgByNetwork(vmNetCfg)
+		for _, networkName := range networkNames {
+			if networkName == key {
+				result = append(result, vmNetCfg)
+				break
+			}
+		}
+	}
+	return result, nil
 }
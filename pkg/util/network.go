@@ -1,10 +1,12 @@
 package util
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/netip"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -55,6 +57,16 @@ func GetServiceCIDRFromNode(node *corev1.Node) (string, error) {
 	return argList[serviceCIDRIndex], nil
 }
 
+// FQDN returns the fully qualified domain name of the host in the domain, or
+// only the host name if there is no domain. It is both handed out to the
+// clients in option 81 and registered in DNS.
+func FQDN(hostname, domainName string) string {
+	if hostname == "" || domainName == "" {
+		return hostname
+	}
+	return hostname + "." + strings.TrimSuffix(domainName, ".")
+}
+
 func LoadCIDR(cidr string) (ipNet *net.IPNet, networkIPAddr netip.Addr, broadcastIPAddr netip.Addr, err error) {
 	_, ipNet, err = net.ParseCIDR(cidr)
 	if err != nil {
@@ -164,3 +176,21 @@ func IsIPInBetweenOf(ip, ip1, ip2 string) bool {
 
 	return ipAddr.Compare(ip1Addr) >= 0 && ipAddr.Compare(ip2Addr) <= 0
 }
+
+// IsMACPrefix reports whether s is the first one to six bytes of a MAC
+// address in colon-separated hex, e.g. an OUI like "52:54:00".
+func IsMACPrefix(s string) bool {
+	octets := strings.Split(s, ":")
+	if len(octets) > 6 {
+		return false
+	}
+	for _, octet := range octets {
+		if len(octet) != 2 {
+			return false
+		}
+		if _, err := hex.DecodeString(octet); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -2,7 +2,10 @@ package ippool
 
 import (
 	"fmt"
+	"net"
 	"net/netip"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/harvester/webhook/pkg/server/admission"
@@ -10,6 +13,7 @@ import (
 	"github.com/sirupsen/logrus"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	ctlcniv1 "github.com/harvester/vm-dhcp-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
@@ -69,6 +73,42 @@ func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
 		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
 	}
 
+	if err := v.checkRoutes(poolInfo, ipPool.Spec.IPv4Config.Routes); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := util.CheckCustomOptions(ipPool.Spec.IPv4Config.CustomOptions); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkBootConfig(ipPool.Spec.IPv4Config.Boot); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkRelayConfig(ipPool.Spec.IPv4Config.Relay); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkLeaseTimes(ipPool.Spec.IPv4Config); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkDynamicRange(poolInfo, ipPool.Spec.IPv4Config.DynamicRange); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkClientClasses(ipPool.Spec.IPv4Config.Classes); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkDDNS(ipPool.Spec.IPv4Config.DDNS, ipPool.Spec.IPv4Config.DomainName); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkIPv6Config(ipPool.Spec.IPv6Config); err != nil {
+		return fmt.Errorf(webhook.CreateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
 	return nil
 }
 
@@ -115,6 +155,42 @@ func (v *Validator) Update(_ *admission.Request, _, newObj runtime.Object) error
 		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
 	}
 
+	if err := v.checkRoutes(poolInfo, ipPool.Spec.IPv4Config.Routes); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := util.CheckCustomOptions(ipPool.Spec.IPv4Config.CustomOptions); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkBootConfig(ipPool.Spec.IPv4Config.Boot); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkRelayConfig(ipPool.Spec.IPv4Config.Relay); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkLeaseTimes(ipPool.Spec.IPv4Config); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkDynamicRange(poolInfo, ipPool.Spec.IPv4Config.DynamicRange); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkClientClasses(ipPool.Spec.IPv4Config.Classes); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkDDNS(ipPool.Spec.IPv4Config.DDNS, ipPool.Spec.IPv4Config.DomainName); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
+	if err := v.checkIPv6Config(ipPool.Spec.IPv6Config); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, "IPPool", ipPool.Namespace, ipPool.Name, err)
+	}
+
 	return nil
 }
 
@@ -264,6 +340,342 @@ func (v *Validator) checkRouter(pi util.PoolInfo) error {
 	return nil
 }
 
+// checkRoutes checks whether each classless static route:
+//   - has an IPv4 network address as its destination
+//   - has a gateway WITHIN the CIDR, as it must be directly reachable
+//   - does NOT have the network or broadcast IP address as its gateway
+//   - does NOT have the same destination as another route
+func (v *Validator) checkRoutes(pi util.PoolInfo, routes []networkv1.Route) error {
+	destinations := make(map[netip.Prefix]struct{}, len(routes))
+
+	for _, route := range routes {
+		prefix, err := netip.ParsePrefix(route.Destination)
+		if err != nil {
+			return err
+		}
+		if !prefix.Addr().Is4() {
+			return fmt.Errorf("route destination %s is not an ipv4 cidr", route.Destination)
+		}
+		if prefix.Masked() != prefix {
+			return fmt.Errorf("route destination %s is not a network address", route.Destination)
+		}
+		if _, ok := destinations[prefix]; ok {
+			return fmt.Errorf("route destination %s is duplicated", route.Destination)
+		}
+		destinations[prefix] = struct{}{}
+
+		gatewayIPAddr, err := netip.ParseAddr(route.Gateway)
+		if err != nil {
+			return err
+		}
+		if !gatewayIPAddr.Is4() {
+			return fmt.Errorf("route gateway %s is not an ipv4 address", gatewayIPAddr)
+		}
+		if !pi.IPNet.Contains(gatewayIPAddr.AsSlice()) {
+			return fmt.Errorf("route gateway %s is not within subnet", gatewayIPAddr)
+		}
+		if gatewayIPAddr == pi.NetworkIPAddr {
+			return fmt.Errorf("route gateway %s is the same as network ip", gatewayIPAddr)
+		}
+		if gatewayIPAddr == pi.BroadcastIPAddr {
+			return fmt.Errorf("route gateway %s is the same as broadcast ip", gatewayIPAddr)
+		}
+	}
+
+	return nil
+}
+
+// checkBootConfig checks whether the boot config:
+//   - has a boot file if there is a next server to load it from
+//   - does NOT have two boot files for the same client architecture
+//   - has an http, https or tftp URL as the iPXE script
+func (v *Validator) checkBootConfig(bootConfig *networkv1.BootConfig) error {
+	if bootConfig == nil {
+		return nil
+	}
+
+	if bootConfig.NextServer != "" && bootConfig.Filename == "" && len(bootConfig.Architectures) == 0 {
+		return fmt.Errorf("next server %s is set without any boot file", bootConfig.NextServer)
+	}
+
+	archs := make(map[int]struct{}, len(bootConfig.Architectures))
+	for _, arch := range bootConfig.Architectures {
+		if _, ok := archs[arch.Arch]; ok {
+			return fmt.Errorf("boot file of client architecture %d is duplicated", arch.Arch)
+		}
+		archs[arch.Arch] = struct{}{}
+	}
+
+	if bootConfig.IPXEScriptURL != "" {
+		u, err := url.Parse(bootConfig.IPXEScriptURL)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "tftp" {
+			return fmt.Errorf("ipxe script url %s is not an http, https or tftp url", bootConfig.IPXEScriptURL)
+		}
+		if len(bootConfig.IPXEScriptURL) > 255 {
+			return fmt.Errorf("ipxe script url %s is longer than 255 characters", bootConfig.IPXEScriptURL)
+		}
+	}
+
+	if tftp := bootConfig.TFTP; tftp != nil {
+		if (tftp.ConfigMapName == "") == (tftp.PersistentVolumeClaimName == "") {
+			return fmt.Errorf("tftp root must be either a configmap or a persistentvolumeclaim")
+		}
+	}
+
+	return nil
+}
+
+// checkRelayConfig checks whether the relay agent circuit and remote IDs are
+// neither empty nor duplicated, and fit into an option 82 sub-option.
+func (v *Validator) checkRelayConfig(relayConfig *networkv1.RelayConfig) error {
+	if relayConfig == nil {
+		return nil
+	}
+
+	for _, relayIDs := range []struct {
+		kind string
+		ids  []string
+	}{
+		{"circuit", relayConfig.CircuitIDs},
+		{"remote", relayConfig.RemoteIDs},
+	} {
+		kind := relayIDs.kind
+		seen := make(map[string]struct{}, len(relayIDs.ids))
+		for _, id := range relayIDs.ids {
+			if id == "" || len(id) > 255 {
+				return fmt.Errorf("relay agent %s id %q is empty or longer than 255 characters", kind, id)
+			}
+			if _, ok := seen[id]; ok {
+				return fmt.Errorf("relay agent %s id %s is duplicated", kind, id)
+			}
+			seen[id] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// checkLeaseTimes checks whether the renewal (T1) and rebinding (T2) times
+// fit into the lease time, i.e. T1 < T2 < lease time, with the defaults of the
+// ones not set. An infinite lease does not expire, so it takes none of them.
+func (v *Validator) checkLeaseTimes(ipv4Config networkv1.IPv4Config) error {
+	if util.IsInfiniteLease(ipv4Config) {
+		if ipv4Config.LeaseTime != nil || ipv4Config.RenewalTime != nil || ipv4Config.RebindingTime != nil {
+			return fmt.Errorf("lease, renewal and rebinding times cannot be set for an infinite lease")
+		}
+		return nil
+	}
+
+	if ipv4Config.RenewalTime == nil && ipv4Config.RebindingTime == nil {
+		return nil
+	}
+
+	leaseTime, renewalTime, rebindingTime := util.LeaseTimes(ipv4Config)
+	if renewalTime <= 0 {
+		return fmt.Errorf("renewal time %d is not positive", renewalTime)
+	}
+	if renewalTime >= rebindingTime {
+		return fmt.Errorf("renewal time %d is not less than rebinding time %d", renewalTime, rebindingTime)
+	}
+	if rebindingTime >= leaseTime {
+		return fmt.Errorf("rebinding time %d is not less than lease time %d", rebindingTime, leaseTime)
+	}
+
+	return nil
+}
+
+// checkDynamicRange checks whether the dynamic range is a valid range of
+// IPv4 addresses WITHIN the subnet but OUTSIDE of the pool range, so that the
+// IPAM never allocates the addresses handed out from it to the VMs, and that
+// it does NOT contain the server or the router IP address.
+func (v *Validator) checkDynamicRange(pi util.PoolInfo, dynamicRange *networkv1.DynamicRange) error {
+	if dynamicRange == nil {
+		return nil
+	}
+
+	startIPAddr, err := netip.ParseAddr(dynamicRange.Start)
+	if err != nil {
+		return err
+	}
+	endIPAddr, err := netip.ParseAddr(dynamicRange.End)
+	if err != nil {
+		return err
+	}
+
+	if startIPAddr.Compare(endIPAddr) > 0 {
+		return fmt.Errorf("dynamic range end ip %s is less than start ip %s", endIPAddr, startIPAddr)
+	}
+
+	if !pi.IPNet.Contains(startIPAddr.AsSlice()) || startIPAddr.Compare(pi.NetworkIPAddr) == 0 {
+		return fmt.Errorf("dynamic range start ip %s is not within subnet", startIPAddr)
+	}
+	if !pi.IPNet.Contains(endIPAddr.AsSlice()) || endIPAddr.Compare(pi.BroadcastIPAddr) == 0 {
+		return fmt.Errorf("dynamic range end ip %s is not within subnet", endIPAddr)
+	}
+
+	poolStartIPAddr, poolEndIPAddr := pi.StartIPAddr, pi.EndIPAddr
+	if !poolStartIPAddr.IsValid() {
+		poolStartIPAddr = pi.NetworkIPAddr.Next()
+	}
+	if !poolEndIPAddr.IsValid() {
+		poolEndIPAddr = pi.BroadcastIPAddr.Prev()
+	}
+
+	if startIPAddr.Compare(poolEndIPAddr) <= 0 && endIPAddr.Compare(poolStartIPAddr) >= 0 {
+		return fmt.Errorf("dynamic range %s-%s overlaps with pool range %s-%s", startIPAddr, endIPAddr, poolStartIPAddr, poolEndIPAddr)
+	}
+
+	for _, ipAddr := range []netip.Addr{pi.ServerIPAddr, pi.RouterIPAddr} {
+		if ipAddr.IsValid() && startIPAddr.Compare(ipAddr) <= 0 && endIPAddr.Compare(ipAddr) >= 0 {
+			return fmt.Errorf("dynamic range %s-%s contains ip %s of the server or the router", startIPAddr, endIPAddr, ipAddr)
+		}
+	}
+
+	return nil
+}
+
+// checkClientClasses checks whether the client class names are unique, and
+// each class matches on at least one vendor class, user class or MAC prefix
+// and carries well-formed custom options.
+func (v *Validator) checkClientClasses(classes []networkv1.ClientClass) error {
+	names := make(map[string]struct{}, len(classes))
+	for _, class := range classes {
+		if _, ok := names[class.Name]; ok {
+			return fmt.Errorf("class %s is duplicated", class.Name)
+		}
+		names[class.Name] = struct{}{}
+
+		if len(class.VendorClasses) == 0 && len(class.UserClasses) == 0 && len(class.MACPrefixes) == 0 {
+			return fmt.Errorf("class %s matches on neither vendor class, user class nor mac prefix", class.Name)
+		}
+
+		for _, vendorClass := range class.VendorClasses {
+			if vendorClass == "" || len(vendorClass) > 255 {
+				return fmt.Errorf("vendor class %q of class %s is not 1 to 255 characters long", vendorClass, class.Name)
+			}
+		}
+
+		for _, userClass := range class.UserClasses {
+			if userClass == "" || len(userClass) > 255 {
+				return fmt.Errorf("user class %q of class %s is not 1 to 255 characters long", userClass, class.Name)
+			}
+		}
+
+		for _, macPrefix := range class.MACPrefixes {
+			if !util.IsMACPrefix(macPrefix) {
+				return fmt.Errorf("mac prefix %s of class %s is not valid", macPrefix, class.Name)
+			}
+		}
+
+		if err := util.CheckCustomOptions(class.CustomOptions); err != nil {
+			return fmt.Errorf("class %s: %w", class.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkDDNS checks whether the DNS server address is a host with an optional
+// port, the zones and the TSIG key name are valid domain names, and the domain
+// name of the IPPool is within the zone, so that the FQDNs handed out to the
+// clients are the names registered.
+func (v *Validator) checkDDNS(ddnsConfig *networkv1.DDNSConfig, domainName *string) error {
+	if ddnsConfig == nil {
+		return nil
+	}
+
+	host := ddnsConfig.Server
+	if h, port, err := net.SplitHostPort(ddnsConfig.Server); err == nil {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("ddns server %s has an invalid port", ddnsConfig.Server)
+		}
+		host = h
+	}
+	if host == "" {
+		return fmt.Errorf("ddns server %q has no host", ddnsConfig.Server)
+	}
+
+	if !isDomainName(ddnsConfig.Zone) {
+		return fmt.Errorf("ddns zone %s is not a valid domain name", ddnsConfig.Zone)
+	}
+
+	if domainName == nil || *domainName == "" {
+		return fmt.Errorf("ddns requires a domain name")
+	}
+	zone := strings.TrimSuffix(strings.ToLower(ddnsConfig.Zone), ".")
+	domain := strings.TrimSuffix(strings.ToLower(*domainName), ".")
+	if domain != zone && !strings.HasSuffix(domain, "."+zone) {
+		return fmt.Errorf("domain name %s is not within ddns zone %s", *domainName, ddnsConfig.Zone)
+	}
+
+	if ddnsConfig.ReverseZone != "" {
+		if !isDomainName(ddnsConfig.ReverseZone) {
+			return fmt.Errorf("ddns reverse zone %s is not a valid domain name", ddnsConfig.ReverseZone)
+		}
+		if !strings.HasSuffix(strings.TrimSuffix(strings.ToLower(ddnsConfig.ReverseZone), "."), ".in-addr.arpa") {
+			return fmt.Errorf("ddns reverse zone %s is not within in-addr.arpa", ddnsConfig.ReverseZone)
+		}
+	}
+
+	if ddnsConfig.TSIG != nil && !isDomainName(ddnsConfig.TSIG.KeyName) {
+		return fmt.Errorf("tsig key name %s is not a valid domain name", ddnsConfig.TSIG.KeyName)
+	}
+
+	return nil
+}
+
+func isDomainName(name string) bool {
+	return len(validation.IsDNS1123Subdomain(strings.TrimSuffix(strings.ToLower(name), "."))) == 0
+}
+
+// checkIPv6Config checks whether the IPv6 CIDR is an IPv6 prefix, the server
+// IP address is WITHIN it but NOT the Subnet-Router anycast address, the DNS
+// servers are IPv6 addresses, and the preferred lifetime does not exceed the
+// valid one.
+func (v *Validator) checkIPv6Config(ipv6Config *networkv1.IPv6Config) error {
+	if ipv6Config == nil {
+		return nil
+	}
+
+	prefix, err := netip.ParsePrefix(ipv6Config.CIDR)
+	if err != nil {
+		return err
+	}
+	if !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return fmt.Errorf("cidr %s is not an ipv6 prefix", ipv6Config.CIDR)
+	}
+
+	serverIPAddr, err := netip.ParseAddr(ipv6Config.ServerIP)
+	if err != nil {
+		return err
+	}
+
+	if !prefix.Contains(serverIPAddr) {
+		return fmt.Errorf("ipv6 server ip %s is not within subnet", serverIPAddr)
+	}
+
+	if serverIPAddr == prefix.Masked().Addr() {
+		return fmt.Errorf("ipv6 server ip %s is the same as network ip", serverIPAddr)
+	}
+
+	for _, dns := range ipv6Config.DNS {
+		dnsAddr, err := netip.ParseAddr(dns)
+		if err != nil || !dnsAddr.Is6() || dnsAddr.Is4In6() {
+			return fmt.Errorf("ipv6 dns server %s is not an ipv6 address", dns)
+		}
+	}
+
+	if ipv6Config.PreferredLifetime != nil && ipv6Config.ValidLifetime != nil &&
+		*ipv6Config.PreferredLifetime > *ipv6Config.ValidLifetime {
+		return fmt.Errorf("ipv6 preferred lifetime %d exceeds valid lifetime %d", *ipv6Config.PreferredLifetime, *ipv6Config.ValidLifetime)
+	}
+
+	return nil
+}
+
 func (v *Validator) checkVmNetCfgs(ipPool *networkv1.IPPool) error {
 	vmnetcfgGetter := util.VmnetcfgGetter{
 		VmnetcfgCache: v.vmnetcfgCache,
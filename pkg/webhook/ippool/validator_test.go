@@ -81,6 +81,582 @@ func TestValidator_Create(t *testing.T) {
 				err: fmt.Errorf("cannot create IPPool %s/%s because server ip %s is not within subnet", testIPPoolNamespace, testIPPoolName, testServerIPOutOfRange),
 			},
 		},
+		{
+			name: "valid ipv6 config",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					IPv6Config("fd00::/64", "fd00::2").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+		},
+		{
+			name: "invalid ipv6 server ip which is out of range",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					IPv6Config("fd00::/64", "fd01::2").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because ipv6 server ip %s is not within subnet", testIPPoolNamespace, testIPPoolName, "fd01::2"),
+			},
+		},
+		{
+			name: "invalid ipv6 cidr which is an ipv4 prefix",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					IPv6Config("192.168.1.0/24", "192.168.1.2").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because cidr %s is not an ipv6 prefix", testIPPoolNamespace, testIPPoolName, "192.168.1.0/24"),
+			},
+		},
+		{
+			name: "valid ipv6 dns servers and lifetimes",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					IPv6Config("fd00::/64", "fd00::2").
+					IPv6DNS("fd00::53", "2001:4860:4860::8888").
+					IPv6Lifetime(1800, 3600).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+		},
+		{
+			name: "invalid ipv6 dns server which is an ipv4 address",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					IPv6Config("fd00::/64", "fd00::2").
+					IPv6DNS("fd00::53", "1.1.1.1").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because ipv6 dns server %s is not an ipv6 address", testIPPoolNamespace, testIPPoolName, "1.1.1.1"),
+			},
+		},
+		{
+			name: "invalid ipv6 preferred lifetime which exceeds the valid lifetime",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					IPv6Config("fd00::/64", "fd00::2").
+					IPv6Lifetime(7200, 3600).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because ipv6 preferred lifetime %d exceeds valid lifetime %d", testIPPoolNamespace, testIPPoolName, 7200, 3600),
+			},
+		},
+		{
+			name: "valid routes",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Router("192.168.0.1").
+					Route("10.10.0.0/16", "192.168.0.254").
+					Route("172.16.0.0/12", "192.168.0.253").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+		},
+		{
+			name: "invalid route destination which is not a network address",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Route("10.10.1.0/16", "192.168.0.254").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because route destination %s is not a network address", testIPPoolNamespace, testIPPoolName, "10.10.1.0/16"),
+			},
+		},
+		{
+			name: "invalid route destination which is an ipv6 prefix",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Route("fd00::/64", "192.168.0.254").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because route destination %s is not an ipv4 cidr", testIPPoolNamespace, testIPPoolName, "fd00::/64"),
+			},
+		},
+		{
+			name: "invalid route destination which is duplicated",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Route("10.10.0.0/16", "192.168.0.254").
+					Route("10.10.0.0/16", "192.168.0.253").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because route destination %s is duplicated", testIPPoolNamespace, testIPPoolName, "10.10.0.0/16"),
+			},
+		},
+		{
+			name: "invalid route gateway which is out of subnet",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Route("10.10.0.0/16", "192.168.1.254").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because route gateway %s is not within subnet", testIPPoolNamespace, testIPPoolName, "192.168.1.254"),
+			},
+		},
+		{
+			name: "invalid route gateway which is the same as broadcast ip",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Route("10.10.0.0/16", "192.168.0.255").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because route gateway %s is the same as broadcast ip", testIPPoolNamespace, testIPPoolName, "192.168.0.255"),
+			},
+		},
+		{
+			name: "valid custom options",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					CustomOption(26, networkv1.CustomOptionTypeUint16, "9000").
+					CustomOption(42, networkv1.CustomOptionTypeIPList, "192.168.0.10, 192.168.0.11").
+					CustomOption(43, networkv1.CustomOptionTypeHex, "01:04:c0:a8:00:02").
+					CustomOption(252, networkv1.CustomOptionTypeString, "http://wpad.example.com/wpad.dat").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+		},
+		{
+			name: "invalid custom option value which is malformed",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					CustomOption(26, networkv1.CustomOptionTypeUint8, "9000").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because option 26 value %q is not an uint8", testIPPoolNamespace, testIPPoolName, "9000"),
+			},
+		},
+		{
+			name: "invalid custom option code which is reserved",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					CustomOption(53, networkv1.CustomOptionTypeUint8, "5").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because option code 53 (dhcp message type) is reserved", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "invalid custom option code which is the renewal time",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					CustomOption(58, networkv1.CustomOptionTypeUint32, "3600").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because option code 58 (renewal time value) is reserved", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "invalid custom option code which is duplicated",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					CustomOption(66, networkv1.CustomOptionTypeString, "192.168.0.5").
+					CustomOption(66, networkv1.CustomOptionTypeString, "192.168.0.6").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because option code 66 is duplicated", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "valid boot config",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Boot("192.168.0.5", "pxelinux.0").
+					BootArchitecture(0, "undionly.kpxe").
+					BootArchitecture(7, "ipxe.efi").
+					IPXEScript("http://192.168.0.5/boot.ipxe").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+		},
+		{
+			name: "invalid boot config which has next server only",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Boot("192.168.0.5", "").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because next server %s is set without any boot file", testIPPoolNamespace, testIPPoolName, "192.168.0.5"),
+			},
+		},
+		{
+			name: "invalid boot config which has duplicated architectures",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					BootArchitecture(7, "ipxe.efi").
+					BootArchitecture(7, "snponly.efi").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because boot file of client architecture %d is duplicated", testIPPoolNamespace, testIPPoolName, 7),
+			},
+		},
+		{
+			name: "invalid ipxe script url",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					IPXEScript("nfs://192.168.0.5/boot.ipxe").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because ipxe script url %s is not an http, https or tftp url", testIPPoolNamespace, testIPPoolName, "nfs://192.168.0.5/boot.ipxe"),
+			},
+		},
+		{
+			name: "boot config with tftp root",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Boot("", "undionly.kpxe").
+					TFTPConfigMap("pxe-assets").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: nil,
+			},
+		},
+		{
+			name: "relay agent ids",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					RelayCircuitID("vlan100").
+					RelayRemoteID("leaf-1").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: nil,
+			},
+		},
+		{
+			name: "invalid relay agent ids which are duplicated",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					RelayRemoteID("leaf-1").
+					RelayRemoteID("leaf-1").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because relay agent remote id leaf-1 is duplicated", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "renewal and rebinding times",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					LeaseTime(3600).
+					RenewalTime(1200).
+					RebindingTime(2400).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: nil,
+			},
+		},
+		{
+			name: "invalid renewal time which is not less than the default rebinding time",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					LeaseTime(3600).
+					RenewalTime(3200).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because renewal time 3200 is not less than rebinding time 3150", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "invalid rebinding time which is not less than lease time",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					LeaseTime(3600).
+					RebindingTime(3600).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because rebinding time 3600 is not less than lease time 3600", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "invalid infinite lease with lease time",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					LeaseTime(3600).
+					InfiniteLease().
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because lease, renewal and rebinding times cannot be set for an infinite lease", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "invalid tftp root which is both configmap and persistentvolumeclaim",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					ServerIP("192.168.0.2").
+					Boot("", "undionly.kpxe").
+					TFTPConfigMap("pxe-assets").
+					TFTPPersistentVolumeClaim("pxe-assets").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because tftp root must be either a configmap or a persistentvolumeclaim", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "invalid dynamic range whose end is less than its start",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DynamicRange("192.168.0.200", "192.168.0.100").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because dynamic range end ip %s is less than start ip %s", testIPPoolNamespace, testIPPoolName, "192.168.0.100", "192.168.0.200"),
+			},
+		},
+		{
+			name: "invalid dynamic range which overlaps with pool range",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					PoolRange("192.168.0.10", "192.168.0.100").
+					DynamicRange("192.168.0.50", "192.168.0.150").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because dynamic range %s-%s overlaps with pool range %s-%s", testIPPoolNamespace, testIPPoolName, "192.168.0.50", "192.168.0.150", "192.168.0.10", "192.168.0.100"),
+			},
+		},
+		{
+			name: "invalid dynamic range which overlaps with the default pool range",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DynamicRange("192.168.0.200", "192.168.0.250").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because dynamic range %s-%s overlaps with pool range %s-%s", testIPPoolNamespace, testIPPoolName, "192.168.0.200", "192.168.0.250", "192.168.0.1", "192.168.0.254"),
+			},
+		},
+		{
+			name: "invalid dynamic range which is out of subnet",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					PoolRange("192.168.0.10", "192.168.0.100").
+					DynamicRange("192.168.0.200", "192.168.1.50").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because dynamic range end ip %s is not within subnet", testIPPoolNamespace, testIPPoolName, "192.168.1.50"),
+			},
+		},
+		{
+			name: "invalid dynamic range which contains the server ip",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					PoolRange("192.168.0.10", "192.168.0.100").
+					ServerIP("192.168.0.2").
+					DynamicRange("192.168.0.1", "192.168.0.9").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because dynamic range %s-%s contains ip %s of the server or the router", testIPPoolNamespace, testIPPoolName, "192.168.0.1", "192.168.0.9", "192.168.0.2"),
+			},
+		},
+		{
+			name: "invalid class which matches on nothing",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					Class(networkv1.ClientClass{Name: "pxe", Filename: "ipxe.efi"}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because class %s matches on neither vendor class, user class nor mac prefix", testIPPoolNamespace, testIPPoolName, "pxe"),
+			},
+		},
+		{
+			name: "invalid class with malformed mac prefix",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					Class(networkv1.ClientClass{Name: "qemu", MACPrefixes: []string{"52:54:0"}}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because mac prefix %s of class %s is not valid", testIPPoolNamespace, testIPPoolName, "52:54:0", "qemu"),
+			},
+		},
+		{
+			name: "invalid ddns server with malformed port",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DDNS(networkv1.DDNSConfig{Server: "192.168.0.53:dns", Zone: "example.com"}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because ddns server %s has an invalid port", testIPPoolNamespace, testIPPoolName, "192.168.0.53:dns"),
+			},
+		},
+		{
+			name: "invalid ddns reverse zone outside of in-addr.arpa",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DomainName("example.com").
+					DDNS(networkv1.DDNSConfig{Server: "192.168.0.53", Zone: "example.com", ReverseZone: "example.org"}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because ddns reverse zone %s is not within in-addr.arpa", testIPPoolNamespace, testIPPoolName, "example.org"),
+			},
+		},
+		{
+			name: "valid domain name within ddns zone",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DomainName("lab.example.com.").
+					DDNS(networkv1.DDNSConfig{Server: "192.168.0.53", Zone: "Example.com"}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+		},
+		{
+			name: "invalid ddns without domain name",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DDNS(networkv1.DDNSConfig{Server: "192.168.0.53", Zone: "example.com"}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because ddns requires a domain name", testIPPoolNamespace, testIPPoolName),
+			},
+		},
+		{
+			name: "invalid domain name outside of ddns zone",
+			given: input{
+				ipPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DomainName("example.org").
+					DDNS(networkv1.DDNSConfig{Server: "192.168.0.53", Zone: "example.com"}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot create IPPool %s/%s because domain name %s is not within ddns zone %s", testIPPoolNamespace, testIPPoolName, "example.org", "example.com"),
+			},
+		},
 		{
 			name: "invalid server ip which is the same as network ip",
 			given: input{
@@ -413,6 +989,72 @@ func TestValidator_Update(t *testing.T) {
 				err: fmt.Errorf("cannot update IPPool %s/%s because server ip %s is not within subnet", testIPPoolNamespace, testIPPoolName, testServerIPOutOfRange),
 			},
 		},
+		{
+			name: "invalid dynamic range whose end is less than its start",
+			given: input{
+				oldIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					NetworkName(testNetworkName).Build(),
+				newIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DynamicRange("192.168.0.200", "192.168.0.100").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot update IPPool %s/%s because dynamic range end ip %s is less than start ip %s", testIPPoolNamespace, testIPPoolName, "192.168.0.100", "192.168.0.200"),
+			},
+		},
+		{
+			name: "invalid dynamic range which overlaps with pool range",
+			given: input{
+				oldIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					NetworkName(testNetworkName).Build(),
+				newIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					PoolRange("192.168.0.10", "192.168.0.100").
+					DynamicRange("192.168.0.50", "192.168.0.150").
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot update IPPool %s/%s because dynamic range %s-%s overlaps with pool range %s-%s", testIPPoolNamespace, testIPPoolName, "192.168.0.50", "192.168.0.150", "192.168.0.10", "192.168.0.100"),
+			},
+		},
+		{
+			name: "invalid duplicated class",
+			given: input{
+				oldIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					NetworkName(testNetworkName).Build(),
+				newIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					Class(networkv1.ClientClass{Name: "windows", VendorClasses: []string{"MSFT"}}).
+					Class(networkv1.ClientClass{Name: "windows", MACPrefixes: []string{"00:15:5d"}}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot update IPPool %s/%s because class %s is duplicated", testIPPoolNamespace, testIPPoolName, "windows"),
+			},
+		},
+		{
+			name: "invalid ddns zone",
+			given: input{
+				oldIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					NetworkName(testNetworkName).Build(),
+				newIPPool: newTestIPPoolBuilder().
+					CIDR("192.168.0.0/24").
+					DDNS(networkv1.DDNSConfig{Server: "192.168.0.53", Zone: "example..com"}).
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().Build(),
+			},
+			expected: output{
+				err: fmt.Errorf("cannot update IPPool %s/%s because ddns zone %s is not a valid domain name", testIPPoolNamespace, testIPPoolName, "example..com"),
+			},
+		},
 		{
 			name: "invalid server ip which is the same as network ip",
 			given: input{
@@ -1,14 +1,18 @@
 package vmnetcfg
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	networkv1 "github.com/harvester/vm-dhcp-controller/pkg/apis/network.harvesterhci.io/v1alpha1"
 	ctlcniv1 "github.com/harvester/vm-dhcp-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlnetworkv1 "github.com/harvester/vm-dhcp-controller/pkg/generated/controllers/network.harvesterhci.io/v1alpha1"
+	"github.com/harvester/vm-dhcp-controller/pkg/indexer"
 	"github.com/harvester/vm-dhcp-controller/pkg/util"
 	"github.com/harvester/vm-dhcp-controller/pkg/webhook"
 	"github.com/harvester/webhook/pkg/server/admission"
@@ -19,14 +23,20 @@ import (
 type Validator struct {
 	admission.DefaultValidator
 
-	ippoolCache ctlnetworkv1.IPPoolCache
-	nadCache    ctlcniv1.NetworkAttachmentDefinitionCache
+	ippoolCache   ctlnetworkv1.IPPoolCache
+	nadCache      ctlcniv1.NetworkAttachmentDefinitionCache
+	vmnetcfgCache ctlnetworkv1.VirtualMachineNetworkConfigCache
 }
 
-func NewValidator(ippoolCache ctlnetworkv1.IPPoolCache, nadCache ctlcniv1.NetworkAttachmentDefinitionCache) *Validator {
+func NewValidator(
+	ippoolCache ctlnetworkv1.IPPoolCache,
+	nadCache ctlcniv1.NetworkAttachmentDefinitionCache,
+	vmnetcfgCache ctlnetworkv1.VirtualMachineNetworkConfigCache,
+) *Validator {
 	return &Validator{
-		ippoolCache: ippoolCache,
-		nadCache:    nadCache,
+		ippoolCache:   ippoolCache,
+		nadCache:      nadCache,
+		vmnetcfgCache: vmnetcfgCache,
 	}
 }
 
@@ -34,6 +44,10 @@ func (v *Validator) Create(request *admission.Request, newObj runtime.Object) er
 	vmNetCfg := newObj.(*networkv1.VirtualMachineNetworkConfig)
 	logrus.Infof("create vmnetcfg %s/%s", vmNetCfg.Namespace, vmNetCfg.Name)
 
+	if err := checkHostname(vmNetCfg.Spec.Hostname); err != nil {
+		return fmt.Errorf(webhook.CreateErr, vmNetCfg.Kind, vmNetCfg.Namespace, vmNetCfg.Name, err)
+	}
+
 	for _, nc := range vmNetCfg.Spec.NetworkConfigs {
 		nadNamespace, nadName := kv.RSplit(nc.NetworkName, "/")
 		if nadNamespace == "" {
@@ -65,8 +79,104 @@ func (v *Validator) Create(request *admission.Request, newObj runtime.Object) er
 		if _, err := v.ippoolCache.Get(ipPoolNamespace, ipPoolName); err != nil {
 			return fmt.Errorf(webhook.CreateErr, vmNetCfg.Kind, vmNetCfg.Namespace, vmNetCfg.Name, err)
 		}
+		if err := util.CheckCustomOptions(nc.CustomOptions); err != nil {
+			return fmt.Errorf(webhook.CreateErr, vmNetCfg.Kind, vmNetCfg.Namespace, vmNetCfg.Name, err)
+		}
+	}
+
+	if err := v.checkClientIDs(vmNetCfg); err != nil {
+		return fmt.Errorf(webhook.CreateErr, vmNetCfg.Kind, vmNetCfg.Namespace, vmNetCfg.Name, err)
+	}
+
+	return nil
+}
+
+func (v *Validator) Update(_ *admission.Request, _, newObj runtime.Object) error {
+	vmNetCfg := newObj.(*networkv1.VirtualMachineNetworkConfig)
+
+	if vmNetCfg.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if err := checkHostname(vmNetCfg.Spec.Hostname); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, vmNetCfg.Kind, vmNetCfg.Namespace, vmNetCfg.Name, err)
+	}
+
+	for _, nc := range vmNetCfg.Spec.NetworkConfigs {
+		if err := util.CheckCustomOptions(nc.CustomOptions); err != nil {
+			return fmt.Errorf(webhook.UpdateErr, vmNetCfg.Kind, vmNetCfg.Namespace, vmNetCfg.Name, err)
+		}
+	}
+
+	if err := v.checkClientIDs(vmNetCfg); err != nil {
+		return fmt.Errorf(webhook.UpdateErr, vmNetCfg.Kind, vmNetCfg.Namespace, vmNetCfg.Name, err)
+	}
+
+	return nil
+}
+
+// checkClientIDs makes sure the client identifiers of the NetworkConfigs are
+// well-formed and not used by any other NIC on the same network, as the agent
+// matches the leases by them.
+func (v *Validator) checkClientIDs(vmNetCfg *networkv1.VirtualMachineNetworkConfig) error {
+	for i, nc := range vmNetCfg.Spec.NetworkConfigs {
+		if nc.ClientID == nil {
+			continue
+		}
+		clientID, err := normalizeClientID(*nc.ClientID)
+		if err != nil {
+			return err
+		}
+
+		for _, other := range vmNetCfg.Spec.NetworkConfigs[i+1:] {
+			if other.NetworkName == nc.NetworkName && other.ClientID != nil && sameClientID(*other.ClientID, clientID) {
+				return fmt.Errorf("client id %s is used by both %s and %s", *nc.ClientID, nc.MACAddress, other.MACAddress)
+			}
+		}
+
+		others, err := v.vmnetcfgCache.GetByIndex(indexer.VmNetCfgByNetworkIndex, nc.NetworkName)
+		if err != nil {
+			return err
+		}
+		for _, otherVmNetCfg := range others {
+			if otherVmNetCfg.Namespace == vmNetCfg.Namespace && otherVmNetCfg.Name == vmNetCfg.Name {
+				continue
+			}
+			for _, other := range otherVmNetCfg.Spec.NetworkConfigs {
+				if other.NetworkName == nc.NetworkName && other.ClientID != nil && sameClientID(*other.ClientID, clientID) {
+					return fmt.Errorf("client id %s is already used by %s of vmnetcfg %s/%s",
+						*nc.ClientID, other.MACAddress, otherVmNetCfg.Namespace, otherVmNetCfg.Name)
+				}
+			}
+		}
 	}
+	return nil
+}
 
+// normalizeClientID returns the client identifier in lower-case hex without
+// separators.
+func normalizeClientID(clientID string) (string, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(clientID, ":", ""))
+	if b, err := hex.DecodeString(normalized); err != nil || len(b) == 0 {
+		return "", fmt.Errorf("client id %s is not valid", clientID)
+	}
+	return normalized, nil
+}
+
+func sameClientID(clientID, normalized string) bool {
+	other, err := normalizeClientID(clientID)
+	return err == nil && other == normalized
+}
+
+// checkHostname checks whether the host name is a single DNS label, as the
+// domain name is appended from the IPPool.
+func checkHostname(hostname string) error {
+	if hostname == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(hostname); len(errs) > 0 {
+		return fmt.Errorf("hostname %s is invalid: %s", hostname, strings.Join(errs, ", "))
+	}
 	return nil
 }
 
@@ -79,6 +189,7 @@ func (v *Validator) Resource() admission.Resource {
 		ObjectType: &networkv1.VirtualMachineNetworkConfig{},
 		OperationTypes: []admissionregv1.OperationType{
 			admissionregv1.Create,
+			admissionregv1.Update,
 		},
 	}
 }
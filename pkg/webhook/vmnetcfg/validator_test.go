@@ -84,6 +84,38 @@ func TestValidator_Create(t *testing.T) {
 				shouldErr: false,
 			},
 		},
+		{
+			name: "custom options",
+			given: input{
+				vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+					WithNetworkConfig("", "", testNetworkName).
+					WithCustomOption(26, networkv1.CustomOptionTypeUint16, "9000").Build(),
+				ipPool: newTestIPPoolBuilder().
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().
+					Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+					Label(util.IPPoolNameLabelKey, testIPPoolName).Build(),
+			},
+			expected: output{
+				shouldErr: false,
+			},
+		},
+		{
+			name: "malformed custom option",
+			given: input{
+				vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+					WithNetworkConfig("", "", testNetworkName).
+					WithCustomOption(26, networkv1.CustomOptionTypeUint16, "90000").Build(),
+				ipPool: newTestIPPoolBuilder().
+					NetworkName(testNetworkName).Build(),
+				nad: newTestNetworkAttachmentDefinitionBuilder().
+					Label(util.IPPoolNamespaceLabelKey, testIPPoolNamespace).
+					Label(util.IPPoolNameLabelKey, testIPPoolName).Build(),
+			},
+			expected: output{
+				shouldErr: true,
+			},
+		},
 		{
 			name: "non-existed ippool referenced",
 			given: input{
@@ -155,9 +187,105 @@ func TestValidator_Create(t *testing.T) {
 
 		ipPoolCache := fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools)
 		nadCache := fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
-		validator := NewValidator(ipPoolCache, nadCache)
+		vmNetCfgCache := fakeclient.VirtualMachineNetworkConfigCache(clientset.NetworkV1alpha1().VirtualMachineNetworkConfigs)
+		validator := NewValidator(ipPoolCache, nadCache, vmNetCfgCache)
 
 		err := validator.Create(&admission.Request{}, tc.given.vmNetCfg)
 		assert.Equal(t, tc.expected.shouldErr, err != nil, tc.name)
 	}
 }
+
+func TestValidator_Update(t *testing.T) {
+	testCases := []struct {
+		name      string
+		vmNetCfg  *networkv1.VirtualMachineNetworkConfig
+		existing  *networkv1.VirtualMachineNetworkConfig
+		shouldErr bool
+	}{
+		{
+			name: "custom options",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithNetworkConfig("", "", testNetworkName).
+				WithCustomOption(252, networkv1.CustomOptionTypeString, "http://wpad.example.com/wpad.dat").Build(),
+		},
+		{
+			name: "reserved custom option code",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithNetworkConfig("", "", testNetworkName).
+				WithCustomOption(54, networkv1.CustomOptionTypeIP, "192.168.0.2").Build(),
+			shouldErr: true,
+		},
+		{
+			name: "hostname",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithHostname("web-01").
+				WithNetworkConfig("", "", testNetworkName).Build(),
+		},
+		{
+			name: "hostname which is not a dns label",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithHostname("web_01.example.com").
+				WithNetworkConfig("", "", testNetworkName).Build(),
+			shouldErr: true,
+		},
+		{
+			name: "client id",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithNetworkConfig("", "11:22:33:44:55:66", testNetworkName).
+				WithClientID("01:11:22:33:44:55:66").Build(),
+			existing: vmnetcfg.NewVmNetCfgBuilder(testVmNetCfgNamespace, "other-vmnetcfg").
+				WithNetworkConfig("", "11:22:33:44:55:77", testNetworkName).
+				WithClientID("01:11:22:33:44:55:77").Build(),
+		},
+		{
+			name: "malformed client id",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithNetworkConfig("", "11:22:33:44:55:66", testNetworkName).
+				WithClientID("client-1").Build(),
+			shouldErr: true,
+		},
+		{
+			name: "client id used twice in the same vmnetcfg",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithNetworkConfig("", "11:22:33:44:55:66", testNetworkName).
+				WithClientID("01:11:22:33:44:55:66").
+				WithNetworkConfig("", "11:22:33:44:55:77", testNetworkName).
+				WithClientID("01112233445566").Build(),
+			shouldErr: true,
+		},
+		{
+			name: "client id used by another vmnetcfg on the same network",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithNetworkConfig("", "11:22:33:44:55:66", testNetworkName).
+				WithClientID("01:11:22:33:44:55:66").Build(),
+			existing: vmnetcfg.NewVmNetCfgBuilder(testVmNetCfgNamespace, "other-vmnetcfg").
+				WithNetworkConfig("", "11:22:33:44:55:77", testNetworkName).
+				WithClientID("01:11:22:33:44:55:66").Build(),
+			shouldErr: true,
+		},
+		{
+			name: "client id used by another vmnetcfg on a different network",
+			vmNetCfg: newTestVirtualMachineNetworkConfigBuilder().
+				WithNetworkConfig("", "11:22:33:44:55:66", testNetworkName).
+				WithClientID("01:11:22:33:44:55:66").Build(),
+			existing: vmnetcfg.NewVmNetCfgBuilder(testVmNetCfgNamespace, "other-vmnetcfg").
+				WithNetworkConfig("", "11:22:33:44:55:77", "default/other-net").
+				WithClientID("01:11:22:33:44:55:66").Build(),
+		},
+	}
+
+	for _, tc := range testCases {
+		clientset := fake.NewSimpleClientset()
+		if tc.existing != nil {
+			err := clientset.Tracker().Add(tc.existing)
+			assert.NoError(t, err, "mock resource should add into fake controller tracker")
+		}
+		ipPoolCache := fakeclient.IPPoolCache(clientset.NetworkV1alpha1().IPPools)
+		nadCache := fakeclient.NetworkAttachmentDefinitionCache(clientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
+		vmNetCfgCache := fakeclient.VirtualMachineNetworkConfigCache(clientset.NetworkV1alpha1().VirtualMachineNetworkConfigs)
+		validator := NewValidator(ipPoolCache, nadCache, vmNetCfgCache)
+
+		err := validator.Update(&admission.Request{}, tc.vmNetCfg, tc.vmNetCfg)
+		assert.Equal(t, tc.shouldErr, err != nil, tc.name)
+	}
+}